@@ -0,0 +1,168 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const REDACTED = "[REDACTED]"
+
+var (
+	// headers and query parameters AccessLog redacts unless allowed
+	DEFAULT_REDACT_HEADERS = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token", "X-Csrf-Token"}
+	DEFAULT_REDACT_PARAMS  = []string{"access_token", "api_key", "apikey", "code", "key", "password", "secret", "signature", "sig", "token"}
+)
+
+// AccessLog writes one entry per request served by the handlers it wraps,
+// with method, path, query, status, size, duration and remote address:
+// info level, error for 5xx responses, after the fields NewContext attached
+// to the request context. The values of sensitive headers and
+// query parameters (DEFAULT_REDACT_HEADERS, DEFAULT_REDACT_PARAMS) are
+// replaced by REDACTED unless listed in Allow.
+type AccessLog struct {
+	Logger *Logger
+	// Headers lists the request headers to log as header.<Name> fields,
+	// "*" for all of them
+	Headers []string
+	// Allow lists headers and query parameters logged as they are although
+	// they are redacted by default (case-insensitive)
+	Allow []string
+}
+
+// AccessLog is a Middleware logging every request to l with the default
+// redaction.
+func (l *Logger) AccessLog(next http.Handler) http.Handler {
+	a := &AccessLog{Logger: l}
+	return a.Middleware(next)
+}
+
+func (a *AccessLog) Middleware(next http.Handler) http.Handler {
+	redactHeaders := a.redactSet(DEFAULT_REDACT_HEADERS)
+	redactParams := a.redactSet(DEFAULT_REDACT_PARAMS)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		// e.g. the request ID of RequestIDMiddleware
+		fields := append([]Field(nil), ContextFields(r.Context())...)
+		fields = append(fields,
+			Field{"method", r.Method},
+			Field{"path", r.URL.Path},
+		)
+		if r.URL.RawQuery != "" {
+			fields = append(fields, Field{"query", redactQuery(r.URL.Query(), redactParams)})
+		}
+		fields = append(fields,
+			Field{"status", sw.status},
+			Field{"bytes", sw.bytes},
+			Field{"duration", time.Since(start)},
+			Field{"remote", r.RemoteAddr},
+		)
+		for _, name := range a.headerNames(r.Header) {
+			v := strings.Join(r.Header.Values(name), ", ")
+			if redactHeaders[strings.ToLower(name)] {
+				v = REDACTED
+			}
+			fields = append(fields, Field{"header." + name, v})
+		}
+
+		t := LOG_INFO
+		if sw.status >= 500 {
+			t = LOG_ERROR
+		}
+		a.Logger.logFields(t, "http request", fields)
+	})
+}
+
+func (a *AccessLog) redactSet(defaults []string) map[string]bool {
+	m := make(map[string]bool, len(defaults))
+	for _, k := range defaults {
+		m[strings.ToLower(k)] = true
+	}
+	for _, k := range a.Allow {
+		delete(m, strings.ToLower(k))
+	}
+	return m
+}
+
+func (a *AccessLog) headerNames(h http.Header) []string {
+	var names []string
+	for _, name := range a.Headers {
+		if name != "*" {
+			if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+			continue
+		}
+		names = names[:0]
+		for k := range h {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		break
+	}
+	return names
+}
+
+func redactQuery(q url.Values, redact map[string]bool) string {
+	for k := range q {
+		if redact[strings.ToLower(k)] {
+			q[k] = []string{REDACTED}
+		}
+	}
+	// Encode escapes the brackets of REDACTED, keep them readable
+	return strings.ReplaceAll(q.Encode(), url.QueryEscape(REDACTED), REDACTED)
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.status = status
+		w.wrote = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wrote = true
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush lets streaming handlers flush through the wrapper.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets websocket and other upgrading handlers take over the
+// connection through the wrapper.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("log: response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap gives http.ResponseController the wrapped writer, for deadlines
+// and the other optional interfaces.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessLogPassesInterfaces(t *testing.T) {
+	var hijacked, flushed bool
+	done := make(chan struct{})
+	h := NewLogger(io.Discard, "", 0).AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); !ok || u.Unwrap() == nil {
+			t.Error("wrapper cannot be unwrapped")
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+			flushed = true
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		hijacked = true
+		conn.Close()
+	}))
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	bufio.NewReader(conn).ReadString('\n')
+	<-done
+
+	if !flushed || !hijacked {
+		t.Errorf("flushed %v, hijacked %v through the wrapper", flushed, hijacked)
+	}
+}
@@ -0,0 +1,71 @@
+package log
+
+// ALERT_SEVERITY is the key of the business severity of an entry, which is
+// independent of its level: an info entry can record a critical business
+// event. Sinks can route on it with RouteAlert.
+const ALERT_SEVERITY = "alert:severity"
+
+// alert severities used by the incident tooling
+const (
+	ALERT_LOW      = "low"
+	ALERT_MEDIUM   = "medium"
+	ALERT_HIGH     = "high"
+	ALERT_CRITICAL = "critical"
+)
+
+// AlertField returns the alert:severity field.
+func AlertField(severity string) Field {
+	return Field{ALERT_SEVERITY, severity}
+}
+
+// Alert writes msg at level t with an alert:severity field, e.g.
+// l.Alert(LOG_INFO, ALERT_CRITICAL, "payment lost", "order", id). fields
+// are alternating keys and values, or Fields.
+func (l *Logger) Alert(t LogType, severity, msg string, fields ...interface{}) {
+	if !l.enabled(t) {
+		return
+	}
+	l.logFields(t, msg, append([]Field{AlertField(severity)}, toFields(fields)...))
+}
+
+// AlertSeverity returns the alert:severity of e, "" if it has none.
+func (e *Entry) AlertSeverity() string {
+	for _, f := range e.Fields {
+		if f.Key == ALERT_SEVERITY {
+			if s, ok := f.Value.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// RouteLevel only sends the sink entries enabled at level. Combined with
+// RouteAlert the sink gets entries matching either.
+func RouteLevel(level LogLevel) SinkOption {
+	return func(c *sinkConfig) {
+		c.levels = level
+		c.routed = true
+	}
+}
+
+// RouteAlert only sends the sink entries with one of the given
+// alert:severity values. Combined with RouteLevel the sink gets entries
+// matching either.
+func RouteAlert(severities ...string) SinkOption {
+	return func(c *sinkConfig) {
+		c.alerts = keySet(c.alerts, severities)
+		c.routed = true
+	}
+}
+
+// routes reports whether e goes to the sink.
+func (c *sinkConfig) routes(e *Entry) bool {
+	if !c.routed {
+		return true
+	}
+	if c.levels|LogLevel(e.Level) == c.levels && c.levels != LOG_LEVEL_NONE {
+		return true
+	}
+	return c.alerts[e.AlertSeverity()]
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAlert(t *testing.T) {
+	l, b := jsonLogger()
+	l.Alert(LOG_INFO, ALERT_CRITICAL, "payment lost", "order", 7)
+
+	got := decodeLines(t, b)
+	if len(got) != 1 || got[0]["level"] != "info" || got[0][ALERT_SEVERITY] != ALERT_CRITICAL || got[0]["order"] != float64(7) {
+		t.Errorf("entries = %v", got)
+	}
+	e := &Entry{Fields: []Field{{"order", 7}, AlertField(ALERT_LOW)}}
+	if s := e.AlertSeverity(); s != ALERT_LOW {
+		t.Errorf("AlertSeverity = %q", s)
+	}
+	if s := (&Entry{Fields: []Field{{ALERT_SEVERITY, 3}}}).AlertSeverity(); s != "" {
+		t.Errorf("AlertSeverity of a non-string = %q", s)
+	}
+}
+
+func TestRouteAlert(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	all, errs, pager, both := &entrySink{}, &entrySink{}, &entrySink{}, &entrySink{}
+	l.AddSink(all)
+	l.AddSink(errs, RouteLevel(LOG_LEVEL_ERROR))
+	l.AddSink(pager, RouteAlert(ALERT_HIGH, ALERT_CRITICAL))
+	l.AddSink(both, RouteLevel(LOG_LEVEL_ERROR), RouteAlert(ALERT_CRITICAL))
+
+	l.Info("started")
+	l.Error("failed")
+	l.Alert(LOG_INFO, ALERT_CRITICAL, "payment lost")
+	l.Alert(LOG_WARNING, ALERT_LOW, "slow checkout")
+
+	for _, tt := range []struct {
+		name string
+		s    *entrySink
+		want string
+	}{
+		{"unrouted", all, "started failed payment lost slow checkout"},
+		{"level", errs, "failed"},
+		{"alert", pager, "payment lost"},
+		{"level or alert", both, "failed payment lost"},
+	} {
+		var got []string
+		for _, m := range tt.s.msgs {
+			got = append(got, strings.TrimSpace(m))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%s sink got %q, want %q", tt.name, s, tt.want)
+		}
+	}
+}
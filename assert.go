@@ -0,0 +1,36 @@
+package log
+
+// Assert logs msg at error level with fields when cond is false. In development mode it panics after logging.
+func (l *Logger) Assert(cond bool, msg string, fields ...interface{}) {
+	if !cond {
+		l.assertFailed(msg, fields)
+	}
+}
+
+// Assert calls Assert on the default logger.
+func Assert(cond bool, msg string, fields ...interface{}) {
+	if !cond {
+		Default().assertFailed(msg, fields)
+	}
+}
+
+// SetDevelopment makes failed assertions panic instead of only logging.
+func (l *Logger) SetDevelopment(on bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.Development = on
+	r.lock.Unlock()
+}
+
+func (l *Logger) assertFailed(msg string, args []interface{}) {
+	// skip assertFailed and Assert
+	l.LogFieldsSkip(2, LOG_ERROR, "assertion failed: "+msg, toFields(args)...)
+
+	r := l.root()
+	r.lock.Lock()
+	dev := r.Development
+	r.lock.Unlock()
+	if dev {
+		panic("assertion failed: " + msg)
+	}
+}
@@ -0,0 +1,33 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestAssert(t *testing.T) {
+	l, b := jsonLogger()
+	l.Assert(true, "holds")
+	l.Assert(false, "broken", "k", 1)
+
+	got := decodeLines(t, b)
+	if len(got) != 1 {
+		t.Fatalf("%d entries, want 1", len(got))
+	}
+	if got[0]["level"] != "error" || got[0]["message"] != "assertion failed: broken" || got[0]["k"] != float64(1) {
+		t.Errorf("entry = %v", got[0])
+	}
+}
+
+func TestAssertDevelopmentPanics(t *testing.T) {
+	l, b := jsonLogger()
+	l.Named("child").SetDevelopment(true)
+	defer func() {
+		if recover() == nil {
+			t.Error("failed assertion did not panic in development mode")
+		}
+		if len(decodeLines(t, b)) != 1 {
+			t.Error("failed assertion not logged before the panic")
+		}
+	}()
+	l.Assert(false, "broken")
+}
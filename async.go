@@ -0,0 +1,197 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// default number of entries an AsyncWriter queues
+const DEFAULT_ASYNC_QUEUE = 1024
+
+// AsyncWriter queues entries and writes them to W from a background
+// goroutine, so logging calls do not wait for the disk. Writers block when
+// the queue is full. Flush waits for the queue to drain, Close drains it
+// and closes W.
+type AsyncWriter struct {
+	W io.Writer
+	// OnError gets the errors of background writes, stderr if nil
+	OnError func(err error)
+
+	queue   chan asyncItem
+	pending int64 // accessed atomically
+	done    chan struct{}
+	closed  bool
+	lock    sync.RWMutex
+}
+
+// asyncItem is an entry to write, or a flush marker
+type asyncItem struct {
+	buf     *Buffer
+	flushed chan struct{}
+}
+
+// NewAsyncWriter starts writing to w with a queue of size entries,
+// DEFAULT_ASYNC_QUEUE if 0.
+func NewAsyncWriter(w io.Writer, size int) *AsyncWriter {
+	if size <= 0 {
+		size = DEFAULT_ASYNC_QUEUE
+	}
+	a := &AsyncWriter{W: w, queue: make(chan asyncItem, size), done: make(chan struct{})}
+	go a.run()
+	return a
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	for item := range a.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		_, err := item.buf.WriteTo(a.W)
+		item.buf.Free()
+		atomic.AddInt64(&a.pending, -1)
+		if err != nil {
+			a.error(err)
+		}
+	}
+}
+
+func (a *AsyncWriter) error(err error) {
+	if a.OnError != nil {
+		a.OnError(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "log: async write: %s\n", err)
+}
+
+// WriteBuffer queues b; the AsyncWriter frees it once written.
+func (a *AsyncWriter) WriteBuffer(b *Buffer) error {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	if a.closed {
+		b.Free()
+		return os.ErrClosed
+	}
+	atomic.AddInt64(&a.pending, 1)
+	a.queue <- asyncItem{buf: b}
+	return nil
+}
+
+// Write queues a copy of p.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	b := getBuffer()
+	b.b = append(b.b, p...)
+	err := a.WriteBuffer(b)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Pending returns the number of queued entries not written yet.
+func (a *AsyncWriter) Pending() int {
+	return int(atomic.LoadInt64(&a.pending))
+}
+
+// Flush waits until the entries queued before the call are written, then
+// syncs W if it is a Syncer.
+func (a *AsyncWriter) Flush(ctx context.Context) (int, error) {
+	flushed := make(chan struct{})
+	a.lock.RLock()
+	closed := a.closed
+	if !closed {
+		select {
+		case a.queue <- asyncItem{flushed: flushed}:
+		case <-ctx.Done():
+			a.lock.RUnlock()
+			return a.Pending(), ctx.Err()
+		}
+	}
+	a.lock.RUnlock()
+	if closed {
+		return a.Pending(), os.ErrClosed
+	}
+
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+		return a.Pending(), ctx.Err()
+	}
+	if s, ok := a.W.(Syncer); ok && !isStdStream(s) {
+		return 0, s.Sync()
+	}
+	return 0, nil
+}
+
+// Close writes what is queued and closes W if it is an io.Closer other than
+// the standard streams.
+func (a *AsyncWriter) Close() error {
+	if !a.stop() {
+		return nil
+	}
+	if c, ok := a.W.(io.Closer); ok && !isStdStream(c) {
+		return c.Close()
+	}
+	return nil
+}
+
+// stop drains the queue and ends the goroutine, leaving W open. It reports
+// whether a was still running.
+func (a *AsyncWriter) stop() bool {
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return false
+	}
+	a.closed = true
+	close(a.queue)
+	a.lock.Unlock()
+
+	<-a.done
+	return true
+}
+
+// SetAsync makes every logger sharing l's root write through an
+// AsyncWriter queueing up to queue entries; 0 goes back to writing on the
+// logging goroutine, after writing what is queued. Use Flush or Close
+// before exiting so queued entries are not lost.
+func (l *Logger) SetAsync(queue int) {
+	r := l.root()
+	r.lazyInit()
+	r.AsyncQueue = queue
+
+	out := r._log.Writer()
+	a, async := out.(*AsyncWriter)
+	switch {
+	case queue > 0 && !async:
+		r.SetOutput(r.newAsyncWriter(out))
+	case queue <= 0 && async:
+		r.SetOutput(a.W)
+		a.stop()
+	}
+}
+
+// drainAsync writes what is queued before the process exits.
+func (l *Logger) drainAsync() {
+	if a, ok := l.root()._log.Writer().(*AsyncWriter); ok {
+		a.Flush(context.Background())
+	}
+}
+
+func (l *Logger) newAsyncWriter(w io.Writer) *AsyncWriter {
+	a := NewAsyncWriter(w, l.AsyncQueue)
+	a.OnError = func(err error) {
+		atomic.AddInt64(&l.counters.writeErrors, 1)
+		if err != ErrQuotaExceeded {
+			l.handleError(&WriteError{"output " + l.outputName(), err})
+		}
+	}
+	return a
+}
@@ -0,0 +1,157 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter sleeps before every write and fails with err if set.
+type slowWriter struct {
+	delay time.Duration
+	err   error
+
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.String()
+}
+
+// within fails the test unless fn returns within a second.
+func within(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestAsyncErrorOnFullQueue(t *testing.T) {
+	w := &slowWriter{delay: 20 * time.Millisecond, err: errors.New("disk full")}
+	l := NewLogger(w, "", 0)
+	var lock sync.Mutex
+	errs := 0
+	l.SetErrorHandler(func(error) {
+		lock.Lock()
+		errs++
+		lock.Unlock()
+	})
+	l.SetAsync(1)
+
+	within(t, "Info on a full queue with failing writes", func() {
+		for i := 0; i < 5; i++ {
+			l.Info("x")
+		}
+		l.Flush(context.Background())
+	})
+	lock.Lock()
+	defer lock.Unlock()
+	if errs != 5 {
+		t.Errorf("error handler called %d times, want 5", errs)
+	}
+}
+
+func TestAsyncFlushAndCloseDrain(t *testing.T) {
+	w := &slowWriter{delay: time.Millisecond}
+	a := NewAsyncWriter(w, 100)
+	for i := 0; i < 20; i++ {
+		a.Write([]byte("a\n"))
+	}
+	if _, err := a.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(w.String(), "a\n"); n != 20 || a.Pending() != 0 {
+		t.Errorf("after Flush: %d written, %d pending, want 20 and 0", n, a.Pending())
+	}
+
+	for i := 0; i < 20; i++ {
+		a.Write([]byte("b\n"))
+	}
+	a.Close()
+	if n := strings.Count(w.String(), "b\n"); n != 20 {
+		t.Errorf("after Close: %d written, want 20", n)
+	}
+	if _, err := a.Write([]byte("c\n")); err == nil {
+		t.Error("write after Close succeeded")
+	}
+}
+
+func TestSetAsyncToggle(t *testing.T) {
+	w := &slowWriter{}
+	l := NewLogger(w, "", 0)
+
+	l.SetAsync(10)
+	if _, ok := l._log.Writer().(*AsyncWriter); !ok {
+		t.Fatal("SetAsync did not install an AsyncWriter")
+	}
+	l.Named("child").Info("queued")
+
+	// going back writes what is queued first
+	l.SetAsync(0)
+	if l._log.Writer() != w {
+		t.Fatalf("output after SetAsync(0) = %T, want the writer", l._log.Writer())
+	}
+	if !strings.Contains(w.String(), "queued") {
+		t.Errorf("queued entry lost: %q", w.String())
+	}
+	l.Info("direct")
+	if !strings.Contains(w.String(), "direct") {
+		t.Errorf("synchronous entry missing: %q", w.String())
+	}
+}
+
+func TestAsyncQueueFullBlocks(t *testing.T) {
+	w := &slowWriter{delay: 50 * time.Millisecond}
+	a := NewAsyncWriter(w, 1)
+	defer a.Close()
+
+	start := time.Now()
+	// one being written, one queued, the third waits for room
+	for i := 0; i < 3; i++ {
+		a.Write([]byte("x\n"))
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("writes to a full queue returned after %v, want them to wait", d)
+	}
+}
+
+func TestAsyncOnError(t *testing.T) {
+	w := &slowWriter{err: errors.New("boom")}
+	a := NewAsyncWriter(w, 0)
+	got := make(chan error, 1)
+	a.OnError = func(err error) { got <- err }
+	a.Write([]byte("x\n"))
+	select {
+	case err := <-got:
+		if err != w.err {
+			t.Errorf("OnError got %v, want %v", err, w.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnError not called")
+	}
+	a.Close()
+}
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// BatchSink is implemented by sinks that write several entries at once
+// more cheaply than one by one, such as network and database sinks.
+// AsyncSink hands them its batches.
+type BatchSink interface {
+	Sink
+	WriteEntries(entries []*Entry) error
+}
+
+// AsyncSink queues entries and writes them to Sink from background workers,
+// so logging calls do not wait for slow sinks. Each worker takes up to a
+// batch of the entries queued at that moment. Writers block when the queue
+// is full. Flush waits for the queue to drain, Close drains it and closes
+// Sink.
+type AsyncSink struct {
+	Sink Sink
+	// OnError gets the errors of background writes, stderr if nil
+	OnError func(err error)
+
+	batch int
+	queue chan *Entry
+	wg    sync.WaitGroup
+
+	closed bool
+	lock   sync.RWMutex
+
+	pending int
+	// closed once pending gets to 0
+	idle        []chan struct{}
+	pendingLock sync.Mutex
+}
+
+// NewAsyncSink starts writing to s with a queue of queue entries
+// (DEFAULT_ASYNC_QUEUE if 0), workers goroutines (1 if 0) and batches of up
+// to batch entries (1 if 0). With several workers s must be safe for
+// concurrent use.
+func NewAsyncSink(s Sink, queue, workers, batch int) *AsyncSink {
+	if queue <= 0 {
+		queue = DEFAULT_ASYNC_QUEUE
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	if batch <= 0 {
+		batch = 1
+	}
+	a := &AsyncSink{Sink: s, batch: batch, queue: make(chan *Entry, queue)}
+	a.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go a.run()
+	}
+	return a
+}
+
+func (a *AsyncSink) run() {
+	defer a.wg.Done()
+
+	batch := make([]*Entry, 0, a.batch)
+	for e := range a.queue {
+		batch = append(batch[:0], e)
+	more:
+		for len(batch) < a.batch {
+			select {
+			case e, ok := <-a.queue:
+				if !ok {
+					break more
+				}
+				batch = append(batch, e)
+			default:
+				break more
+			}
+		}
+		a.write(batch)
+		a.done(len(batch))
+	}
+}
+
+func (a *AsyncSink) write(batch []*Entry) {
+	if b, ok := a.Sink.(BatchSink); ok && len(batch) > 1 {
+		a.error(b.WriteEntries(batch))
+		return
+	}
+	for _, e := range batch {
+		a.error(a.Sink.WriteEntry(e))
+	}
+}
+
+func (a *AsyncSink) error(err error) {
+	switch {
+	case err == nil:
+	case a.OnError != nil:
+		a.OnError(err)
+	default:
+		fmt.Fprintf(os.Stderr, "log: async sink: %s\n", err)
+	}
+}
+
+func (a *AsyncSink) done(n int) {
+	a.pendingLock.Lock()
+	a.pending -= n
+	if a.pending == 0 {
+		for _, c := range a.idle {
+			close(c)
+		}
+		a.idle = nil
+	}
+	a.pendingLock.Unlock()
+}
+
+// WriteEntry queues a copy of e.
+func (a *AsyncSink) WriteEntry(e *Entry) error {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	if a.closed {
+		return os.ErrClosed
+	}
+	a.pendingLock.Lock()
+	a.pending++
+	a.pendingLock.Unlock()
+	a.queue <- e.Clone()
+	return nil
+}
+
+func (a *AsyncSink) ConcurrentSafe() bool {
+	return true
+}
+
+// Pending returns the number of queued entries not written yet.
+func (a *AsyncSink) Pending() int {
+	a.pendingLock.Lock()
+	defer a.pendingLock.Unlock()
+	return a.pending
+}
+
+// Flush waits until the queue is drained, then flushes Sink if it is a
+// Flusher.
+func (a *AsyncSink) Flush(ctx context.Context) (int, error) {
+	a.pendingLock.Lock()
+	var idle chan struct{}
+	if a.pending > 0 {
+		idle = make(chan struct{})
+		a.idle = append(a.idle, idle)
+	}
+	a.pendingLock.Unlock()
+
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return a.Pending(), ctx.Err()
+		}
+	}
+	if f, ok := a.Sink.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return 0, nil
+}
+
+// Close writes what is queued and closes Sink if it is an io.Closer.
+func (a *AsyncSink) Close() error {
+	if !a.stop() {
+		return nil
+	}
+	if c, ok := a.Sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// stop drains the queue and ends the workers, leaving Sink open. It
+// reports whether a was still running.
+func (a *AsyncSink) stop() bool {
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return false
+	}
+	a.closed = true
+	close(a.queue)
+	a.lock.Unlock()
+
+	a.wg.Wait()
+	return true
+}
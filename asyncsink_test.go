@@ -0,0 +1,130 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchSink records the sizes of the batches it gets; writes wait for gate
+// if it is set.
+type batchSink struct {
+	gate chan struct{}
+	err  error
+
+	lock    sync.Mutex
+	batches []int
+	msgs    []string
+	closed  bool
+}
+
+func (s *batchSink) WriteEntry(e *Entry) error {
+	return s.WriteEntries([]*Entry{e})
+}
+
+func (s *batchSink) WriteEntries(entries []*Entry) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batches = append(s.batches, len(entries))
+	for _, e := range entries {
+		s.msgs = append(s.msgs, e.Message)
+	}
+	return s.err
+}
+
+func (s *batchSink) Close() error {
+	s.lock.Lock()
+	s.closed = true
+	s.lock.Unlock()
+	return nil
+}
+
+func TestAsyncOption(t *testing.T) {
+	s := &batchSink{gate: make(chan struct{})}
+	l := NewLogger(&slowWriter{}, "", 0)
+	l.AddSink(s, Async(16, 1, 4))
+
+	// the worker is stuck on the first entry, the others queue
+	within(t, "logging to a stuck async sink", func() {
+		for i := 0; i < 7; i++ {
+			l.Info("entry")
+		}
+	})
+	close(s.gate)
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s.lock.Lock()
+	// the rest was queued before the first batch was written
+	if len(s.msgs) != 7 || len(s.batches) < 2 || s.batches[0] > 4 || s.batches[1] != min(4, 7-s.batches[0]) {
+		t.Errorf("batches %v of %d entries, want batches of up to 4", s.batches, len(s.msgs))
+	}
+	s.lock.Unlock()
+
+	l.Info("last")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.msgs) != 8 || !s.closed {
+		t.Errorf("after Close: %d entries, closed %v", len(s.msgs), s.closed)
+	}
+}
+
+func TestAsyncOptionErrors(t *testing.T) {
+	s := &batchSink{err: errTest}
+	l := NewLogger(&slowWriter{}, "", 0)
+	errs := make(chan error, 4)
+	l.SetErrorHandler(func(err error) { errs <- err })
+	l.AddSink(s, Async(0, 0, 0))
+	l.Info("failing")
+	l.Flush(context.Background())
+
+	select {
+	case err := <-errs:
+		var we *WriteError
+		if !errors.As(err, &we) || we.Component != "sink 0 (*log.batchSink)" || !errors.Is(err, errTest) {
+			t.Errorf("error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("background error not reported")
+	}
+	l.Close()
+}
+
+func TestAsyncSink(t *testing.T) {
+	s := &batchSink{gate: make(chan struct{})}
+	a := NewAsyncSink(s, 4, 1, 1)
+	a.WriteEntry(&Entry{Message: "one"})
+	a.WriteEntry(&Entry{Message: "two"})
+	if n := a.Pending(); n != 2 {
+		t.Errorf("Pending = %d, want 2", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if n, err := a.Flush(ctx); n != 2 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Flush of a stuck sink = %d, %v", n, err)
+	}
+
+	close(s.gate)
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.msgs) != 2 || !s.closed || a.Pending() != 0 {
+		t.Errorf("after Close: %v, closed %v", s.msgs, s.closed)
+	}
+	if err := a.WriteEntry(&Entry{}); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after Close: %v", err)
+	}
+	if err := a.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// a typical request entry: about 200 bytes in every format
+func logRequest(l *Logger) {
+	l.LogFields(LOG_INFO, "request done",
+		F("method", "GET"),
+		F("path", "/api/v1/users/42"),
+		F("status", 200),
+		F("duration_ms", 12.5),
+		F("request_id", "4f1c2a9e-7b3d-4e8a-9c1f-0d2e3b4a5c6d"))
+}
+
+func benchLogger(b *testing.B, format string, w io.Writer) *Logger {
+	l := NewLogger(w, "", Ldate|Ltime|Lshortfile)
+	if err := l.SetFormat(format); err != nil {
+		b.Fatal(err)
+	}
+	return l
+}
+
+var benchFormats = []string{FORMAT_TEXT, FORMAT_JSON, FORMAT_LOGFMT}
+
+func BenchmarkEncode(b *testing.B) {
+	for _, format := range benchFormats {
+		b.Run(format, func(b *testing.B) {
+			l := benchLogger(b, format, io.Discard)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logRequest(l)
+			}
+		})
+	}
+}
+
+func BenchmarkEncodeParallel(b *testing.B) {
+	for _, format := range benchFormats {
+		b.Run(format, func(b *testing.B) {
+			l := benchLogger(b, format, io.Discard)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logRequest(l)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkBufferSize compares initial buffer capacities. The pool is
+// emptied first so every size starts from its own buffers.
+func BenchmarkBufferSize(b *testing.B) {
+	defer SetBufferSize(DEFAULT_BUFFER_SIZE)
+	for _, size := range []int{64, 128, 256, 512, 1024} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			SetBufferSize(size)
+			runtime.GC()
+			runtime.GC()
+			l := benchLogger(b, FORMAT_JSON, io.Discard)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logRequest(l)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkMaxPooledBufferSize logs one 32 KB entry every 100 entries:
+// below the maximum its buffer goes back to the pool, above it is
+// allocated again every time.
+func BenchmarkMaxPooledBufferSize(b *testing.B) {
+	defer SetMaxPooledBufferSize(MAX_POOLED_BUFFER_SIZE)
+	big := strings.Repeat("x", 32<<10)
+	for _, size := range []int{16 << 10, 64 << 10} {
+		b.Run(strconv.Itoa(size>>10)+"K", func(b *testing.B) {
+			SetMaxPooledBufferSize(size)
+			l := benchLogger(b, FORMAT_JSON, io.Discard)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if i%100 == 0 {
+					l.LogFields(LOG_INFO, "big", F("body", big))
+				} else {
+					logRequest(l)
+				}
+			}
+		})
+	}
+}
+
+// writerOnly hides the WriteBuffer method of its writer, so entries are
+// copied out of their buffer as for any io.Writer.
+type writerOnly struct {
+	io.Writer
+}
+
+// BenchmarkBufferHandoff compares handing the encoded buffer to an
+// AsyncWriter, which queues it as is, with copying it into a queued buffer
+// as before BufferWriter, for typical and 32 KB entries.
+func BenchmarkBufferHandoff(b *testing.B) {
+	big := strings.Repeat("x", 32<<10)
+	for _, size := range []string{"200B", "32K"} {
+		for _, name := range []string{"copy", "handoff"} {
+			b.Run(size+"/"+name, func(b *testing.B) {
+				a := NewAsyncWriter(io.Discard, 0)
+				defer a.Close()
+				var w io.Writer = a
+				if name == "copy" {
+					w = writerOnly{a}
+				}
+				l := benchLogger(b, FORMAT_JSON, w)
+				b.ReportAllocs()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if size == "32K" {
+							l.LogFields(LOG_INFO, "big", F("body", big))
+						} else {
+							logRequest(l)
+						}
+					}
+				})
+			})
+		}
+	}
+}
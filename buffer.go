@@ -0,0 +1,185 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// holds a typical entry of a few fields, about 200 bytes, without
+	// growing. Pooled buffers keep the capacity they grew to, so
+	// BenchmarkBufferSize shows no difference between 64 and 1024 once the
+	// pool is warm; a small size keeps idle buffers small.
+	DEFAULT_BUFFER_SIZE = 256
+	// buffers grown beyond this by one huge entry are not returned to the
+	// pool, see SetMaxPooledBufferSize. It is high enough to keep reusing the
+	// buffers of occasional large entries such as request bodies, see
+	// BenchmarkMaxPooledBufferSize, and low enough not to pin megabytes.
+	MAX_POOLED_BUFFER_SIZE = 64 << 10
+)
+
+var (
+	bufferSize       int64 = DEFAULT_BUFFER_SIZE
+	maxPooledBufSize int64 = MAX_POOLED_BUFFER_SIZE
+
+	// buffers kept across garbage collections, see SetBufferReserve
+	bufferReserve atomic.Value // chan *Buffer
+
+	bufferCounters struct {
+		gets, allocs, discarded int64
+	}
+)
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		atomic.AddInt64(&bufferCounters.allocs, 1)
+		return &Buffer{b: make([]byte, 0, atomic.LoadInt64(&bufferSize))}
+	},
+}
+
+// BufferStats are counters of the buffer pool shared by all loggers.
+type BufferStats struct {
+	Gets int64
+	// Allocs counts gets that had to allocate, Discarded buffers not
+	// returned to the pool because they grew past the maximum size
+	Allocs    int64
+	Discarded int64
+	// Reserved is the number of idle buffers in the reserve
+	Reserved int
+}
+
+// HitRate returns the share of gets served by a reused buffer.
+func (s BufferStats) HitRate() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+	return float64(s.Gets-s.Allocs) / float64(s.Gets)
+}
+
+// BufferPoolStats returns the counters of the buffer pool.
+func BufferPoolStats() BufferStats {
+	s := BufferStats{
+		Gets:      atomic.LoadInt64(&bufferCounters.gets),
+		Allocs:    atomic.LoadInt64(&bufferCounters.allocs),
+		Discarded: atomic.LoadInt64(&bufferCounters.discarded),
+	}
+	if r := reserve(); r != nil {
+		s.Reserved = len(r)
+	}
+	return s
+}
+
+// Buffer holds one encoded entry. It comes from a pool shared by all
+// loggers, so it must not be used after Free.
+type Buffer struct {
+	b []byte
+
+	// bytes accounted with the memory limit while queued
+	accounted int64
+}
+
+// BufferWriter is implemented by outputs that can take ownership of an
+// encoded entry instead of copying it out of the pool, e.g. to queue it.
+// The output must call Free on b once it is done with it.
+type BufferWriter interface {
+	WriteBuffer(b *Buffer) error
+}
+
+func (b *Buffer) Bytes() []byte {
+	return b.b
+}
+
+func (b *Buffer) Len() int {
+	return len(b.b)
+}
+
+func (b *Buffer) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(b.b)
+	return int64(n), err
+}
+
+// Free returns b to the pool.
+func (b *Buffer) Free() {
+	putBuffer(b)
+}
+
+// SetBufferSize sets the initial capacity of the pooled buffers entries are
+// encoded into. Services with long lines can raise it to avoid regrowing
+// buffers; it only affects buffers allocated after the call.
+func SetBufferSize(size int) {
+	if size <= 0 {
+		size = DEFAULT_BUFFER_SIZE
+	}
+	atomic.StoreInt64(&bufferSize, int64(size))
+}
+
+// SetMaxPooledBufferSize sets the capacity above which buffers are dropped
+// instead of going back to the pool, so one giant entry does not pin its
+// buffer for the life of the process. size <= 0 restores
+// MAX_POOLED_BUFFER_SIZE.
+func SetMaxPooledBufferSize(size int) {
+	if size <= 0 {
+		size = MAX_POOLED_BUFFER_SIZE
+	}
+	atomic.StoreInt64(&maxPooledBufSize, int64(size))
+}
+
+// SetBufferReserve keeps up to n buffers outside the sync.Pool, which is
+// emptied by garbage collections, so a steady load does not allocate again
+// after every cycle. The n buffers are allocated and touched right away. n
+// <= 0 removes the reserve.
+func SetBufferReserve(n int) {
+	if n <= 0 {
+		bufferReserve.Store((chan *Buffer)(nil))
+		return
+	}
+
+	r := make(chan *Buffer, n)
+	size := atomic.LoadInt64(&bufferSize)
+	for i := 0; i < n; i++ {
+		b := make([]byte, size)
+		for j := range b {
+			b[j] = 0
+		}
+		r <- &Buffer{b: b[:0]}
+	}
+	bufferReserve.Store(r)
+}
+
+func reserve() chan *Buffer {
+	r, _ := bufferReserve.Load().(chan *Buffer)
+	return r
+}
+
+func getBuffer() *Buffer {
+	atomic.AddInt64(&bufferCounters.gets, 1)
+	if r := reserve(); r != nil {
+		select {
+		case b := <-r:
+			return b
+		default:
+		}
+	}
+	return bufferPool.Get().(*Buffer)
+}
+
+func putBuffer(b *Buffer) {
+	if b.accounted > 0 {
+		memory.release(b.accounted)
+		b.accounted = 0
+	}
+	if int64(cap(b.b)) > atomic.LoadInt64(&maxPooledBufSize) {
+		atomic.AddInt64(&bufferCounters.discarded, 1)
+		return
+	}
+	b.b = b.b[:0]
+	if r := reserve(); r != nil {
+		select {
+		case r <- b:
+			return
+		default:
+		}
+	}
+	bufferPool.Put(b)
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestBufferReserve(t *testing.T) {
+	SetBufferReserve(2)
+	defer SetBufferReserve(0)
+	if n := BufferPoolStats().Reserved; n != 2 {
+		t.Fatalf("Reserved = %d, want 2", n)
+	}
+
+	a, b := getBuffer(), getBuffer()
+	if n := BufferPoolStats().Reserved; n != 0 {
+		t.Errorf("Reserved = %d after two gets", n)
+	}
+	if cap(a.b) != DEFAULT_BUFFER_SIZE || len(a.b) != 0 {
+		t.Errorf("reserved buffer len %d cap %d", len(a.b), cap(a.b))
+	}
+	a.b = append(a.b, "entry"...)
+	a.Free()
+	b.Free()
+	if n := BufferPoolStats().Reserved; n != 2 {
+		t.Errorf("Reserved = %d after Free, want 2", n)
+	}
+	if a = getBuffer(); len(a.b) != 0 {
+		t.Errorf("reused buffer holds %q", a.b)
+	}
+	a.Free()
+}
+
+func TestMaxPooledBufferSize(t *testing.T) {
+	SetMaxPooledBufferSize(1024)
+	defer SetMaxPooledBufferSize(0)
+
+	before := BufferPoolStats()
+	// pooled buffers may have grown in earlier tests
+	b := getBuffer()
+	b.b = make([]byte, 0, 2048)
+	b.Free()
+	small := getBuffer()
+	small.b = make([]byte, 0, 64)
+	small.Free()
+
+	s := BufferPoolStats()
+	if d := s.Discarded - before.Discarded; d != 1 {
+		t.Errorf("%d buffers discarded, want 1", d)
+	}
+	if g := s.Gets - before.Gets; g != 2 {
+		t.Errorf("%d gets counted, want 2", g)
+	}
+	if r := s.HitRate(); r < 0 || r > 1 {
+		t.Errorf("HitRate = %v", r)
+	}
+	if r := (BufferStats{}).HitRate(); r != 0 {
+		t.Errorf("HitRate without gets = %v", r)
+	}
+	if r := (BufferStats{Gets: 4, Allocs: 1}).HitRate(); r != 0.75 {
+		t.Errorf("HitRate = %v, want 0.75", r)
+	}
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+var (
+	buildOnce   sync.Once
+	buildFields []Field
+)
+
+// BuildInfo returns the main module version and the vcs.revision, vcs.time
+// and vcs.modified settings of the running binary. It is read once with
+// debug.ReadBuildInfo; values the toolchain did not record are left out.
+func BuildInfo() []Field {
+	return append([]Field(nil), buildInfoFields()...)
+}
+
+func buildInfoFields() []Field {
+	buildOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Main.Version != "" {
+			buildFields = append(buildFields, Field{"version", info.Main.Version})
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				buildFields = append(buildFields, Field{s.Key, s.Value})
+			}
+		}
+	})
+	return buildFields
+}
+
+// IncludeBuildInfo attaches BuildInfo to every entry.
+func (l *Logger) IncludeBuildInfo(on bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.BuildInfo = on
+	r.lock.Unlock()
+}
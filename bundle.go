@@ -0,0 +1,156 @@
+package log
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// how much of the active log file goes into a support bundle
+const BUNDLE_TAIL_SIZE = 256 << 10
+
+// SupportBundle writes a tar stream with the logger's effective
+// configuration (config.json), its Stats (stats.json), the entries held by
+// its MemorySinks (recent.log) and the tail of the active log file
+// (active.log), ready to attach to a bug report.
+func (l *Logger) SupportBundle(w io.Writer) error {
+	r := l.root()
+	r.lazyInit()
+	tw := tar.NewWriter(w)
+	now := time.Now()
+
+	config, err := json.MarshalIndent(r.configSummary(), "", "  ")
+	if err != nil {
+		return err
+	}
+	err = addTarFile(tw, "config.json", config, now)
+	if err != nil {
+		return err
+	}
+
+	stats, err := json.MarshalIndent(r.Stats(), "", "  ")
+	if err != nil {
+		return err
+	}
+	err = addTarFile(tw, "stats.json", stats, now)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	sinks := r.sinks
+	rw := r.rw
+	r.lock.Unlock()
+	enc := textEncoder{timeFormat: time.RFC3339Nano, flags: Lshortfile}
+	var recent []byte
+	for _, s := range sinks {
+		if m, ok := s.sink.(*MemorySink); ok {
+			for _, e := range m.Snapshot() {
+				recent = enc.encode(recent, &e)
+			}
+		}
+	}
+	err = addTarFile(tw, "recent.log", recent, now)
+	if err != nil {
+		return err
+	}
+
+	if rw != nil {
+		tail, err := readTail(rw.Name(), BUNDLE_TAIL_SIZE)
+		if err == nil {
+			err = addTarFile(tw, "active.log", tail, now)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// SupportBundle calls SupportBundle on the default logger.
+func SupportBundle(w io.Writer) error {
+	return Default().SupportBundle(w)
+}
+
+func (l *Logger) configSummary() map[string]interface{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	sinks := make([]string, len(l.sinks))
+	for i, s := range l.sinks {
+		sinks[i] = fmt.Sprintf("%T", s.sink)
+	}
+	mirror := "none"
+	if l.mirrorLevel != 0 {
+		mirror = LogTypeToString(l.mirrorLevel)
+	}
+	return map[string]interface{}{
+		"level":           LogLevelToString(l.Level()),
+		"output":          l.outputName(),
+		"flags":           l._log.Flags(),
+		"prefix":          l._log.Prefix(),
+		"FileName":        l.FileName,
+		"TimeFormat":      l.TimeFormat,
+		"EntryTimeFormat": l.EntryTimeFormat,
+		"SuffixName":      l.SuffixName,
+		"RotateEvery":     l.RotateEvery,
+		"MaxSize":         l.MaxSize,
+		"MaxTotalSize":    l.MaxTotalSize,
+		"MaxBackups":      l.MaxBackups,
+		"MaxAge":          l.MaxAge,
+		"Compress":        l.Compress,
+		"IndexEvery":      l.IndexEvery,
+		"AsyncQueue":      l.AsyncQueue,
+		"Symlink":         l.Symlink,
+		"NoAppend":        l.NoAppend,
+		"UseUTC":          l.UseUTC,
+		"DisableCaller":   l.DisableCaller,
+		"FuncName":        l.FuncName,
+		"StackLevel":      l.StackLevel,
+		"Format":          l.Format,
+		"Template":        l.Template,
+		"NormalizeKeys":   l.NormalizeKeys,
+		"mirror":          mirror,
+		"sinks":           sinks,
+	}
+}
+
+func addTarFile(tw *tar.Writer, name string, data []byte, mtime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: mtime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+func readTail(path string, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	off := fi.Size() - max
+	if off < 0 {
+		off = 0
+	}
+	_, err = f.Seek(off, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(f, max))
+}
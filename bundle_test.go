@@ -0,0 +1,57 @@
+package log
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSupportBundle(t *testing.T) {
+	w, err := NewRotatingWriter(filepath.Join(t.TempDir(), "app"), FORMAT_TIME_DAY, ".log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLogger(io.Discard, "", 0)
+	l.SetOutput(w)
+	defer l.Close()
+	m := NewMemorySink(10, 0)
+	l.AddSink(m)
+	l.Info("first")
+	l.Error("second")
+
+	var b bytes.Buffer
+	if err := l.Named("child").SupportBundle(&b); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(&b)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[h.Name] = string(data)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(files["config.json"]), &config); err != nil || config["output"] != w.Name() {
+		t.Errorf("config.json = %s (%v), want output %s", files["config.json"], err, w.Name())
+	}
+	var stats Stats
+	if err := json.Unmarshal([]byte(files["stats.json"]), &stats); err != nil || stats.Entries["info"] != 1 || stats.Entries["error"] != 1 {
+		t.Errorf("stats.json = %s (%v)", files["stats.json"], err)
+	}
+	for _, name := range []string{"recent.log", "active.log"} {
+		if !strings.Contains(files[name], "first") || !strings.Contains(files[name], "second") {
+			t.Errorf("%s = %q, want both entries", name, files[name])
+		}
+	}
+}
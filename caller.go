@@ -0,0 +1,77 @@
+package log
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// callers looked up at most, beyond which lookups are not cached
+const CALLER_CACHE_SIZE = 4096
+
+type callerInfo struct {
+	file string
+	line int
+	// package and function, e.g. "log.(*Logger).Info"
+	fn string
+}
+
+// callerCache maps call sites to their file and line, which runtime.Caller
+// would otherwise resolve, allocating, on every entry.
+var callerCache = struct {
+	m    map[uintptr]callerInfo
+	lock sync.RWMutex
+}{m: make(map[uintptr]callerInfo)}
+
+// caller works like runtime.Caller(skip).
+func caller(skip int) (file string, line int, ok bool) {
+	return pcCaller(callerPC(skip + 1))
+}
+
+// callerPC returns the call site of caller(skip), 0 if there is none.
+func callerPC(skip int) uintptr {
+	var pc [1]uintptr
+	if runtime.Callers(skip+2, pc[:]) == 0 {
+		return 0
+	}
+	return pc[0]
+}
+
+// pcCaller returns the file and line of the call site pc, as returned by
+// runtime.Callers.
+func pcCaller(pc uintptr) (file string, line int, ok bool) {
+	c, ok := lookupPC(pc)
+	return c.file, c.line, ok
+}
+
+// pcFunc returns the package and function of the call site pc, "" if not
+// known.
+func pcFunc(pc uintptr) string {
+	c, _ := lookupPC(pc)
+	return c.fn
+}
+
+func lookupPC(pc uintptr) (callerInfo, bool) {
+	if pc == 0 {
+		return callerInfo{}, false
+	}
+	callerCache.lock.RLock()
+	c, ok := callerCache.m[pc]
+	callerCache.lock.RUnlock()
+	if ok {
+		return c, true
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.PC == 0 {
+		return callerInfo{}, false
+	}
+	// the import path up to the package name is the same for every entry
+	c = callerInfo{frame.File, frame.Line, frame.Function[strings.LastIndexByte(frame.Function, '/')+1:]}
+	callerCache.lock.Lock()
+	if len(callerCache.m) < CALLER_CACHE_SIZE {
+		callerCache.m[pc] = c
+	}
+	callerCache.lock.Unlock()
+	return c, true
+}
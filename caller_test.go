@@ -0,0 +1,170 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// here returns the file and line of its caller.
+func here() (string, int) {
+	_, file, line, _ := runtime.Caller(1)
+	return filepath.Base(file), line
+}
+
+func jsonLogger() (*Logger, *bytes.Buffer) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", Lshortfile)
+	l.SetFormat(FORMAT_JSON)
+	return l, &b
+}
+
+func decodeLines(t *testing.T, b *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, s := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if s == "" {
+			continue
+		}
+		var m map[string]interface{}
+		err := json.Unmarshal([]byte(s), &m)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestCallerIsCallSite(t *testing.T) {
+	l, b := jsonLogger()
+	file, start := here()
+	l.Info("info")
+	l.Warningf("warning %d", 1)
+	l.LogFields(LOG_INFO, "fields")
+	l.LogAt(LOG_INFO, time.Now(), "at")
+	l.Emit(&Entry{Level: LOG_INFO, Message: "emit"})
+	l.Wrap(errTest, "wrap")
+	l.Assert(false, "assert")
+	l.Slog().Info("slog")
+	l.InfoCtx(context.Background(), "ctx")
+	l.Named("child").With("k", 1).Info("child")
+
+	got := decodeLines(t, b)
+	if len(got) != 10 {
+		t.Fatalf("%d entries, want 10", len(got))
+	}
+	for i, m := range got {
+		want := file + ":" + strconv.Itoa(start+1+i)
+		if m["caller"] != want {
+			t.Errorf("%s: caller %v, want %s", m["message"], m["caller"], want)
+		}
+	}
+}
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+const errTest = testError("test error")
+
+// logFromHelper logs one entry and returns the line it did so from.
+func logFromHelper(l *Logger) int {
+	_, start := here()
+	l.Info("from helper")
+	return start + 1
+}
+
+func TestFuncNameFromHelper(t *testing.T) {
+	l, b := jsonLogger()
+	l.SetFuncName(true)
+	n := logFromHelper(l)
+	func() {
+		l.Info("from closure")
+	}()
+
+	got := decodeLines(t, b)
+	if len(got) != 2 {
+		t.Fatalf("%d entries, want 2", len(got))
+	}
+	if want := "caller_test.go:" + strconv.Itoa(n); got[0]["caller"] != want {
+		t.Errorf("caller %v, want %s", got[0]["caller"], want)
+	}
+	if got[0]["func"] != "log.logFromHelper" {
+		t.Errorf("func %v, want log.logFromHelper", got[0]["func"])
+	}
+	if got[1]["func"] != "log.TestFuncNameFromHelper.func1" {
+		t.Errorf("func %v, want log.TestFuncNameFromHelper.func1", got[1]["func"])
+	}
+}
+
+func TestGoFuncCaller(t *testing.T) {
+	w := &slowWriter{}
+	l := NewLogger(w, "", Lshortfile)
+	l.SetFormat(FORMAT_JSON)
+	file, start := here()
+	GoFunc(l, "ok", func() error { return nil })()
+	GoFunc(l, "failed", func() error { return errTest })()
+	Go(l, "panic", func() error { panic("boom") })
+	within(t, "Go", func() {
+		for strings.Count(w.String(), "\n") < 6 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	got := decodeLines(t, bytes.NewBufferString(w.String()))
+	for i, m := range got {
+		want := file + ":" + strconv.Itoa(start+1+i/2)
+		if m["caller"] != want {
+			t.Errorf("%s: caller %v, want %s", m["message"], m["caller"], want)
+		}
+	}
+}
+
+func TestAssertCallerOnce(t *testing.T) {
+	l, b := jsonLogger()
+	l.Named("child").Assert(false, "invariant", "k", 1)
+
+	if n := strings.Count(b.String(), `"caller"`); n != 1 {
+		t.Errorf("%d caller fields: %s", n, b.String())
+	}
+}
+
+func TestCallerCache(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		file, line, ok := caller(0)
+		_, wantFile, wantLine, _ := runtime.Caller(0)
+		if !ok || file != wantFile || line != wantLine-1 {
+			t.Errorf("caller = %s:%d, want %s:%d", file, line, wantFile, wantLine-1)
+		}
+	}
+	if n := testing.AllocsPerRun(100, func() { caller(0) }); n != 0 {
+		t.Errorf("cached caller lookup allocates %v times", n)
+	}
+}
+
+func TestEnableCaller(t *testing.T) {
+	l, b := jsonLogger()
+	l.Named("cmd").EnableCaller(false)
+	l.Info("json")
+	l.SetFormat(FORMAT_TEXT)
+	l.Info("text")
+	got := b.String()
+	if strings.Contains(got, "caller") || strings.Contains(got, "caller_test.go") {
+		t.Errorf("output with the caller turned off = %q", got)
+	}
+
+	b.Reset()
+	l.EnableCaller(true)
+	file, line := here()
+	l.Info("text")
+	if want := file + ":" + strconv.Itoa(line+1) + ": [info] text \n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
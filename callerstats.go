@@ -0,0 +1,115 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CallerStat is the number of entries one call site wrote at one level.
+type CallerStat struct {
+	Caller string
+	Level  LogType
+	Count  int64
+}
+
+type callerKey struct {
+	caller string
+	level  LogType
+}
+
+type callerStats struct {
+	lock   sync.Mutex
+	counts map[callerKey]int64
+	topN   int
+	cancel func()
+}
+
+// EnableCallerStats counts entries per call site and level. Every interval
+// the topN busiest sites of the period are logged at info level with their
+// share of the volume ("x.go:42 debug 8000 80.0%"), and counting starts
+// over. This points at the noisy log statements worth cleaning up.
+// interval <= 0 turns counting off.
+func (l *Logger) EnableCallerStats(interval time.Duration, topN int) {
+	r := l.root()
+
+	r.lock.Lock()
+	old := r.callerStats
+	r.callerStats = nil
+	if interval > 0 {
+		cs := &callerStats{counts: make(map[callerKey]int64), topN: topN}
+		cs.cancel = maintenance.schedule(interval, func() { r.reportCallerStats(cs) })
+		r.callerStats = cs
+	}
+	r.lock.Unlock()
+
+	if old != nil {
+		old.cancel()
+	}
+}
+
+// CallerStats returns the counts of the current period, largest first.
+func (l *Logger) CallerStats() []CallerStat {
+	r := l.root()
+
+	r.lock.Lock()
+	cs := r.callerStats
+	r.lock.Unlock()
+	if cs == nil {
+		return nil
+	}
+
+	stats, _ := cs.snapshot(false)
+	return stats
+}
+
+func (cs *callerStats) add(e *Entry) {
+	k := callerKey{e.File + ":" + strconv.Itoa(e.Line), e.Level}
+
+	cs.lock.Lock()
+	cs.counts[k]++
+	cs.lock.Unlock()
+}
+
+func (cs *callerStats) snapshot(reset bool) ([]CallerStat, int64) {
+	cs.lock.Lock()
+	stats := make([]CallerStat, 0, len(cs.counts))
+	var total int64
+	for k, n := range cs.counts {
+		stats = append(stats, CallerStat{Caller: k.caller, Level: k.level, Count: n})
+		total += n
+	}
+	if reset {
+		cs.counts = make(map[callerKey]int64)
+	}
+	cs.lock.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Caller < stats[j].Caller
+	})
+	return stats, total
+}
+
+func (l *Logger) reportCallerStats(cs *callerStats) {
+	stats, total := cs.snapshot(true)
+	if total == 0 {
+		return
+	}
+	if cs.topN > 0 && len(stats) > cs.topN {
+		stats = stats[:cs.topN]
+	}
+
+	fields := []Field{{"total", total}}
+	for i, s := range stats {
+		fields = append(fields, Field{
+			"top" + strconv.Itoa(i+1),
+			fmt.Sprintf("%s %s %d %.1f%%", s.Caller, LogTypeToString(s.Level), s.Count, float64(s.Count)*100/float64(total)),
+		})
+	}
+	l.logFields(LOG_INFO, "caller stats", fields)
+}
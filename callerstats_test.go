@@ -0,0 +1,49 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallerStats(t *testing.T) {
+	l, b := jsonLogger()
+	l.EnableCallerStats(time.Hour, 1)
+	defer l.EnableCallerStats(0, 0)
+	file, start := here()
+	for i := 0; i < 3; i++ {
+		l.Debug("noisy")
+	}
+	l.Warning("rare")
+
+	stats := l.CallerStats()
+	want := []CallerStat{
+		{file + ":" + strconv.Itoa(start+2), LOG_DEBUG, 3},
+		{file + ":" + strconv.Itoa(start+4), LOG_WARNING, 1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("stats = %v, want %v", stats, want)
+	}
+	for i := range want {
+		// the caller is the full path, the entries the short one
+		if got := stats[i]; !strings.HasSuffix(got.Caller, want[i].Caller) || got.Level != want[i].Level || got.Count != want[i].Count {
+			t.Errorf("stat %d = %v, want %v", i, got, want[i])
+		}
+	}
+
+	b.Reset()
+	l.reportCallerStats(l.callerStats)
+	got := decodeLines(t, b)
+	if len(got) != 1 {
+		t.Fatalf("%d reports, want 1", len(got))
+	}
+	top, _ := got[0]["top1"].(string)
+	if got[0]["total"] != float64(4) || !strings.HasSuffix(top, "debug 3 75.0%") || got[0]["top2"] != nil {
+		t.Errorf("report = %v", got[0])
+	}
+	// only the report itself was counted since
+	if stats := l.CallerStats(); len(stats) != 1 || stats[0].Count != 1 {
+		t.Errorf("stats after the report = %v, want the counts reset", stats)
+	}
+}
@@ -0,0 +1,56 @@
+package log
+
+// Named returns a child logger whose entries carry name (appended to the
+// parent's name with a dot). The child writes to the outputs and sinks of
+// its root logger, so configuring those on the root affects all children,
+// but it has its own level, which starts out as the parent's. Fields
+// attached with With are kept.
+func (l *Logger) Named(name string) *Logger {
+	l.lazyInit()
+
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return l.child(name, nil)
+}
+
+// With returns a child logger attaching fields, given as alternating keys
+// and values or as Fields, to every entry it writes, before the fields of
+// the entry itself:
+//
+//	l.With("request_id", id, "user", u).Info("done")
+//
+// Like Named children it writes through its root logger and starts out with
+// the parent's level; it keeps the parent's name and fields.
+func (l *Logger) With(args ...interface{}) *Logger {
+	return l.WithFields(toFields(args)...)
+}
+
+// WithFields is With for fields only.
+func (l *Logger) WithFields(fields ...Field) *Logger {
+	l.lazyInit()
+	return l.child(l.name, fields)
+}
+
+func (l *Logger) child(name string, fields []Field) *Logger {
+	return &Logger{
+		_log:   l._log,
+		level:  int32(l.Level()),
+		parent: l.root(),
+		name:   name,
+		// full slice expression, so siblings never share appended fields
+		fields: append(l.fields[:len(l.fields):len(l.fields)], fields...),
+	}
+}
+
+// Name returns the name given by Named, empty for a root logger.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+func (l *Logger) root() *Logger {
+	if l.parent != nil {
+		return l.parent
+	}
+	return l
+}
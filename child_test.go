@@ -0,0 +1,115 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChildSettersApplyToRoot(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	c := l.Named("cmd")
+	c.SetLevelNames(map[LogType]string{LOG_ERROR: "ERR"})
+	c.SetStackLevel(LOG_ERROR)
+	c.MirrorToStderr(LOG_FATAL)
+	c.IncludeBuildInfo(true)
+	if err := c.SetEntryTimeFormat(time.RFC3339); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Error("failed")
+	if out := b.String(); !strings.Contains(out, "[ERR]") || !strings.Contains(out, "stack=") {
+		t.Errorf("output = %q, want the level name and stack set on the child", out)
+	}
+	if l.mirrorLevel != LOG_FATAL || !l.BuildInfo || l.EntryTimeFormat != time.RFC3339 {
+		t.Errorf("root has mirror %v, build info %v, time format %q", l.mirrorLevel, l.BuildInfo, l.EntryTimeFormat)
+	}
+	if c.stackLevel != 0 || c.levelNames != nil {
+		t.Error("child holds its own copy of the settings")
+	}
+}
+
+func TestChildIncludeSeverity(t *testing.T) {
+	l, b := jsonLogger()
+	l.Named("cmd").IncludeSeverity(true)
+	l.Warning("slow")
+
+	got := decodeLines(t, b)
+	if len(got) != 1 || got[0]["severity"] == nil {
+		t.Errorf("entries = %v, want a severity field", got)
+	}
+}
+
+func TestChildIncludeContainerInfo(t *testing.T) {
+	l := NewLogger(&bytes.Buffer{}, "", 0)
+	l.With(Field{"request", 1}).IncludeContainerInfo(true)
+	if !l.ContainerInfo {
+		t.Error("container info set on a child does not reach the root")
+	}
+}
+
+func TestChildSetUseUTC(t *testing.T) {
+	l := NewLogger(&bytes.Buffer{}, "", 0)
+	s := &lastSink{}
+	l.AddSink(s)
+	l.Named("cmd").SetUseUTC(true)
+	l.Info("now")
+
+	if loc := s.e.Time.Location(); loc != time.UTC {
+		t.Errorf("entry time in %v, want UTC", loc)
+	}
+}
+
+func TestChildCloseKeepsRootOutput(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, nil)
+	l := NewLogger(w, "", 0)
+	s := &closeSink{}
+	l.AddSink(s)
+	l.SetAsync(8)
+
+	if err := l.Named("cmd").Close(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after")
+	l.Flush(context.Background())
+	if got := fs.read(w.Name()); !strings.Contains(got, "after") {
+		t.Errorf("file = %q after closing a child, want the entry", got)
+	}
+	if s.closed != 0 {
+		t.Error("closing a child closed a sink of the root")
+	}
+	l.Close()
+}
+
+func TestWith(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	req := l.With("request_id", 7, F("user", "ann"))
+	a := req.WithFields(F("step", "a"))
+	c := req.Named("db").With("step", "c", "dangling")
+
+	req.Info("start")
+	a.LogFields(LOG_INFO, "done", F("ms", 3))
+	c.Info("query")
+	l.Info("plain")
+
+	want := "[info] start  request_id=7 user=ann\n" +
+		"[info] done request_id=7 user=ann step=a ms=3\n" +
+		"[info] [db] query  request_id=7 user=ann step=c !BADKEY=dangling\n" +
+		"[info] plain \n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	if req.Name() != "" || c.Name() != "db" {
+		t.Errorf("names %q and %q", req.Name(), c.Name())
+	}
+
+	c.SetLevel(LOG_LEVEL_ERROR)
+	if !req.IsLevelEnabled(LOG_INFO) || c.IsLevelEnabled(LOG_INFO) {
+		t.Error("the level of a child is not its own")
+	}
+}
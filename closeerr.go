@@ -0,0 +1,38 @@
+package log
+
+import (
+	"fmt"
+	"io"
+)
+
+// CloseError attributes one failure of Close or ShutdownAll to the
+// component that caused it, e.g. "output app.20240102.log" or
+// "sink 1 (*log.NetworkSink)". Close returns them joined as with
+// errors.Join; errors.As finds the first one, Unwrap() []error on the
+// joined error lists all of them.
+type CloseError struct {
+	Component string
+	Err       error
+}
+
+func (e *CloseError) Error() string {
+	return e.Component + ": " + e.Err.Error()
+}
+
+func (e *CloseError) Unwrap() error {
+	return e.Err
+}
+
+func sinkName(i int, s Sink) string {
+	return fmt.Sprintf("sink %d (%T)", i, s)
+}
+
+func closerName(c io.Closer) string {
+	switch c := c.(type) {
+	case *Logger:
+		return "logger " + c.FileName
+	case *RotatingWriter:
+		return "output " + c.FileName
+	}
+	return fmt.Sprintf("%T", c)
+}
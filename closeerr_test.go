@@ -0,0 +1,41 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// failCloser is a sink whose Close fails with err.
+type failCloser struct {
+	err error
+}
+
+func (s failCloser) WriteEntry(e *Entry) error { return nil }
+
+func (s failCloser) Close() error { return s.err }
+
+func TestCloseErrorsJoined(t *testing.T) {
+	errA := errors.New("flush a")
+	errB := errors.New("flush b")
+	l := NewLogger(io.Discard, "", 0)
+	l.AddSink(failCloser{errA})
+	l.AddSink(failCloser{nil})
+	l.AddSink(failCloser{errB})
+
+	err := l.Close()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("err = %v, want both failures", err)
+	}
+	var first *CloseError
+	if !errors.As(err, &first) || first.Component != "sink 0 (log.failCloser)" {
+		t.Errorf("first CloseError = %v", first)
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Errorf("err = %#v, want two joined CloseErrors", err)
+	}
+	if got := joined.Unwrap()[1].Error(); got != "sink 2 (log.failCloser): flush b" {
+		t.Errorf("second error = %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+// Command loggen writes typed logging functions from an event schema, see
+// package loggen. It is meant for go generate:
+//
+//	//go:generate go run github.com/Yprolic/log/cmd/loggen -schema events.json -o events_log.go
+//
+// The package name defaults to $GOPACKAGE, set by go generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Yprolic/log/loggen"
+)
+
+func main() {
+	schema := flag.String("schema", "", "event schema `file` (JSON)")
+	out := flag.String("o", "", "output `file`, stdout if empty")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package `name` of the generated file")
+	flag.Parse()
+
+	if *schema == "" || *pkg == "" {
+		fmt.Fprintln(os.Stderr, "usage: loggen -schema events.json [-o file] [-pkg name]")
+		os.Exit(2)
+	}
+
+	err := run(*schema, *out, *pkg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(schema, out, pkg string) error {
+	f, err := os.Open(schema)
+	if err != nil {
+		return err
+	}
+	s, err := loggen.Parse(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", schema, err)
+	}
+
+	src, err := loggen.Generate(s, pkg, filepath.Base(schema))
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0666)
+}
@@ -0,0 +1,93 @@
+// Command logmerge interleaves the logs of several hosts by timestamp:
+//
+//	logmerge -skew 2s -offset db1=-1.5s web1=web1.log db1=db1.log.gz
+//
+// Every argument is a file, optionally prefixed with its label (the file
+// name by default); gzip-compressed files are read as they are. Entries are
+// printed with a source field naming their label.
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Yprolic/log"
+)
+
+type offsetsFlag map[string]time.Duration
+
+func (f offsetsFlag) String() string {
+	return ""
+}
+
+func (f offsetsFlag) Set(s string) error {
+	label, d, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("want label=duration, got %q", s)
+	}
+	offset, err := time.ParseDuration(d)
+	if err != nil {
+		return err
+	}
+	f[label] = offset
+	return nil
+}
+
+func open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil || !strings.HasSuffix(path, ".gz") {
+		return f, err
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{zr, f}, nil
+}
+
+func main() {
+	offsets := offsetsFlag{}
+	skew := flag.Duration("skew", time.Second, "how far timestamps may go back within one file")
+	flag.Var(offsets, "offset", "add a clock correction to a source, `label=duration` (repeatable)")
+	jsonOut := flag.Bool("json", false, "print entries as JSON lines")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: logmerge [-skew d] [-offset label=d] [-json] [label=]file...")
+		os.Exit(2)
+	}
+
+	var srcs []log.MergeSource
+	for _, arg := range flag.Args() {
+		label, path, ok := strings.Cut(arg, "=")
+		if !ok {
+			label, path = filepath.Base(arg), arg
+		}
+		r, err := open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer r.Close()
+		srcs = append(srcs, log.MergeSource{Label: label, R: r, Offset: offsets[label]})
+	}
+
+	out := log.NewWriterSink(os.Stdout)
+	out.TimeFormat = time.RFC3339Nano
+	out.JSON = *jsonOut
+	err := log.Merge(srcs, *skew, out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,64 @@
+// Command logmigrate renames or removes fields in existing log files:
+//
+//	logmigrate -rename user=user_id -remove password app.*.log > app.sha256
+//
+// It prints the SHA-256 of every rewritten file in the sha256sum format on
+// stdout, and the old checksum, line and change counts on stderr.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Yprolic/log/logmigrate"
+)
+
+type rulesFlag struct {
+	rules  *[]logmigrate.Rule
+	remove bool
+}
+
+func (f rulesFlag) String() string {
+	return ""
+}
+
+func (f rulesFlag) Set(s string) error {
+	if f.remove {
+		s = "-" + s
+	}
+	r, err := logmigrate.ParseRule(s)
+	if err != nil {
+		return err
+	}
+	*f.rules = append(*f.rules, r)
+	return nil
+}
+
+func main() {
+	var rules []logmigrate.Rule
+	flag.Var(rulesFlag{rules: &rules}, "rename", "rename a field, `old=new` (repeatable)")
+	flag.Var(rulesFlag{rules: &rules, remove: true}, "remove", "remove a `field` (repeatable)")
+	dryRun := flag.Bool("n", false, "dry run: report what would change")
+	flag.Parse()
+
+	if len(rules) == 0 || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: logmigrate [-n] -rename old=new|-remove field ... file...")
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		res, err := logmigrate.RewriteFile(path, rules, *dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s  %s\n", res.NewSHA256, path)
+		fmt.Fprintf(os.Stderr, "%s: %d lines, %d changed, was sha256 %s\n", path, res.Lines, res.Changed, res.OldSHA256)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
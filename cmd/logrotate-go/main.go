@@ -0,0 +1,106 @@
+// Command logrotate-go applies the retention and compression of this
+// package to rotated files written by other processes or older versions:
+//
+//	logrotate-go -max-backups 14 -max-age 30d -compress gzip /var/log/app/app
+//
+// Every argument is a FileName as in the Logger configuration; its files
+// are FileName.<time suffix>[-N]<suffix>, optionally compressed, where the
+// time suffix is in the -time-format layout; others are left alone. The newest
+// one is treated as active and left alone.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Yprolic/log"
+	_ "github.com/Yprolic/log/logzstd"
+)
+
+// verboseFS reports the files removed and created
+type verboseFS struct {
+	log.FileSystem
+}
+
+func (fs verboseFS) Remove(name string) error {
+	err := fs.FileSystem.Remove(name)
+	if err == nil {
+		fmt.Printf("removed %s\n", name)
+	}
+	return err
+}
+
+func (fs verboseFS) Rename(oldpath, newpath string) error {
+	err := fs.FileSystem.Rename(oldpath, newpath)
+	if err == nil {
+		fmt.Printf("wrote %s\n", newpath)
+	}
+	return err
+}
+
+// Chtimes keeps the modification times of compressed files
+func (fs verboseFS) Chtimes(name string, atime, mtime time.Time) error {
+	if c, ok := fs.FileSystem.(interface {
+		Chtimes(name string, atime, mtime time.Time) error
+	}); ok {
+		return c.Chtimes(name, atime, mtime)
+	}
+	return nil
+}
+
+// Lstat lets symlinks to the active file be told apart from rotated files
+func (fs verboseFS) Lstat(name string) (os.FileInfo, error) {
+	if l, ok := fs.FileSystem.(interface {
+		Lstat(name string) (os.FileInfo, error)
+	}); ok {
+		return l.Lstat(name)
+	}
+	return fs.Stat(name)
+}
+
+func main() {
+	suffix := flag.String("suffix", ".log", "`suffix` of the file names (SuffixName)")
+	timeFormat := flag.String("time-format", log.FORMAT_TIME_DAY, "Go time `layout` of the file names (TimeFormat)")
+	maxBackups := flag.Int("max-backups", 0, "keep at most `n` rotated files")
+	maxAge := flag.String("max-age", "", "remove rotated files older than `age`, e.g. 168h or 7d")
+	maxTotal := flag.Int("max-total", 0, "remove the oldest files beyond `mb` megabytes in total")
+	compress := flag.String("compress", "", "compress rotated files with `method` (gzip or zstd)")
+	verbose := flag.Bool("v", false, "print removed and written files")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: logrotate-go [-suffix s] [-time-format layout] [-max-backups n] [-max-age age] [-max-total mb] [-compress gzip|zstd] [-v] FileName...")
+		os.Exit(2)
+	}
+
+	age, err := log.ParseMaxAge(*maxAge)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	r := log.Retention{
+		TimeFormat:    *timeFormat,
+		MaxBackups:    *maxBackups,
+		MaxAge:        age,
+		MaxTotalBytes: int64(*maxTotal) << 20,
+		Compress:      *compress,
+	}
+	fs := log.OSFileSystem
+	if *verbose {
+		fs = verboseFS{fs}
+	}
+
+	failed := false
+	for _, name := range flag.Args() {
+		err := log.ApplyRetention(fs, name, *suffix, r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
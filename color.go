@@ -0,0 +1,58 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	COLOR_NEVER  = "never"
+	COLOR_AUTO   = "auto"
+	COLOR_ALWAYS = "always"
+)
+
+// SetColor colors the level tags of text output with DefaultTheme:
+// COLOR_AUTO when the output is a terminal and NO_COLOR is not set,
+// COLOR_ALWAYS or COLOR_NEVER to force it. "" means COLOR_NEVER. With
+// COLOR_AUTO the output is checked again on every SetOutput.
+func (l *Logger) SetColor(mode string) error {
+	mode = strings.ToLower(mode)
+	switch mode {
+	case "", COLOR_NEVER, COLOR_AUTO, COLOR_ALWAYS:
+	default:
+		return errors.New("unknown Color: " + mode)
+	}
+
+	r := l.root()
+	r.lazyInit()
+	r.lock.Lock()
+	r.Color = mode
+	r.theme = ColorTheme(r._log.Writer(), mode)
+	r.lock.Unlock()
+	return nil
+}
+
+// ColorTheme returns the theme to write to w with under a SetColor mode:
+// DefaultTheme or nil.
+func ColorTheme(w io.Writer, mode string) Theme {
+	switch mode {
+	case COLOR_ALWAYS:
+		return DefaultTheme
+	case COLOR_AUTO:
+		if os.Getenv("NO_COLOR") == "" && isTerminal(w) {
+			return DefaultTheme
+		}
+	}
+	return nil
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
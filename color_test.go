@@ -0,0 +1,38 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestSetColor(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	if err := l.SetColor("rainbow"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+
+	// a buffer is no terminal
+	l.SetColor(COLOR_AUTO)
+	l.Error("auto")
+	l.Named("child").SetColor(COLOR_ALWAYS)
+	l.Error("always")
+	l.SetColor(COLOR_NEVER)
+	l.Error("never")
+
+	want := "[error] auto \n" + DefaultTheme[LOG_ERROR] + "[error]" + COLOR_RESET + " always \n[error] never \n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestColorThemeNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if ColorTheme(os.Stdout, COLOR_AUTO) != nil {
+		t.Error("NO_COLOR ignored")
+	}
+	if ColorTheme(&bytes.Buffer{}, COLOR_ALWAYS) == nil {
+		t.Error("COLOR_ALWAYS without a theme")
+	}
+}
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compress values: rotated files are compressed in the background once the
+// writer moves on to a new file. Other methods can be added with
+// RegisterCompressor, e.g. "zstd" by importing logzstd.
+const (
+	COMPRESS_NONE = ""
+	COMPRESS_GZIP = "gzip"
+)
+
+// Compressor wraps w in a compressing writer. Closing it completes the
+// archive without closing w.
+type Compressor func(w io.Writer) (io.WriteCloser, error)
+
+type compressor struct {
+	ext string
+	fn  Compressor
+}
+
+var (
+	compressors = map[string]compressor{
+		COMPRESS_GZIP: {".gz", func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		}},
+	}
+	compressorsLock sync.RWMutex
+)
+
+// RegisterCompressor makes method usable as Compress, naming compressed
+// files with the extension ext.
+func RegisterCompressor(method, ext string, fn Compressor) {
+	compressorsLock.Lock()
+	compressors[method] = compressor{ext, fn}
+	compressorsLock.Unlock()
+}
+
+func getCompressor(method string) (compressor, bool) {
+	compressorsLock.RLock()
+	c, ok := compressors[method]
+	compressorsLock.RUnlock()
+	return c, ok
+}
+
+func validateCompress(method string) error {
+	if method == COMPRESS_NONE {
+		return nil
+	}
+	if _, ok := getCompressor(method); !ok {
+		return fmt.Errorf("unknown Compress %q", method)
+	}
+	return nil
+}
+
+// compressedExts returns the extensions of all registered methods.
+func compressedExts() []string {
+	compressorsLock.RLock()
+	defer compressorsLock.RUnlock()
+
+	exts := make([]string, 0, len(compressors))
+	for _, c := range compressors {
+		exts = append(exts, c.ext)
+	}
+	return exts
+}
+
+// isCompressed reports whether name has the extension of any method.
+func isCompressed(name string) bool {
+	for _, ext := range compressedExts() {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressFile replaces path with a compressed copy keeping its
+// modification time, so retention still sees it in rotation order.
+func compressFile(fs FileSystem, path, method string) error {
+	src, err := fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	r, ok := src.(io.Reader)
+	if !ok {
+		return errors.New("log: " + path + " cannot be read")
+	}
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	c, ok := getCompressor(method)
+	if !ok {
+		return fmt.Errorf("unknown Compress %q", method)
+	}
+
+	name := path + c.ext
+	tmp := name + ".tmp"
+	dst, err := fs.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	zw, err := c.fn(dst)
+	if err == nil {
+		_, err = io.Copy(zw, r)
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = fs.Rename(tmp, name)
+	}
+	if err != nil {
+		fs.Remove(tmp)
+		return err
+	}
+
+	if c, ok := fs.(interface {
+		Chtimes(name string, atime, mtime time.Time) error
+	}); ok {
+		c.Chtimes(name, fi.ModTime(), fi.ModTime())
+	}
+	// offsets do not apply to the compressed file
+	fs.Remove(path + INDEX_EXT)
+	return fs.Remove(path)
+}
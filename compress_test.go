@@ -0,0 +1,112 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gunzip(t *testing.T, data string) string {
+	t.Helper()
+	zr, err := gzip.NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestCompressFile(t *testing.T) {
+	fs := newMemFS()
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	fs.write("/logs/app.20240101.log", "old entries\n", mtime)
+	fs.write("/logs/app.20240101.log"+INDEX_EXT, "", mtime)
+
+	if err := compressFile(fs, "/logs/app.20240101.log", COMPRESS_GZIP); err != nil {
+		t.Fatal(err)
+	}
+	checkNames(t, fs, "app.20240101.log.gz")
+	if got := gunzip(t, fs.read("/logs/app.20240101.log.gz")); got != "old entries\n" {
+		t.Errorf("decompressed = %q", got)
+	}
+	fi, err := fs.Stat("/logs/app.20240101.log.gz")
+	if err != nil || !fi.ModTime().Equal(mtime) {
+		t.Errorf("compressed file mtime = %v, %v, want %v", fi.ModTime(), err, mtime)
+	}
+
+	if err := compressFile(fs, "/logs/app.20240101.log.gz", "lz4"); err == nil {
+		t.Error("compressing with an unknown method succeeded")
+	}
+}
+
+type upperCloser struct {
+	w io.Writer
+}
+
+func (u upperCloser) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (u upperCloser) Close() error {
+	return nil
+}
+
+func TestRegisterCompressor(t *testing.T) {
+	RegisterCompressor("upper", ".up", func(w io.Writer) (io.WriteCloser, error) {
+		return upperCloser{w}, nil
+	})
+	defer func() {
+		compressorsLock.Lock()
+		delete(compressors, "upper")
+		compressorsLock.Unlock()
+	}()
+
+	if err := validateCompress("upper"); err != nil {
+		t.Fatal(err)
+	}
+	if !isCompressed("app.20240101.log.up") || isCompressed("app.20240101.log") {
+		t.Error("isCompressed does not know the registered extension")
+	}
+	fs := newMemFS()
+	fs.write("/logs/app.20240101.log", "old\n", time.Now())
+	if err := compressFile(fs, "/logs/app.20240101.log", "upper"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.read("/logs/app.20240101.log.up"); got != "OLD\n" {
+		t.Errorf("compressed = %q", got)
+	}
+}
+
+func TestRotateCompresses(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.MaxBytes = 8 })
+	l := NewLogger(w, "", 0)
+	l.SetOutput(w)
+	if err := l.Named("cmd").SetCompress("lz4"); err == nil {
+		t.Error("SetCompress accepted an unknown method")
+	}
+	if err := l.Named("cmd").SetCompress(COMPRESS_GZIP); err != nil {
+		t.Fatal(err)
+	}
+	if l.Compress != COMPRESS_GZIP {
+		t.Errorf("Compress = %q after SetCompress on a child", l.Compress)
+	}
+
+	l.Info("12345")
+	l.Info("678")
+	deadline := time.Now().Add(time.Second)
+	// compression ends with the removal of the rotated file
+	for fs.read("/logs/"+today()) != "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	checkNames(t, fs, today()+".gz", today("1"))
+	if got := gunzip(t, fs.read("/logs/"+today()+".gz")); got != "[info] 12345 \n" {
+		t.Errorf("rotated file = %q", got)
+	}
+}
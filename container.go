@@ -0,0 +1,87 @@
+package log
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	containerOnce   sync.Once
+	containerFields []Field
+
+	containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+)
+
+// ContainerInfo returns what is known about the container the process runs
+// in, read once: container.id from the cgroup or mount tables,
+// k8s.pod, k8s.namespace and k8s.node from the downward API variables
+// POD_NAME, POD_NAMESPACE and NODE_NAME (the pod name falling back to the
+// host name and the namespace to the service account), and
+// container.image from CONTAINER_IMAGE. Outside containers it is empty.
+func ContainerInfo() []Field {
+	return append([]Field(nil), containerInfoFields()...)
+}
+
+func containerInfoFields() []Field {
+	containerOnce.Do(func() {
+		add := func(key, value string) {
+			if value != "" {
+				containerFields = append(containerFields, Field{key, value})
+			}
+		}
+
+		add("container.id", containerID())
+
+		inK8s := os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+		pod := os.Getenv("POD_NAME")
+		if pod == "" && inK8s {
+			pod, _ = os.Hostname()
+		}
+		add("k8s.pod", pod)
+		ns := os.Getenv("POD_NAMESPACE")
+		if ns == "" && inK8s {
+			b, _ := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+			ns = strings.TrimSpace(string(b))
+		}
+		add("k8s.namespace", ns)
+		add("k8s.node", os.Getenv("NODE_NAME"))
+
+		add("container.image", os.Getenv("CONTAINER_IMAGE"))
+	})
+	return containerFields
+}
+
+// containerID finds the 64 hex digit id docker, containerd and cri-o use in
+// the cgroup paths (cgroup v1) or in the mounts of /etc/hostname (v2).
+func containerID() string {
+	for _, name := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := sc.Text()
+			if name == "/proc/self/mountinfo" && !strings.Contains(line, "/containers/") {
+				continue
+			}
+			if id := containerIDPattern.FindString(line); id != "" {
+				f.Close()
+				return id
+			}
+		}
+		f.Close()
+	}
+	return ""
+}
+
+// IncludeContainerInfo attaches ContainerInfo to every entry.
+func (l *Logger) IncludeContainerInfo(on bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.ContainerInfo = on
+	r.lock.Unlock()
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"sync"
+	"testing"
+)
+
+// resetContainerInfo makes the next ContainerInfo read the environment again.
+func resetContainerInfo() {
+	containerOnce = sync.Once{}
+	containerFields = nil
+}
+
+func TestContainerInfo(t *testing.T) {
+	resetContainerInfo()
+	defer resetContainerInfo()
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("POD_NAME", "api-7f9c")
+	t.Setenv("POD_NAMESPACE", "shop")
+	t.Setenv("NODE_NAME", "")
+	t.Setenv("CONTAINER_IMAGE", "shop/api:1.2")
+
+	got := map[string]interface{}{}
+	for _, f := range ContainerInfo() {
+		got[f.Key] = f.Value
+	}
+	want := map[string]string{"k8s.pod": "api-7f9c", "k8s.namespace": "shop", "container.image": "shop/api:1.2"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %s", k, got[k], v)
+		}
+	}
+	if _, ok := got["k8s.node"]; ok {
+		t.Error("empty NODE_NAME written")
+	}
+
+	l, b := jsonLogger()
+	l.IncludeContainerInfo(true)
+	l.Info("x")
+	if e := decodeLines(t, b); e[0]["k8s.pod"] != "api-7f9c" {
+		t.Errorf("entry = %v, want the container fields", e[0])
+	}
+}
+
+func TestContainerIDPattern(t *testing.T) {
+	id := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	line := "0::/system.slice/docker-" + id + ".scope"
+	if got := containerIDPattern.FindString(line); got != id {
+		t.Errorf("id = %q, want %q", got, id)
+	}
+}
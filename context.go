@@ -0,0 +1,93 @@
+package log
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey struct{}
+
+// contextLog is what NewContext stores in a context.
+type contextLog struct {
+	// base is the logger given to NewContext, nil for the default logger
+	base   *Logger
+	fields []Field
+	// base with fields, set by NewContext when base is known
+	logger *Logger
+}
+
+// NewContext returns a copy of ctx carrying l and fields, so request-scoped
+// fields such as a request ID follow the call stack:
+//
+//	ctx = log.NewContext(ctx, nil, log.F("request_id", id))
+//	...
+//	log.FromContext(ctx).Info("done")
+//
+// Fields add to those already in ctx. A nil l keeps the logger of ctx, or
+// the default logger if it has none.
+func NewContext(ctx context.Context, l *Logger, fields ...Field) context.Context {
+	c := &contextLog{base: l}
+	if p, ok := ctx.Value(contextKey{}).(*contextLog); ok {
+		if c.base == nil {
+			c.base = p.base
+		}
+		// full slice expression, so contexts never share appended fields
+		c.fields = p.fields[:len(p.fields):len(p.fields)]
+	}
+	c.fields = append(c.fields, fields...)
+	if c.base != nil {
+		c.logger = c.base.WithFields(c.fields...)
+	}
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the logger of ctx with the fields of ctx attached, or
+// the default logger if NewContext was never called on ctx.
+func FromContext(ctx context.Context) *Logger {
+	c, ok := ctx.Value(contextKey{}).(*contextLog)
+	switch {
+	case !ok:
+		return Default()
+	case c.logger != nil:
+		return c.logger
+	}
+	return Default().WithFields(c.fields...)
+}
+
+// ContextFields returns the fields NewContext attached to ctx.
+func ContextFields(ctx context.Context) []Field {
+	if c, ok := ctx.Value(contextKey{}).(*contextLog); ok {
+		return c.fields
+	}
+	return nil
+}
+
+func (l *Logger) logCtx(ctx context.Context, t LogType, v []interface{}) {
+	if !l.enabled(t) {
+		return
+	}
+
+	msg, fields := l.message(v)
+	if cf := ContextFields(ctx); len(cf) > 0 {
+		fields = append(cf[:len(cf):len(cf)], fields...)
+	}
+	l.output(3, &Entry{Level: t, Time: time.Now(), Message: msg, Fields: fields})
+}
+
+// ErrorCtx is Error with the fields NewContext attached to ctx, ahead of
+// those in v. The logger of ctx is not used: l writes the entry.
+func (l *Logger) ErrorCtx(ctx context.Context, v ...interface{}) {
+	l.logCtx(ctx, LOG_ERROR, v)
+}
+
+func (l *Logger) WarningCtx(ctx context.Context, v ...interface{}) {
+	l.logCtx(ctx, LOG_WARNING, v)
+}
+
+func (l *Logger) InfoCtx(ctx context.Context, v ...interface{}) {
+	l.logCtx(ctx, LOG_INFO, v)
+}
+
+func (l *Logger) DebugCtx(ctx context.Context, v ...interface{}) {
+	l.logCtx(ctx, LOG_DEBUG, v)
+}
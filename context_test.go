@@ -0,0 +1,42 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	ctx := NewContext(context.Background(), l, F("request_id", 7))
+	inner := NewContext(ctx, nil, F("user", "ann"))
+	sibling := NewContext(ctx, nil, F("user", "bob"))
+
+	FromContext(inner).Info("done")
+	FromContext(sibling).LogFields(LOG_INFO, "done", F("ms", 3))
+	l.WarningCtx(inner, "slow")
+	l.DebugCtx(context.Background(), "plain")
+
+	want := "[info] done  request_id=7 user=ann\n" +
+		"[info] done request_id=7 user=bob ms=3\n" +
+		"[warning] slow  request_id=7 user=ann\n" +
+		"[debug] plain \n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	if got := ContextFields(inner); len(got) != 2 || ContextFields(context.Background()) != nil {
+		t.Errorf("ContextFields = %v", got)
+	}
+}
+
+func TestContextDefault(t *testing.T) {
+	if FromContext(context.Background()) != Default() {
+		t.Error("a context without logger does not give the default logger")
+	}
+	ctx := NewContext(context.Background(), nil, F("request_id", 7))
+	l := FromContext(ctx)
+	if l.root() != Default() || len(l.fields) != 1 {
+		t.Errorf("logger of a context without base: root %p, fields %v", l.root(), l.fields)
+	}
+}
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"math/bits"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// at most this many cooldown keys are tracked; expired ones are pruned when
+// the limit is hit and entries of new keys pass untracked while it still is
+const COOLDOWN_MAX_KEYS = 4096
+
+type cooldownKey struct {
+	level LogType
+	key   string
+}
+
+type cooldownState struct {
+	until      time.Time
+	suppressed int
+}
+
+type cooldown struct {
+	windows [5]time.Duration
+	key     string
+
+	lock  sync.Mutex
+	state map[cooldownKey]*cooldownState
+}
+
+// SetCooldown lets at most one entry per window through for each level in
+// windows, e.g. {LOG_WARNING: 30 * time.Second}. Entries are told apart by
+// the value of their key field, or by call site if key is "" or the field
+// is missing. Unlike sampling, the first entry of every window is always
+// written; it carries the number of entries held back before it as the
+// suppressed field. nil turns cooldowns off.
+func (l *Logger) SetCooldown(windows map[LogType]time.Duration, key string) {
+	var c *cooldown
+	if windows != nil {
+		c = &cooldown{key: key, state: make(map[cooldownKey]*cooldownState)}
+		for t, d := range windows {
+			if i := bits.TrailingZeros(uint(t)); i < len(c.windows) {
+				c.windows[i] = d
+			}
+		}
+	}
+
+	r := l.root()
+	r.lock.Lock()
+	r.cooldown = c
+	r.lock.Unlock()
+}
+
+// allow reports whether e is the first of its window, adding the
+// suppressed field if entries were held back.
+func (c *cooldown) allow(e *Entry) bool {
+	i := bits.TrailingZeros(uint(e.Level))
+	if i >= len(c.windows) || c.windows[i] <= 0 {
+		return true
+	}
+
+	k := cooldownKey{level: e.Level}
+	for _, f := range e.Fields {
+		if c.key != "" && f.Key == c.key {
+			// "=" keeps field values apart from call sites
+			k.key = "=" + string(appendValue(nil, f.Value))
+			break
+		}
+	}
+	if k.key == "" {
+		k.key = e.File + ":" + strconv.Itoa(e.Line)
+	}
+
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	s := c.state[k]
+	if s != nil && now.Before(s.until) {
+		s.suppressed++
+		return false
+	}
+	if s == nil {
+		if len(c.state) >= COOLDOWN_MAX_KEYS {
+			c.prune(now)
+		}
+		if len(c.state) >= COOLDOWN_MAX_KEYS {
+			return true
+		}
+		s = &cooldownState{}
+		c.state[k] = s
+	}
+	if s.suppressed > 0 {
+		e.Fields = append(e.Fields, Field{"suppressed", s.suppressed})
+	}
+	s.until = now.Add(c.windows[i])
+	s.suppressed = 0
+	return true
+}
+
+// prune forgets the keys whose window ended. Their suppressed counts are
+// dropped; the entries were counted as suppressed when they were held back.
+func (c *cooldown) prune(now time.Time) {
+	for k, s := range c.state {
+		if !now.Before(s.until) {
+			delete(c.state, k)
+		}
+	}
+}
+
+func (l *Logger) cooledDown(c *cooldown, e *Entry) bool {
+	if c == nil || c.allow(e) {
+		return false
+	}
+	atomic.AddInt64(&l.counters.suppressed, 1)
+	return true
+}
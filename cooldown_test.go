@@ -0,0 +1,103 @@
+package log
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// warnTwice logs two different warnings from one function, as helpers do.
+func warnTwice(l *Logger) {
+	for i := 0; i < 3; i++ {
+		l.Warning("retrying")
+		l.Warning("queue full")
+	}
+}
+
+func TestCooldownKeepsCallSitesApart(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.SetCooldown(map[LogType]time.Duration{LOG_WARNING: time.Minute}, "")
+	warnTwice(l)
+
+	want := "[warning] retrying \n[warning] queue full \n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestEveryNKeepsCallSitesApart(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	err := l.SetEveryN(map[LogType]int{LOG_WARNING: 3}, EVERY_N_CALLER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnTwice(l)
+
+	if got := strings.Count(b.String(), "\n"); got != 2 {
+		t.Errorf("%d entries, want one per call site:\n%s", got, b.String())
+	}
+}
+
+func TestSummaryCaller(t *testing.T) {
+	l, b := jsonLogger()
+	l.SetDedup(time.Minute)
+	file, start := here()
+	l.Info("same")
+	l.Info("same")
+	l.Info("other")
+
+	got := decodeLines(t, b)
+	if len(got) != 3 {
+		t.Fatalf("%d entries, want 3: %s", len(got), b.String())
+	}
+	// the summary has the call site of the repeated entry
+	for i, n := range []int{1, 1, 3} {
+		want := file + ":" + strconv.Itoa(start+n)
+		if got[i]["caller"] != want {
+			t.Errorf("%s: caller %v, want %s", got[i]["message"], got[i]["caller"], want)
+		}
+	}
+}
+
+func TestCooldownKeyLimit(t *testing.T) {
+	c := &cooldown{state: make(map[cooldownKey]*cooldownState)}
+	c.windows[0] = time.Hour
+	entry := func(i int) *Entry {
+		return &Entry{Level: LOG_FATAL, File: "x.go", Line: i}
+	}
+	for i := 0; i < COOLDOWN_MAX_KEYS; i++ {
+		c.allow(entry(i))
+		c.allow(entry(i))
+	}
+
+	// expired keys go even if entries were held back
+	past := time.Now().Add(-time.Second)
+	for k, s := range c.state {
+		if k.key < "x.go:2" {
+			s.until = past
+		}
+	}
+	if !c.allow(entry(-1)) {
+		t.Fatal("entry of a new key held back")
+	}
+	if n := len(c.state); n >= COOLDOWN_MAX_KEYS {
+		t.Errorf("%d keys after pruning, want fewer than %d", n, COOLDOWN_MAX_KEYS)
+	}
+
+	// none expired: new keys pass untracked
+	for i := COOLDOWN_MAX_KEYS; len(c.state) < COOLDOWN_MAX_KEYS; i++ {
+		c.allow(entry(i))
+	}
+	for i := 0; i < 3; i++ {
+		if !c.allow(entry(-2)) {
+			t.Fatal("entry of an untracked key held back")
+		}
+	}
+	if n := len(c.state); n != COOLDOWN_MAX_KEYS {
+		t.Errorf("%d keys, want %d", n, COOLDOWN_MAX_KEYS)
+	}
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"os"
+	"time"
+)
+
+const DEFAULT_DEBUG_FILE_INTERVAL = 5 * time.Second
+
+type debugWatch struct {
+	path   string
+	active bool
+	saved  LogLevel
+	cancel func()
+}
+
+// WatchDebugFile checks every interval whether the file at path exists,
+// e.g. /var/run/app/log-debug. While it does, l logs at debug level; once
+// it is removed the level l had before is restored. It lets operators
+// raise the verbosity of a running process with touch and rm. interval <= 0
+// means DEFAULT_DEBUG_FILE_INTERVAL; an empty path stops watching.
+func (l *Logger) WatchDebugFile(path string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DEFAULT_DEBUG_FILE_INTERVAL
+	}
+
+	l.lock.Lock()
+	old := l.debugWatch
+	l.debugWatch = nil
+	var d *debugWatch
+	if path != "" {
+		d = &debugWatch{path: path}
+		d.cancel = maintenance.schedule(interval, func() { l.checkDebugFile(d) })
+		l.debugWatch = d
+	}
+	l.lock.Unlock()
+
+	if old != nil {
+		old.cancel()
+		l.checkDebugFileGone(old)
+	}
+	if d != nil {
+		l.checkDebugFile(d)
+	}
+}
+
+func (l *Logger) checkDebugFile(d *debugWatch) {
+	_, err := os.Stat(d.path)
+	if err != nil {
+		l.checkDebugFileGone(d)
+		return
+	}
+
+	l.lock.Lock()
+	activate := !d.active && l.debugWatch == d
+	if activate {
+		d.active = true
+		d.saved = l.Level()
+	}
+	l.lock.Unlock()
+
+	if activate {
+		l.SetLevel(LOG_LEVEL_DEBUG)
+		l.Infof("%s found, log level raised to debug", d.path)
+	}
+}
+
+// checkDebugFileGone restores the saved level if d raised it.
+func (l *Logger) checkDebugFileGone(d *debugWatch) {
+	l.lock.Lock()
+	restore := d.active
+	d.active = false
+	l.lock.Unlock()
+
+	if restore {
+		l.SetLevel(d.saved)
+		l.Infof("%s removed, log level restored to %s", d.path, LogLevelToString(d.saved))
+	}
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchDebugFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log-debug")
+	l := NewLogger(io.Discard, "", 0)
+	l.SetLevel(LOG_LEVEL_WARN)
+	l.WatchDebugFile(path, time.Hour)
+	defer l.WatchDebugFile("", 0)
+
+	check := func(want LogLevel) {
+		t.Helper()
+		l.lock.Lock()
+		d := l.debugWatch
+		l.lock.Unlock()
+		l.checkDebugFile(d)
+		if got := l.Level(); got != want {
+			t.Errorf("level %s, want %s", LogLevelToString(got), LogLevelToString(want))
+		}
+	}
+	check(LOG_LEVEL_WARN)
+	if err := os.WriteFile(path, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	check(LOG_LEVEL_DEBUG)
+	check(LOG_LEVEL_DEBUG)
+	os.Remove(path)
+	check(LOG_LEVEL_WARN)
+
+	// stopping the watch while the file exists restores the level too
+	os.WriteFile(path, nil, 0666)
+	check(LOG_LEVEL_DEBUG)
+	l.WatchDebugFile("", 0)
+	if got := l.Level(); got != LOG_LEVEL_WARN {
+		t.Errorf("level %s after stopping the watch, want warn", LogLevelToString(got))
+	}
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DecodeError is returned by Decoder for a JSON line that cannot be parsed.
+type DecodeError struct {
+	Line int
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("log: line %d: %v", e.Line, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder reads entries written by this package from a stream, text or
+// JSON lines. In text streams, lines without a level tag continue the
+// message of the entry before them, so multi-line messages and stack
+// traces come back whole; such lines before the first entry are skipped.
+type Decoder struct {
+	sc     *bufio.Scanner
+	line   int
+	parser *Parser
+
+	// text entry waiting for its continuation lines
+	pending *Entry
+	body    string
+
+	// JSON line read while finishing pending
+	ready    *Entry
+	readyErr error
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	return &Decoder{sc: sc, parser: &defaultParser}
+}
+
+// Decode returns the next entry, or io.EOF at the end of the stream.
+// A JSON line that cannot be parsed is returned as a *DecodeError;
+// decoding can continue after it.
+func (d *Decoder) Decode() (*Entry, error) {
+	if d.ready != nil || d.readyErr != nil {
+		e, err := d.ready, d.readyErr
+		d.ready, d.readyErr = nil, nil
+		return e, err
+	}
+
+	for d.sc.Scan() {
+		line := d.sc.Text()
+		d.line++
+
+		if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+			e, body, err := d.parser.parseTextHead(line)
+			if err != nil {
+				if d.pending != nil {
+					d.body += "\n" + line
+				}
+				continue
+			}
+			p := d.finish()
+			d.pending, d.body = e, body
+			if p != nil {
+				return p, nil
+			}
+			continue
+		}
+
+		e, err := parseJSON([]byte(line))
+		if err != nil {
+			e, err = nil, &DecodeError{d.line, err}
+		}
+		if p := d.finish(); p != nil {
+			d.ready, d.readyErr = e, err
+			return p, nil
+		}
+		return e, err
+	}
+
+	if p := d.finish(); p != nil {
+		return p, nil
+	}
+	if err := d.sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// finish completes the pending text entry and returns it.
+func (d *Decoder) finish() *Entry {
+	p := d.pending
+	if p != nil {
+		splitBody(p, d.body)
+	}
+	d.pending, d.body = nil, ""
+	return p
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+type dedup struct {
+	window time.Duration
+	cancel func()
+
+	lock  sync.Mutex
+	last  *Entry
+	since time.Time
+	count int
+}
+
+// SetDedup collapses an entry repeated right after itself, with the same
+// level and message, into one "last message repeated N times" entry like
+// syslog does. The first entry is written as usual; the summary, with the
+// repeated field, is written when a different entry comes, or once window
+// has passed since the first. window <= 0 turns it off.
+func (l *Logger) SetDedup(window time.Duration) {
+	r := l.root()
+	var d *dedup
+	if window > 0 {
+		d = &dedup{window: window}
+		d.cancel = maintenance.schedule(window, func() { r.flushDedup(d, false) })
+	}
+
+	r.lock.Lock()
+	old := r.dedup
+	r.dedup = d
+	r.lock.Unlock()
+
+	if old != nil {
+		old.cancel()
+		r.flushDedup(old, true)
+	}
+}
+
+// check reports whether e repeats the last entry, returning the summary of
+// the repeats to write before e otherwise.
+func (d *dedup) check(e *Entry) (repeat bool, summary *Entry) {
+	now := time.Now()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.last != nil && e.Level == d.last.Level && e.Message == d.last.Message && now.Sub(d.since) < d.window {
+		d.count++
+		return true, nil
+	}
+	summary = d.summary(now)
+	d.last = &Entry{Level: e.Level, Message: e.Message, Name: e.Name, File: e.File, Line: e.Line}
+	d.since = now
+	return false, summary
+}
+
+// summary returns the entry reporting the repeats of the last entry, if
+// any, and forgets them.
+func (d *dedup) summary(now time.Time) *Entry {
+	if d.count == 0 {
+		return nil
+	}
+	s := &Entry{
+		Level:   d.last.Level,
+		Time:    now,
+		Message: "last message repeated " + strconv.Itoa(d.count) + " times",
+		Fields:  []Field{{"repeated", d.count}},
+		Name:    d.last.Name,
+		File:    d.last.File,
+		Line:    d.last.Line,
+		report:  true,
+	}
+	if s.File == "" {
+		s.File = "???"
+	}
+	d.count = 0
+	return s
+}
+
+// flushDedup writes the summary of the repeats once the window passed, or
+// right away with force.
+func (l *Logger) flushDedup(d *dedup, force bool) {
+	now := time.Now()
+
+	d.lock.Lock()
+	var s *Entry
+	if force || now.Sub(d.since) >= d.window {
+		s = d.summary(now)
+		d.last = nil
+	}
+	d.lock.Unlock()
+
+	if s != nil {
+		l.output(2, s)
+	}
+}
+
+func (l *Logger) duplicate(d *dedup, e *Entry, calldepth int) bool {
+	if d == nil || e.report {
+		return false
+	}
+	repeat, summary := d.check(e)
+	if summary != nil {
+		// duplicate and the output calling it
+		l.output(calldepth+2, summary)
+	}
+	return repeat
+}
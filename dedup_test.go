@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.Named("net").SetDedup(time.Minute)
+	for i := 0; i < 4; i++ {
+		l.Error("connection refused")
+	}
+	l.Warning("connection refused")
+	l.Warning("connection refused")
+	// turning it off writes the pending summary
+	l.SetDedup(0)
+	l.Warning("connection refused")
+
+	want := "[error] connection refused \n" +
+		"[error] last message repeated 3 times repeated=3\n" +
+		"[warning] connection refused \n" +
+		"[warning] last message repeated 1 times repeated=1\n" +
+		"[warning] connection refused \n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.SetDedup(time.Hour)
+	defer l.SetDedup(0)
+	l.Info("tick")
+	l.Info("tick")
+
+	d := l.dedup
+	d.lock.Lock()
+	d.since = d.since.Add(-2 * time.Hour)
+	d.lock.Unlock()
+	// once the window has passed the summary is written without waiting
+	// for another entry, and the next repeat starts anew
+	l.flushDedup(d, false)
+	l.Info("tick")
+	if want := "[info] tick \n[info] last message repeated 1 times repeated=1\n[info] tick \n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type dynamicField struct {
+	key string
+	fn  func() interface{}
+	ttl time.Duration
+
+	lock    sync.Mutex
+	value   interface{}
+	expires time.Time
+}
+
+// AddDynamicField attaches key to every entry, with the value fn returns at
+// the time of the entry, e.g. a queue depth or the heap size. fn runs on the
+// logging goroutine, so it must be cheap and must not log.
+func (l *Logger) AddDynamicField(key string, fn func() interface{}) {
+	l.AddDynamicFieldTTL(key, 0, fn)
+}
+
+// AddDynamicFieldTTL is AddDynamicField for values that are expensive to
+// compute: fn is called at most once per ttl and its result reused until
+// then.
+func (l *Logger) AddDynamicFieldTTL(key string, ttl time.Duration, fn func() interface{}) {
+	r := l.root()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	d := &dynamicField{key: key, fn: fn, ttl: ttl}
+	for i, f := range r.dynamicFields {
+		if f.key == key {
+			r.dynamicFields[i] = d
+			return
+		}
+	}
+	r.dynamicFields = append(r.dynamicFields, d)
+}
+
+// RemoveDynamicField stops attaching key.
+func (l *Logger) RemoveDynamicField(key string) {
+	r := l.root()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, f := range r.dynamicFields {
+		if f.key == key {
+			r.dynamicFields = append(r.dynamicFields[:i:i], r.dynamicFields[i+1:]...)
+			return
+		}
+	}
+}
+
+func (d *dynamicField) field(now time.Time) Field {
+	if d.ttl <= 0 {
+		return Field{d.key, d.eval()}
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if now.After(d.expires) {
+		d.value = d.eval()
+		d.expires = now.Add(d.ttl)
+	}
+	return Field{d.key, d.value}
+}
+
+func (d *dynamicField) eval() (v interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			v = fmt.Sprintf("!PANIC: %v", p)
+		}
+	}()
+	return d.fn()
+}
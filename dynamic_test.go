@@ -0,0 +1,38 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDynamicFields(t *testing.T) {
+	l, b := jsonLogger()
+	depth, calls := 0, 0
+	l.AddDynamicField("depth", func() interface{} { depth++; return depth })
+	l.Named("child").AddDynamicFieldTTL("heap", time.Hour, func() interface{} { calls++; return calls })
+	l.AddDynamicField("broken", func() interface{} { panic("nil map") })
+	l.Info("a")
+	l.Info("b")
+	l.RemoveDynamicField("depth")
+	l.Info("c")
+
+	got := decodeLines(t, b)
+	if len(got) != 3 {
+		t.Fatalf("%d entries, want 3", len(got))
+	}
+	for i, want := range []interface{}{float64(1), float64(2), nil} {
+		if got[i]["depth"] != want {
+			t.Errorf("entry %d: depth %v, want %v", i, got[i]["depth"], want)
+		}
+		// cached for the ttl
+		if got[i]["heap"] != float64(1) {
+			t.Errorf("entry %d: heap %v, want 1", i, got[i]["heap"])
+		}
+		if got[i]["broken"] != "!PANIC: nil map" {
+			t.Errorf("entry %d: broken %v", i, got[i]["broken"])
+		}
+	}
+	if calls != 1 {
+		t.Errorf("ttl field computed %d times, want once", calls)
+	}
+}
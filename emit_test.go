@@ -0,0 +1,62 @@
+package log
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEmit(t *testing.T) {
+	analytics := NewLogger(&bytes.Buffer{}, "", Lshortfile)
+	got := &lastSink{}
+	analytics.AddSink(got)
+
+	l, b := jsonLogger()
+	l.AddHook("analytics", func(e *Entry) {
+		c := e.Clone()
+		for i := range c.Fields {
+			if c.Fields[i].Key == "user" {
+				c.Fields[i].Value = "***"
+			}
+		}
+		analytics.Emit(c)
+	})
+	file, line := here()
+	l.Named("shop").LogFields(LOG_INFO, "bought", F("user", "ann"))
+
+	entries := decodeLines(t, b)
+	if len(entries) != 1 || entries[0]["user"] != "ann" {
+		t.Errorf("own output = %v, want the original fields", entries)
+	}
+	e := got.e
+	if e == nil || e.Name != "shop" || e.Fields[0].Value != "***" {
+		t.Fatalf("emitted %+v", e)
+	}
+	if filepath.Base(e.File) != file || e.Line != line+1 {
+		t.Errorf("emitted from %s:%d, want the original call site", e.File, e.Line)
+	}
+}
+
+func TestEmitDefaults(t *testing.T) {
+	l := NewLogger(&bytes.Buffer{}, "", Lshortfile)
+	got := &lastSink{}
+	l.AddSink(got)
+	l.SetLevel(LOG_LEVEL_INFO)
+
+	l.Emit(&Entry{Level: LOG_DEBUG, Message: "filtered"})
+	if got.e != nil {
+		t.Errorf("emitted below the level: %+v", got.e)
+	}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.Emit(&Entry{Level: LOG_INFO, Message: "old", Time: at})
+	if !got.e.Time.Equal(at) {
+		t.Errorf("time %v, want the entry's own", got.e.Time)
+	}
+	before := time.Now()
+	file, line := here()
+	l.Emit(&Entry{Level: LOG_WARNING, Message: "new"})
+	if got.e.Time.Before(before) || filepath.Base(got.e.File) != file || got.e.Line != line+1 {
+		t.Errorf("emitted at %v from %s:%d, want now from the call of Emit", got.e.Time, got.e.File, got.e.Line)
+	}
+}
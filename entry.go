@@ -0,0 +1,169 @@
+package log
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// Entry is a single log record on its way to the output.
+type Entry struct {
+	Level   LogType
+	Time    time.Time
+	Message string
+	Fields  []Field
+
+	// Name is the name of the child logger that wrote the entry
+	Name string
+
+	// Seq is the delivery sequence number assigned by a SpoolSink
+	Seq uint64
+
+	// Replayed marks entries re-emitted with a caller-supplied Time,
+	// e.g. when importing events from a queue.
+	Replayed bool
+
+	File string
+	Line int
+	// the call site File and Line come from, 0 if not known
+	pc uintptr
+
+	// set on entries reporting dropped entries, which are never dropped
+	// themselves
+	report bool
+}
+
+// Clone returns a copy of e with its own Fields, which can be changed
+// without affecting e, e.g. by a hook anonymizing a copy for Emit.
+func (e *Entry) Clone() *Entry {
+	c := *e
+	if e.Fields != nil {
+		c.Fields = append([]Field(nil), e.Fields...)
+	}
+	return &c
+}
+
+// textEncoder renders entries in the package's line layout.
+type textEncoder struct {
+	prefix     string
+	flags      int
+	timeFormat string
+	levelNames map[LogType]string
+	theme      Theme
+}
+
+func (enc *textEncoder) levelName(t LogType) string {
+	if name, ok := enc.levelNames[t]; ok {
+		return name
+	}
+	return LogTypeToString(t)
+}
+
+func (enc *textEncoder) encode(buf []byte, e *Entry) []byte {
+	formatHeader(&buf, e.Time, enc.prefix, enc.flags, enc.timeFormat, e.File, e.Line)
+
+	color := enc.theme[e.Level]
+	buf = append(buf, color...)
+	buf = append(buf, '[')
+	buf = append(buf, enc.levelName(e.Level)...)
+	buf = append(buf, ']')
+	if color != "" {
+		buf = append(buf, COLOR_RESET...)
+	}
+	buf = append(buf, ' ')
+	if e.Replayed {
+		buf = append(buf, "[replayed] "...)
+	}
+	if e.Seq != 0 {
+		buf = append(buf, "[seq "...)
+		buf = strconv.AppendUint(buf, e.Seq, 10)
+		buf = append(buf, "] "...)
+	}
+	if e.Name != "" {
+		buf = append(buf, '[')
+		buf = append(buf, e.Name...)
+		buf = append(buf, "] "...)
+	}
+	buf = append(buf, e.Message...)
+	buf = appendFields(buf, e.Fields)
+	buf = append(buf, '\n')
+
+	return buf
+}
+
+// formatHeader follows the header layout of the standard log package,
+// but uses the entry time instead of the time of the write. A non-empty
+// timeFormat replaces the date and time flags.
+func formatHeader(buf *[]byte, t time.Time, prefix string, flags int, timeFormat string, file string, line int) {
+	if flags&log.Lmsgprefix == 0 {
+		*buf = append(*buf, prefix...)
+	}
+	if timeFormat != "" {
+		if flags&log.LUTC != 0 {
+			t = t.UTC()
+		}
+		*buf = t.AppendFormat(*buf, timeFormat)
+		*buf = append(*buf, ' ')
+	} else if flags&(Ldate|Ltime|Lmicroseconds) != 0 {
+		if flags&log.LUTC != 0 {
+			t = t.UTC()
+		}
+		if flags&Ldate != 0 {
+			year, month, day := t.Date()
+			itoa(buf, year, 4)
+			*buf = append(*buf, '/')
+			itoa(buf, int(month), 2)
+			*buf = append(*buf, '/')
+			itoa(buf, day, 2)
+			*buf = append(*buf, ' ')
+		}
+		if flags&(Ltime|Lmicroseconds) != 0 {
+			hour, min, sec := t.Clock()
+			itoa(buf, hour, 2)
+			*buf = append(*buf, ':')
+			itoa(buf, min, 2)
+			*buf = append(*buf, ':')
+			itoa(buf, sec, 2)
+			if flags&Lmicroseconds != 0 {
+				*buf = append(*buf, '.')
+				itoa(buf, t.Nanosecond()/1e3, 6)
+			}
+			*buf = append(*buf, ' ')
+		}
+	}
+	if flags&(Lshortfile|Llongfile) != 0 {
+		if flags&Lshortfile != 0 {
+			file = shortFileName(file)
+		}
+		*buf = append(*buf, file...)
+		*buf = append(*buf, ':')
+		itoa(buf, line, -1)
+		*buf = append(*buf, ": "...)
+	}
+	if flags&log.Lmsgprefix != 0 {
+		*buf = append(*buf, prefix...)
+	}
+}
+
+func shortFileName(file string) string {
+	for i := len(file) - 1; i > 0; i-- {
+		if file[i] == '/' {
+			return file[i+1:]
+		}
+	}
+	return file
+}
+
+func itoa(buf *[]byte, i int, wid int) {
+	var b [20]byte
+	bp := len(b) - 1
+	for i >= 10 || wid > 1 {
+		wid--
+		q := i / 10
+		b[bp] = byte('0' + i - q*10)
+		bp--
+		i = q
+	}
+	b[bp] = byte('0' + i)
+	*buf = append(*buf, b[bp:]...)
+}
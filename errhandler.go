@@ -0,0 +1,46 @@
+package log
+
+import (
+	"fmt"
+	"os"
+)
+
+// WriteError attributes a failed write to the output or sink it happened
+// in, e.g. "output app.20240102.log" or "sink 1 (*log.NetworkSink)". A
+// failing or panicking sink does not keep the entry from the others.
+type WriteError struct {
+	Component string
+	Err       error
+}
+
+func (e *WriteError) Error() string {
+	return e.Component + ": " + e.Err.Error()
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// SetErrorHandler makes write failures of the output and sinks of every
+// logger sharing l's root go to fn, as *WriteError, instead of stderr. nil
+// restores stderr. Entries dropped by the size quota or the memory limit
+// are only counted.
+func (l *Logger) SetErrorHandler(fn func(err error)) {
+	l.root().errorHandler.Store(errorHandler{fn})
+}
+
+// errorHandler is what SetErrorHandler stores. It is read without the lock
+// of the logger, so background writers reporting errors never wait for a
+// logging call blocked on their full queue.
+type errorHandler struct {
+	fn func(err error)
+}
+
+func (l *Logger) handleError(err error) {
+	h, _ := l.root().errorHandler.Load().(errorHandler)
+	if h.fn == nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return
+	}
+	h.fn(err)
+}
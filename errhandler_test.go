@@ -0,0 +1,59 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type panicSink struct{}
+
+func (panicSink) WriteEntry(e *Entry) error {
+	panic("sink bug")
+}
+
+type failSink struct{}
+
+func (failSink) WriteEntry(e *Entry) error {
+	return errTest
+}
+
+func TestSinkFailuresAreIsolated(t *testing.T) {
+	w := &slowWriter{err: errors.New("disk full")}
+	l := NewLogger(w, "", 0)
+	good := &entrySink{}
+	l.AddSink(panicSink{})
+	l.AddSink(failSink{})
+	l.AddSink(good)
+
+	var errs []string
+	l.Named("cmd").SetErrorHandler(func(err error) {
+		var we *WriteError
+		if !errors.As(err, &we) {
+			t.Errorf("%v is no *WriteError", err)
+		}
+		errs = append(errs, err.Error())
+	})
+	l.Info("ready")
+
+	if len(good.msgs) != 1 {
+		t.Errorf("good sink got %v", good.msgs)
+	}
+	want := []string{
+		"output *log.slowWriter: disk full",
+		"sink 0 (log.panicSink): panic: sink bug",
+		"sink 1 (log.failSink): " + errTest.Error(),
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors = %q, want %q", errs, want)
+	}
+	if !errors.Is(&WriteError{"sink 1", errTest}, errTest) {
+		t.Error("WriteError does not unwrap")
+	}
+
+	l.SetErrorHandler(nil)
+	out := captureStderr(t, func() { l.Info("ready") })
+	if !strings.Contains(out, "sink 1 (log.failSink)") {
+		t.Errorf("stderr = %q without a handler", out)
+	}
+}
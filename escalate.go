@@ -0,0 +1,103 @@
+package log
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+type escalation struct {
+	n      int
+	window time.Duration
+	key    string
+
+	lock  sync.Mutex
+	state map[string]*escalationState
+}
+
+type escalationState struct {
+	start time.Time
+	count int
+	done  bool
+}
+
+// EscalateWarnings writes a synthesized error entry when the same warning
+// occurs more than n times within window: "warning repeated 120 times in
+// 1m0s: <message>", with the count, the window and the key. Warnings are
+// told apart by the value of their key field, or by call site if key is
+// "" or the field is missing. A warning is escalated at most once per
+// window. n <= 0 turns escalation off.
+func (l *Logger) EscalateWarnings(n int, window time.Duration, key string) {
+	var esc *escalation
+	if n > 0 && window > 0 {
+		esc = &escalation{n: n, window: window, key: key, state: make(map[string]*escalationState)}
+	}
+
+	r := l.root()
+	r.lock.Lock()
+	r.escalation = esc
+	r.lock.Unlock()
+}
+
+// observe counts the warning e and returns the error entry to write if it
+// crossed the threshold.
+func (esc *escalation) observe(e *Entry) *Entry {
+	if e.Level != LOG_WARNING {
+		return nil
+	}
+
+	k := ""
+	for _, f := range e.Fields {
+		if esc.key != "" && f.Key == esc.key {
+			k = "=" + string(appendValue(nil, f.Value))
+			break
+		}
+	}
+	if k == "" {
+		k = e.File + ":" + strconv.Itoa(e.Line)
+	}
+
+	now := time.Now()
+
+	esc.lock.Lock()
+	defer esc.lock.Unlock()
+
+	s := esc.state[k]
+	if s == nil || now.Sub(s.start) > esc.window {
+		if s == nil && len(esc.state) >= COOLDOWN_MAX_KEYS {
+			esc.prune(now)
+			if len(esc.state) >= COOLDOWN_MAX_KEYS {
+				return nil
+			}
+		}
+		s = &escalationState{start: now}
+		esc.state[k] = s
+	}
+	s.count++
+	if s.count <= esc.n || s.done {
+		return nil
+	}
+	s.done = true
+
+	fields := []Field{{"count", s.count}, {"window", esc.window}}
+	if esc.key != "" && k[0] == '=' {
+		fields = append(fields, Field{esc.key, k[1:]})
+	}
+	return &Entry{
+		Level:   LOG_ERROR,
+		Time:    now,
+		Message: "warning repeated " + strconv.Itoa(s.count) + " times in " + esc.window.String() + ": " + e.Message,
+		Fields:  fields,
+		Name:    e.Name,
+		File:    e.File,
+		Line:    e.Line,
+	}
+}
+
+func (esc *escalation) prune(now time.Time) {
+	for k, s := range esc.state {
+		if now.Sub(s.start) > esc.window {
+			delete(esc.state, k)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscalateWarnings(t *testing.T) {
+	l, b := jsonLogger()
+	l.EscalateWarnings(3, time.Minute, "queue")
+	for i := 0; i < 6; i++ {
+		l.LogFields(LOG_WARNING, "queue full", F("queue", "mail"))
+		l.LogFields(LOG_WARNING, "queue full", F("queue", "sms"))
+	}
+
+	var errs []map[string]interface{}
+	for _, e := range decodeLines(t, b) {
+		if e["level"] == "error" {
+			errs = append(errs, e)
+		}
+	}
+	// once per key and window, on the fourth warning
+	if len(errs) != 2 {
+		t.Fatalf("%d escalations, want 2: %s", len(errs), b.String())
+	}
+	for i, queue := range []string{"mail", "sms"} {
+		msg, _ := errs[i]["message"].(string)
+		if errs[i]["queue"] != queue || errs[i]["count"] != float64(4) || !strings.HasPrefix(msg, "warning repeated 4 times in 1m0s: queue full") {
+			t.Errorf("escalation %d = %v", i, errs[i])
+		}
+	}
+}
+
+func TestEscalationKeyLimit(t *testing.T) {
+	esc := &escalation{n: 1, window: time.Hour, state: make(map[string]*escalationState)}
+	for i := 0; i < COOLDOWN_MAX_KEYS+10; i++ {
+		esc.observe(&Entry{Level: LOG_WARNING, File: "x.go", Line: i})
+	}
+	if n := len(esc.state); n != COOLDOWN_MAX_KEYS {
+		t.Errorf("%d keys tracked, want at most %d", n, COOLDOWN_MAX_KEYS)
+	}
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"errors"
+	"math/bits"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// how SetEveryN tells identical entries apart
+const (
+	EVERY_N_MESSAGE = "message"
+	EVERY_N_CALLER  = "caller"
+)
+
+// counts of identical entries are dropped once there are more than this
+const EVERY_N_MAX_KEYS = 4096
+
+type everyN struct {
+	n  [5]int
+	by string
+
+	lock sync.Mutex
+	held map[cooldownKey]int
+}
+
+// SetEveryN writes only the first of every n identical entries for each
+// level in n, e.g. {LOG_DEBUG: 100} for debug entries in a tight loop.
+// Entries are identical if they have the same message (EVERY_N_MESSAGE) or
+// come from the same call site (EVERY_N_CALLER). Written entries carry the
+// number of entries held back since the previous one as the suppressed
+// field; held back entries are counted as sampled in Stats. nil turns it
+// off.
+func (l *Logger) SetEveryN(n map[LogType]int, by string) error {
+	var s *everyN
+	if n != nil {
+		switch by {
+		case EVERY_N_MESSAGE, EVERY_N_CALLER:
+		default:
+			return errors.New("unknown SetEveryN key: " + by)
+		}
+		s = &everyN{by: by, held: make(map[cooldownKey]int)}
+		for t, every := range n {
+			if i := bits.TrailingZeros(uint(t)); i < len(s.n) {
+				s.n[i] = every
+			}
+		}
+	}
+
+	r := l.root()
+	r.lock.Lock()
+	r.everyN = s
+	r.lock.Unlock()
+	return nil
+}
+
+// allow reports whether e is the first of its group of n, adding the
+// suppressed field if entries were held back.
+func (s *everyN) allow(e *Entry) bool {
+	i := bits.TrailingZeros(uint(e.Level))
+	if i >= len(s.n) || s.n[i] <= 1 {
+		return true
+	}
+
+	k := cooldownKey{level: e.Level, key: e.Message}
+	if s.by == EVERY_N_CALLER {
+		k.key = e.File + ":" + strconv.Itoa(e.Line)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	held, ok := s.held[k]
+	if !ok {
+		if len(s.held) >= EVERY_N_MAX_KEYS {
+			s.prune()
+		}
+		if len(s.held) < EVERY_N_MAX_KEYS {
+			s.held[k] = 0
+		}
+		return true
+	}
+	if held+1 < s.n[i] {
+		s.held[k] = held + 1
+		return false
+	}
+	s.held[k] = 0
+	e.Fields = append(e.Fields, Field{"suppressed", held})
+	return true
+}
+
+// prune forgets the groups without held back entries.
+func (s *everyN) prune() {
+	for k, held := range s.held {
+		if held == 0 {
+			delete(s.held, k)
+		}
+	}
+}
+
+func (l *Logger) repeated(s *everyN, e *Entry) bool {
+	if s == nil || s.allow(e) {
+		return false
+	}
+	atomic.AddInt64(&l.counters.sampled, 1)
+	return true
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestEveryN(t *testing.T) {
+	l, b := jsonLogger()
+	if err := l.SetEveryN(map[LogType]int{LOG_DEBUG: 3}, "level"); err == nil {
+		t.Error("SetEveryN accepted an unknown key")
+	}
+	if err := l.Named("loop").SetEveryN(map[LogType]int{LOG_DEBUG: 3}, EVERY_N_MESSAGE); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		l.Debug("tick")
+		l.Warning("slow")
+	}
+	l.Debug("other")
+
+	var ticks []interface{}
+	warnings := 0
+	for _, e := range decodeLines(t, b) {
+		switch e["level"] {
+		case "debug":
+			ticks = append(ticks, e["suppressed"])
+		case "warning":
+			warnings++
+		}
+	}
+	// entries 1, 4 and 7 of tick, then other
+	if len(ticks) != 4 || ticks[0] != nil || ticks[1] != float64(2) || ticks[2] != float64(2) || ticks[3] != nil {
+		t.Errorf("debug entries have suppressed %v", ticks)
+	}
+	if warnings != 7 {
+		t.Errorf("%d warnings, want all 7", warnings)
+	}
+	if s := l.Stats(); s.Sampled != 4 {
+		t.Errorf("Sampled = %d, want 4", s.Sampled)
+	}
+
+	l.SetEveryN(nil, "")
+	b.Reset()
+	l.Debug("tick")
+	l.Debug("tick")
+	if n := len(decodeLines(t, b)); n != 2 {
+		t.Errorf("%d entries after turning it off", n)
+	}
+}
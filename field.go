@@ -0,0 +1,100 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Field is a key/value pair attached to an entry. In text output fields
+// follow the message as key=value.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Fields turns alternating keys and values, as taken by With, into fields,
+// e.g. for adapters of other logging APIs.
+func Fields(args ...interface{}) []Field {
+	return toFields(args)
+}
+
+// toFields turns alternating keys and values into fields. Field values are
+// taken as they are, so both styles can be mixed.
+func toFields(args []interface{}) []Field {
+	if len(args) == 0 {
+		return nil
+	}
+
+	fields := make([]Field, 0, len(args)/2+1)
+	for i := 0; i < len(args); i++ {
+		switch a := args[i].(type) {
+		case Field:
+			fields = append(fields, a)
+		case string:
+			if i+1 < len(args) {
+				fields = append(fields, Field{a, args[i+1]})
+				i++
+			} else {
+				fields = append(fields, Field{"!BADKEY", a})
+			}
+		default:
+			fields = append(fields, Field{"!BADKEY", a})
+		}
+	}
+	return fields
+}
+
+func appendFields(buf []byte, fields []Field) []byte {
+	for _, f := range fields {
+		buf = append(buf, ' ')
+		buf = append(buf, f.Key...)
+		buf = append(buf, '=')
+		buf = appendValue(buf, f.Value)
+	}
+	return buf
+}
+
+func appendValue(buf []byte, v interface{}) []byte {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case int, int64, int32, uint, uint64, uint32, bool:
+		// never quoted
+		return appendOperand(buf, v)
+	case error:
+		if isNil(v) {
+			s = NIL_VALUE
+		} else {
+			s = errorString(v)
+		}
+	default:
+		if isNil(v) {
+			s = NIL_VALUE
+		} else {
+			s = fmt.Sprint(v)
+		}
+	}
+	if needsQuote(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+func needsQuote(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c == '"' || c == '=' || c == 0x7f || c >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"flag"
+	"strconv"
+)
+
+// Flags holds the standard logging command line flags shared by our CLIs.
+type Flags struct {
+	// Level is an explicit level name and wins over Verbosity
+	Level string
+	// File, if set, sends output to rotating files with this base name
+	File string
+	// Verbosity is the number of -v flags
+	Verbosity int
+}
+
+// VerbosityToLogLevel maps a count of -v flags to a level: without any only
+// warnings and errors are written, -v adds info and -vv adds debug.
+func VerbosityToLogLevel(v int) LogLevel {
+	switch {
+	case v <= 0:
+		return LOG_LEVEL_WARN
+	case v == 1:
+		return LOG_LEVEL_INFO
+	}
+	return LOG_LEVEL_DEBUG
+}
+
+// BindFlags registers -log-level, -log-file and the verbosity flags -v
+// (repeatable, or -v=N) and -vv on fs.
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.Level, "log-level", "", "log level: fatal, error, warn, info or debug")
+	fs.StringVar(&f.File, "log-file", "", "write logs to rotating files with this base name")
+	fs.Var((*verbosity)(&f.Verbosity), "v", "increase verbosity: -v for info, -vv for debug")
+	fs.Var(&verbosityFlag{n: &f.Verbosity, add: 2}, "vv", "same as -v -v")
+	return f
+}
+
+// Apply configures l from the flags that were given: the level only if
+// Level or Verbosity is set, so l keeps its own level otherwise, and the
+// output only if File is. Unlike SetOutputByName it returns the error of
+// opening File.
+func (f *Flags) Apply(l *Logger) error {
+	if f.Level != "" {
+		l.SetLevelByString(f.Level)
+	} else if f.Verbosity != 0 {
+		l.SetLevel(VerbosityToLogLevel(f.Verbosity))
+	}
+	if f.File != "" {
+		return l.root().openFile(f.File)
+	}
+	return nil
+}
+
+type verbosity int
+
+func (v *verbosity) String() string {
+	if v == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*v))
+}
+
+func (v *verbosity) Set(s string) error {
+	if s == "true" {
+		*v++
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = verbosity(n)
+	return nil
+}
+
+func (v *verbosity) IsBoolFlag() bool {
+	return true
+}
+
+type verbosityFlag struct {
+	n   *int
+	add int
+}
+
+func (v *verbosityFlag) String() string {
+	return ""
+}
+
+func (v *verbosityFlag) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err == nil && on {
+		*v.n += v.add
+	}
+	return err
+}
+
+func (v *verbosityFlag) IsBoolFlag() bool {
+	return true
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+)
+
+func TestFlagsApply(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want LogLevel
+	}{
+		{nil, LOG_LEVEL_DEBUG},
+		{[]string{"-v"}, LOG_LEVEL_INFO},
+		{[]string{"-vv"}, LOG_LEVEL_DEBUG},
+		{[]string{"-v", "-log-level", "error"}, LOG_LEVEL_ERROR},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		f := BindFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		l := NewLogger(&bytes.Buffer{}, "", 0)
+		l.SetLevel(LOG_LEVEL_DEBUG)
+		if err := f.Apply(l); err != nil {
+			t.Fatal(err)
+		}
+		if got := l.Level(); got != tt.want {
+			t.Errorf("%v: level %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestFlagsApplyFileError(t *testing.T) {
+	f := &Flags{File: "/dev/null/app"}
+	l := NewLogger(&bytes.Buffer{}, "", 0)
+	if err := f.Apply(l); err == nil {
+		t.Error("no error for a file that cannot be opened")
+	}
+}
@@ -0,0 +1,134 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Flusher is implemented by outputs and sinks that hold entries back, such
+// as queues and spools. Flush delivers them until ctx ends and returns how
+// many are left.
+type Flusher interface {
+	Flush(ctx context.Context) (remaining int, err error)
+}
+
+// Syncer is implemented by outputs and sinks that can commit what they
+// wrote to stable storage, such as files.
+type Syncer interface {
+	Sync() error
+}
+
+// UnflushedError is returned by Flush when entries were still held back
+// when ctx ended, or when a component failed to flush.
+type UnflushedError struct {
+	Remaining int
+	Err       error
+}
+
+func (e *UnflushedError) Error() string {
+	return fmt.Sprintf("log: %d entries not flushed: %v", e.Remaining, e.Err)
+}
+
+func (e *UnflushedError) Unwrap() error {
+	return e.Err
+}
+
+// Flush drains the output and the sinks of l's root: Flushers are flushed,
+// Syncers synced, in order, and Flush returns once all are done or ctx
+// ends, whichever comes first. A Sync in progress is not interrupted.
+func (l *Logger) Flush(ctx context.Context) error {
+	r := l.root()
+
+	r.lock.Lock()
+	targets := make([]interface{}, 0, len(r.sinks)+1)
+	targets = append(targets, r._log.Writer())
+	for _, c := range r.sinks {
+		if c.async != nil {
+			targets = append(targets, c.async)
+		}
+		targets = append(targets, c.sink)
+	}
+	r.lock.Unlock()
+
+	remaining := 0
+	var first error
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			if first == nil {
+				first = ctx.Err()
+			}
+			if f, ok := t.(interface{ Pending() int }); ok {
+				remaining += f.Pending()
+			}
+			continue
+		}
+
+		var err error
+		switch t := t.(type) {
+		case Flusher:
+			var n int
+			n, err = t.Flush(ctx)
+			remaining += n
+		case Syncer:
+			if !isStdStream(t) {
+				err = t.Sync()
+			}
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	if first != nil || remaining > 0 {
+		if first == nil {
+			first = ctx.Err()
+		}
+		return &UnflushedError{remaining, first}
+	}
+	return nil
+}
+
+// how long SpoolSink.Flush waits between delivery attempts
+const SPOOL_RETRY_INTERVAL = 100 * time.Millisecond
+
+// Flush retries delivering the unacknowledged entries until they are all
+// acknowledged or ctx ends.
+func (s *SpoolSink) Flush(ctx context.Context) (int, error) {
+	for {
+		s.lock.Lock()
+		closed := s.closed
+		if !closed {
+			s.deliver()
+		}
+		n := int(s.seq - s.hwm)
+		s.lock.Unlock()
+
+		if n == 0 {
+			return 0, nil
+		}
+		if closed {
+			return n, os.ErrClosed
+		}
+
+		t := time.NewTimer(SPOOL_RETRY_INTERVAL)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return n, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Sync commits the current file to disk.
+func (w *RotatingWriter) Sync() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if s, ok := w.fd.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// stuckSink holds back n entries until ctx ends.
+type stuckSink struct {
+	n int
+}
+
+func (s *stuckSink) WriteEntry(e *Entry) error { return nil }
+
+func (s *stuckSink) Flush(ctx context.Context) (int, error) {
+	if s.n == 0 {
+		return 0, nil
+	}
+	<-ctx.Done()
+	return s.n, ctx.Err()
+}
+
+func (s *stuckSink) Pending() int { return s.n }
+
+// syncSink counts Syncs.
+type syncSink struct {
+	synced int
+}
+
+func (s *syncSink) WriteEntry(e *Entry) error { return nil }
+
+func (s *syncSink) Sync() error {
+	s.synced++
+	return nil
+}
+
+func TestFlush(t *testing.T) {
+	l := NewLogger(io.Discard, "", 0)
+	sync := &syncSink{}
+	l.AddSink(sync)
+	stuck := &stuckSink{}
+	l.AddSink(stuck)
+	if err := l.Named("child").Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if sync.synced != 1 {
+		t.Errorf("%d syncs, want 1", sync.synced)
+	}
+
+	stuck.n = 3
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := l.Flush(ctx)
+	var u *UnflushedError
+	if !errors.As(err, &u) || u.Remaining != 3 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want 3 entries unflushed at the deadline", err)
+	}
+}
+
+func TestFlushEndedContext(t *testing.T) {
+	l := NewLogger(io.Discard, "", 0)
+	sync := &syncSink{}
+	l.AddSink(sync)
+	l.AddSink(&stuckSink{n: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := l.Flush(ctx)
+	var u *UnflushedError
+	if !errors.As(err, &u) || u.Remaining != 2 || !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the pending entries counted", err)
+	}
+	if sync.synced != 0 {
+		t.Error("synced after ctx ended")
+	}
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Formatter renders whole lines for a Logger set up with SetFormatter,
+// replacing the level tag, the flags and the prefix. caller is file:line,
+// or "" when not known; the file is shortened unless Llongfile is set.
+// msg carries the fields of the entry as " key=value" pairs after the
+// message. A missing trailing newline is added.
+type Formatter interface {
+	Format(level LogType, t time.Time, caller string, msg string) []byte
+}
+
+// FormatterFunc adapts a function to Formatter.
+type FormatterFunc func(level LogType, t time.Time, caller string, msg string) []byte
+
+func (f FormatterFunc) Format(level LogType, t time.Time, caller string, msg string) []byte {
+	return f(level, t, caller, msg)
+}
+
+// SetFormatter makes f render every line written to the output; nil goes
+// back to the configured Format. If f panics the entry is written in the
+// configured Format instead.
+func (l *Logger) SetFormatter(f Formatter) {
+	r := l.root()
+	r.lock.Lock()
+	r.formatter = f
+	r.lock.Unlock()
+}
+
+func encodeFormatter(buf []byte, f Formatter, flags int, e *Entry) (out []byte, ok bool) {
+	defer func() {
+		if p := recover(); p != nil {
+			fmt.Fprintf(os.Stderr, "log: formatter panicked: %v\n", p)
+			out, ok = buf, false
+		}
+	}()
+
+	caller := ""
+	if e.File != "" {
+		file := e.File
+		if flags&Llongfile == 0 {
+			file = shortFileName(file)
+		}
+		caller = file + ":" + strconv.Itoa(e.Line)
+	}
+	if tf, ok := f.(*TemplateFormatter); ok {
+		b, err := tf.formatEntry(buf, e, caller)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "log: template failed: %v\n", err)
+			return buf, false
+		}
+		if len(b) == len(buf) || b[len(b)-1] != '\n' {
+			b = append(b, '\n')
+		}
+		return b, true
+	}
+
+	msg := e.Message
+	if len(e.Fields) > 0 {
+		msg = string(appendFields([]byte(msg), e.Fields))
+	}
+
+	b := f.Format(e.Level, e.Time, caller, msg)
+	buf = append(buf, b...)
+	if len(b) == 0 || b[len(b)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+	return buf, true
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetFormatter(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "prefix ", Lshortfile)
+	l.SetFormatter(FormatterFunc(func(level LogType, ts time.Time, caller string, msg string) []byte {
+		return []byte(fmt.Sprintf("%s|%s|%s", LogTypeToString(level), caller, msg))
+	}))
+	_, start := here()
+	l.LogFields(LOG_WARNING, "slow", F("ms", 30))
+
+	want := fmt.Sprintf("warning|formatter_test.go:%d|slow ms=30\n", start+1)
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	l.SetFormatter(nil)
+	l.Info("plain")
+	if !strings.HasPrefix(b.String(), "prefix ") {
+		t.Errorf("output = %q after removing the formatter", b.String())
+	}
+}
+
+func TestFormatterPanic(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.SetFormatter(FormatterFunc(func(LogType, time.Time, string, string) []byte { panic("broken") }))
+	stderr := captureStderr(t, func() { l.Info("kept") })
+
+	if b.String() != "[info] kept \n" {
+		t.Errorf("output = %q, want the configured format", b.String())
+	}
+	if !strings.Contains(stderr, "formatter panicked: broken") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
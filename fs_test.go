@@ -0,0 +1,287 @@
+package log
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memFS is an in-memory FileSystem without directories. Open files keep
+// their node when the name is removed or renamed, as with inodes.
+type memFS struct {
+	nodes map[string]*memNode
+	lock  sync.Mutex
+}
+
+type memNode struct {
+	data    []byte
+	modTime time.Time
+	// target of a symbolic link
+	link string
+}
+
+func newMemFS() *memFS {
+	return &memFS{nodes: make(map[string]*memNode)}
+}
+
+func (fs *memFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	n, err := fs.resolve(name)
+	if os.IsNotExist(err) && flag&os.O_CREATE != 0 {
+		n = &memNode{modTime: time.Now()}
+		fs.nodes[name] = n
+		err = nil
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	if flag&os.O_TRUNC != 0 {
+		n.data = nil
+	}
+	return &memFile{fs: fs, node: n, name: name}, nil
+}
+
+// resolve returns the node of name, following symbolic links. fs.lock is
+// held.
+func (fs *memFS) resolve(name string) (*memNode, error) {
+	for i := 0; i < 8; i++ {
+		n, ok := fs.nodes[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		if n.link == "" {
+			return n, nil
+		}
+		if path.IsAbs(n.link) {
+			name = n.link
+		} else {
+			name = path.Join(path.Dir(name), n.link)
+		}
+	}
+	return nil, os.ErrInvalid
+}
+
+func (fs *memFS) Rename(oldpath, newpath string) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	n, ok := fs.nodes[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	delete(fs.nodes, oldpath)
+	fs.nodes[newpath] = n
+	return nil
+}
+
+func (fs *memFS) Remove(name string) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if _, ok := fs.nodes[name]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(fs.nodes, name)
+	return nil
+}
+
+func (fs *memFS) Stat(name string) (os.FileInfo, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	n, err := fs.resolve(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return n.info(name), nil
+}
+
+func (fs *memFS) Lstat(name string) (os.FileInfo, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	n, ok := fs.nodes[name]
+	if !ok {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+	return n.info(name), nil
+}
+
+func (fs *memFS) Symlink(oldname, newname string) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if _, ok := fs.nodes[newname]; ok {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: os.ErrExist}
+	}
+	fs.nodes[newname] = &memNode{link: oldname, modTime: time.Now()}
+	return nil
+}
+
+func (fs *memFS) Chtimes(name string, atime, mtime time.Time) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	n, err := fs.resolve(name)
+	if err != nil {
+		return &os.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	n.modTime = mtime
+	return nil
+}
+
+func (fs *memFS) Glob(pattern string) ([]string, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	var names []string
+	for name := range fs.nodes {
+		ok, err := filepath.Match(pattern, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// write creates name with data, last written at mtime.
+func (fs *memFS) write(name, data string, mtime time.Time) {
+	fs.lock.Lock()
+	fs.nodes[name] = &memNode{data: []byte(data), modTime: mtime}
+	fs.lock.Unlock()
+}
+
+// read returns the content of name, "" if it does not exist.
+func (fs *memFS) read(name string) string {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	n, err := fs.resolve(name)
+	if err != nil {
+		return ""
+	}
+	return string(n.data)
+}
+
+// names returns the base names of the files and links in fs, sorted.
+func (fs *memFS) names() []string {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	var names []string
+	for name := range fs.nodes {
+		names = append(names, path.Base(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (n *memNode) info(name string) os.FileInfo {
+	return memInfo{name: path.Base(name), size: int64(len(n.data)), modTime: n.modTime, link: n.link != ""}
+}
+
+type memFile struct {
+	fs     *memFS
+	node   *memNode
+	name   string
+	off    int
+	closed bool
+}
+
+func (f *memFile) Name() string {
+	return f.name
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.lock.Lock()
+	defer f.fs.lock.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	f.node.data = append(f.node.data, p...)
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.lock.Lock()
+	defer f.fs.lock.Unlock()
+
+	if f.off >= len(f.node.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.off:])
+	f.off += n
+	return n, nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.lock.Lock()
+	defer f.fs.lock.Unlock()
+
+	return f.node.info(f.name), nil
+}
+
+func (f *memFile) Close() error {
+	f.fs.lock.Lock()
+	defer f.fs.lock.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
+
+type memInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	link    bool
+}
+
+func (fi memInfo) Name() string       { return fi.name }
+func (fi memInfo) Size() int64        { return fi.size }
+func (fi memInfo) ModTime() time.Time { return fi.modTime }
+func (fi memInfo) IsDir() bool        { return false }
+func (fi memInfo) Sys() interface{}   { return nil }
+
+func (fi memInfo) Mode() os.FileMode {
+	if fi.link {
+		return os.ModeSymlink | 0777
+	}
+	return 0666
+}
+
+func TestMemFS(t *testing.T) {
+	fs := newMemFS()
+	f, err := fs.OpenFile("/logs/a", os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("x"))
+	fs.Rename("/logs/a", "/logs/b")
+	f.Write([]byte("y"))
+	if got := fs.read("/logs/b"); got != "xy" {
+		t.Errorf("renamed file = %q, want %q", got, "xy")
+	}
+	fs.Symlink("b", "/logs/link")
+	if got := fs.read("/logs/link"); got != "xy" {
+		t.Errorf("link = %q, want %q", got, "xy")
+	}
+	if _, err := fs.Stat("/logs/a"); !os.IsNotExist(err) {
+		t.Errorf("stat of renamed name: %v, want not exist", err)
+	}
+}
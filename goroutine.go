@@ -0,0 +1,53 @@
+package log
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Go runs fn in a new goroutine, logging its start, exit, duration,
+// returned error and any recovered panic under name.
+func Go(l *Logger, name string, fn func() error) {
+	go goFunc(l, name, fn, callerPC(1))()
+}
+
+// GoFunc wraps fn with the same lifecycle logging as Go without starting a
+// goroutine, so the result can be passed to errgroup.Group.Go. A panic in fn
+// is recovered, logged and returned as an error.
+func GoFunc(l *Logger, name string, fn func() error) func() error {
+	return goFunc(l, name, fn, callerPC(1))
+}
+
+// goFunc is GoFunc for entries reporting pc, the call site of Go or GoFunc,
+// as their caller.
+func goFunc(l *Logger, name string, fn func() error, pc uintptr) func() error {
+	return func() (err error) {
+		start := time.Now()
+		goLog(l, pc, LOG_INFO, fmt.Sprintf("goroutine %s started", name), nil)
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("goroutine %s panic: %v", name, r)
+				fields := append(PanicFields(r), Field{"stack", string(debug.Stack())})
+				goLog(l, pc, LOG_ERROR, fmt.Sprintf("goroutine %s panic after %s", name, time.Since(start)), fields)
+				return
+			}
+			if err != nil {
+				goLog(l, pc, LOG_ERROR, fmt.Sprintf("goroutine %s exited after %s: %v", name, time.Since(start), err), nil)
+				return
+			}
+			goLog(l, pc, LOG_INFO, fmt.Sprintf("goroutine %s exited after %s", name, time.Since(start)), nil)
+		}()
+
+		return fn()
+	}
+}
+
+func goLog(l *Logger, pc uintptr, t LogType, msg string, fields []Field) {
+	if !l.enabled(t) {
+		return
+	}
+
+	l.output(0, &Entry{Level: t, Time: time.Now(), Message: msg, Fields: fields, pc: pc})
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoFuncLifecycle(t *testing.T) {
+	l, b := jsonLogger()
+	if err := GoFunc(l, "ok", func() error { return nil })(); err != nil {
+		t.Errorf("ok returned %v", err)
+	}
+	if err := GoFunc(l, "failed", func() error { return errTest })(); err != errTest {
+		t.Errorf("failed returned %v, want %v", err, errTest)
+	}
+	err := GoFunc(l, "panic", func() error { panic("boom") })()
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("panic returned %v, want the recovered value", err)
+	}
+
+	want := []struct{ level, prefix string }{
+		{"info", "goroutine ok started"},
+		{"info", "goroutine ok exited after"},
+		{"info", "goroutine failed started"},
+		{"error", "goroutine failed exited after"},
+		{"info", "goroutine panic started"},
+		{"error", "goroutine panic panic after"},
+	}
+	got := decodeLines(t, b)
+	if len(got) != len(want) {
+		t.Fatalf("%d entries, want %d: %s", len(got), len(want), b.String())
+	}
+	for i, w := range want {
+		msg, _ := got[i]["message"].(string)
+		if got[i]["level"] != w.level || !strings.HasPrefix(msg, w.prefix) {
+			t.Errorf("entry %d = %v %q, want %s %q...", i, got[i]["level"], msg, w.level, w.prefix)
+		}
+	}
+	if got[5]["stack"] == nil {
+		t.Error("panic entry has no stack")
+	}
+}
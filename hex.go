@@ -0,0 +1,42 @@
+package log
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+)
+
+// HexValue is the value of a Hex field.
+type HexValue struct {
+	Len       int    `json:"len"`
+	Hex       string `json:"hex"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func (h HexValue) String() string {
+	s := "len=" + strconv.Itoa(h.Len) + " " + h.Hex
+	if h.Truncated {
+		s += "..."
+	}
+	return s
+}
+
+// MarshalJSON writes h as an object; without it the JSON encoder would use
+// String.
+func (h HexValue) MarshalJSON() ([]byte, error) {
+	type value HexValue
+	return json.Marshal(value(h))
+}
+
+// Hex returns a field rendering at most max bytes of b as hex together with
+// the full length of b, so binary payloads can be logged without corrupting
+// text output or blowing up the entry. max <= 0 renders all of b.
+func Hex(key string, b []byte, max int) Field {
+	v := HexValue{Len: len(b)}
+	if max > 0 && len(b) > max {
+		b = b[:max]
+		v.Truncated = true
+	}
+	v.Hex = hex.EncodeToString(b)
+	return Field{Key: key, Value: v}
+}
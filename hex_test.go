@@ -0,0 +1,38 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHex(t *testing.T) {
+	payload := []byte{0x00, 0xff, 0x0a, 0x41}
+	for _, tt := range []struct {
+		max  int
+		want HexValue
+	}{
+		{0, HexValue{Len: 4, Hex: "00ff0a41"}},
+		{4, HexValue{Len: 4, Hex: "00ff0a41"}},
+		{2, HexValue{Len: 4, Hex: "00ff", Truncated: true}},
+	} {
+		f := Hex("body", payload, tt.max)
+		if f.Key != "body" || f.Value != tt.want {
+			t.Errorf("max %d: %v, want %v", tt.max, f.Value, tt.want)
+		}
+	}
+
+	var b bytes.Buffer
+	NewLogger(&b, "", 0).LogFields(LOG_INFO, "packet", Hex("body", payload, 2))
+	if got := b.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "len=4 00ff...") {
+		t.Errorf("text = %q", got)
+	}
+
+	l, jb := jsonLogger()
+	l.LogFields(LOG_INFO, "packet", Hex("body", payload, 2))
+	got := decodeLines(t, jb)
+	body, _ := got[0]["body"].(map[string]interface{})
+	if body["len"] != float64(4) || body["hex"] != "00ff" || body["truncated"] != true {
+		t.Errorf("JSON body = %v", got[0]["body"])
+	}
+}
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// hooks panicking this many times are disabled
+const HOOK_MAX_PANICS = 3
+
+type hook struct {
+	name     string
+	fn       func(e *Entry) bool
+	panics   int64 // accessed atomically
+	disabled int32 // accessed atomically
+}
+
+// HookStat reports the health of one hook or filter.
+type HookStat struct {
+	Name     string
+	Panics   int64
+	Disabled bool
+}
+
+// AddHook runs fn on every entry before it is written, e.g. to add or mask
+// fields. Hooks run in the order they were added, on the logging
+// goroutine, before the filters, sampling and cooldowns, so they also see
+// the entries those drop. A panic in fn is recovered and the entry written
+// as it is; after HOOK_MAX_PANICS panics the hook is disabled.
+func (l *Logger) AddHook(name string, fn func(e *Entry)) {
+	l.addHook(name, func(e *Entry) bool {
+		fn(e)
+		return true
+	})
+}
+
+// AddFilter drops the entries for which fn returns false. Filters run in
+// the order they were added, after the hooks, so they see the fields hooks
+// add. It is isolated like a hook: entries are kept when fn panics.
+func (l *Logger) AddFilter(name string, fn func(e *Entry) bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.filters = append(r.filters[:len(r.filters):len(r.filters)], &hook{name: name, fn: fn})
+	r.lock.Unlock()
+}
+
+func (l *Logger) addHook(name string, fn func(e *Entry) bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.hooks = append(r.hooks[:len(r.hooks):len(r.hooks)], &hook{name: name, fn: fn})
+	r.lock.Unlock()
+}
+
+func (l *Logger) HookStats() []HookStat {
+	r := l.root()
+	r.lock.Lock()
+	hooks := append(r.hooks[:len(r.hooks):len(r.hooks)], r.filters...)
+	r.lock.Unlock()
+
+	stats := make([]HookStat, len(hooks))
+	for i, h := range hooks {
+		stats[i] = HookStat{h.name, atomic.LoadInt64(&h.panics), atomic.LoadInt32(&h.disabled) != 0}
+	}
+	return append(stats, r.stageStats()...)
+}
+
+// runHooks runs hooks on e and reports whether it is to be written.
+func runHooks(hooks []*hook, e *Entry) bool {
+	for _, h := range hooks {
+		if atomic.LoadInt32(&h.disabled) != 0 {
+			continue
+		}
+		if !h.run(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *hook) run(e *Entry) (keep bool) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		keep = true
+		n := atomic.AddInt64(&h.panics, 1)
+		if n >= HOOK_MAX_PANICS && atomic.CompareAndSwapInt32(&h.disabled, 0, 1) {
+			fmt.Fprintf(os.Stderr, "log: hook %s panicked %d times, disabled: %v\n", h.name, n, p)
+		} else if n == 1 {
+			fmt.Fprintf(os.Stderr, "log: hook %s panicked: %v\n", h.name, p)
+		}
+	}()
+	return h.fn(e)
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHookPanicIsolation(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	calls := 0
+	l.AddHook("broken", func(e *Entry) { calls++; panic("nil map") })
+	l.AddHook("tag", func(e *Entry) { e.Fields = append(e.Fields, F("tagged", true)) })
+	l.AddFilter("drop-debug", func(e *Entry) bool { return e.Level != LOG_DEBUG })
+	l.AddFilter("broken-filter", func(e *Entry) bool { panic("bad filter") })
+
+	stderr := captureStderr(t, func() {
+		for i := 0; i < 5; i++ {
+			l.Info("entry")
+		}
+		l.Debug("dropped")
+	})
+
+	if got := strings.Count(b.String(), "tagged=true"); got != 5 {
+		t.Errorf("%d entries written with the later hook applied, want 5:\n%s", got, b.String())
+	}
+	if strings.Contains(b.String(), "dropped") {
+		t.Error("filter did not drop the debug entry")
+	}
+	if calls != HOOK_MAX_PANICS {
+		t.Errorf("broken hook ran %d times, want %d", calls, HOOK_MAX_PANICS)
+	}
+	if !strings.Contains(stderr, "hook broken panicked 3 times, disabled") {
+		t.Errorf("stderr = %q", stderr)
+	}
+
+	stats := map[string]HookStat{}
+	for _, s := range l.HookStats() {
+		stats[s.Name] = s
+	}
+	if s := stats["broken"]; s.Panics != 3 || !s.Disabled {
+		t.Errorf("broken: %+v", s)
+	}
+	if s := stats["tag"]; s.Panics != 0 || s.Disabled {
+		t.Errorf("tag: %+v", s)
+	}
+	if s := stats["broken-filter"]; s.Panics != 3 || !s.Disabled {
+		t.Errorf("broken-filter: %+v", s)
+	}
+}
@@ -0,0 +1,155 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// an index sidecar is named after its log file with this extension
+const INDEX_EXT = ".idx"
+
+// indexWriter appends "<unix nanoseconds> <offset>" lines to the sidecar of
+// a log file, one every n entries. It is best effort: the index is dropped
+// at the first error.
+type indexWriter struct {
+	f     File
+	every int
+	n     int
+}
+
+func openIndex(fs FileSystem, name string, every int) *indexWriter {
+	f, err := fs.OpenFile(name+INDEX_EXT, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log: open index: %s\n", err)
+		return nil
+	}
+	return &indexWriter{f: f, every: every}
+}
+
+// mark is called before each entry with the offset it is written at.
+func (x *indexWriter) mark(offset int64) {
+	if x == nil || x.f == nil {
+		return
+	}
+	x.n++
+	if (x.n-1)%x.every != 0 {
+		return
+	}
+
+	b := strconv.AppendInt(nil, time.Now().UnixNano(), 10)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, offset, 10)
+	b = append(b, '\n')
+	_, err := x.f.Write(b)
+	if err != nil {
+		x.close()
+	}
+}
+
+func (x *indexWriter) close() {
+	if x == nil || x.f == nil {
+		return
+	}
+	x.f.Close()
+	x.f = nil
+}
+
+// SeekIndex returns the offset of the last indexed entry written before
+// t, read from index, so decoding from there sees every entry logged from
+// t on. It only holds for entries logged with the current time, not for
+// ones with their own timestamps such as LogAt.
+func SeekIndex(index io.Reader, t time.Time) (int64, error) {
+	var offset int64
+	sc := bufio.NewScanner(index)
+	for sc.Scan() {
+		ts, off, ok := strings.Cut(sc.Text(), " ")
+		if !ok {
+			// torn last line
+			continue
+		}
+		ns, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			continue
+		}
+		if ns >= t.UnixNano() {
+			break
+		}
+		o, err := strconv.ParseInt(off, 10, 64)
+		if err == nil {
+			offset = o
+		}
+	}
+	return offset, sc.Err()
+}
+
+// RangeReader decodes the entries of one log file within a time range,
+// seeking with the index sidecar if there is one.
+type RangeReader struct {
+	f        *os.File
+	d        *Decoder
+	from, to time.Time
+}
+
+// OpenRange opens the log file at path for the entries from from up to to.
+// A zero to reads to the end. Entries without a timestamp are always
+// returned.
+func OpenRange(path string, from, to time.Time) (*RangeReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if idx, err := os.Open(path + INDEX_EXT); err == nil {
+		offset, err := SeekIndex(idx, from)
+		idx.Close()
+		if err == nil {
+			_, err = f.Seek(offset, io.SeekStart)
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return &RangeReader{f: f, d: NewDecoder(f), from: from, to: to}, nil
+}
+
+// Decode returns the next entry in the range, or io.EOF past its end.
+func (r *RangeReader) Decode() (*Entry, error) {
+	for {
+		e, err := r.d.Decode()
+		if err != nil {
+			return e, err
+		}
+		if e.Time.IsZero() {
+			return e, nil
+		}
+		if e.Time.Before(truncateLike(r.from, e.Time)) {
+			continue
+		}
+		if !r.to.IsZero() && e.Time.After(truncateLike(r.to, e.Time)) {
+			return nil, io.EOF
+		}
+		return e, nil
+	}
+}
+
+// truncateLike truncates t to the precision of the timestamp ts, e.g. to
+// seconds for text entries written without Lmicroseconds.
+func truncateLike(t, ts time.Time) time.Time {
+	for _, d := range []time.Duration{time.Second, time.Millisecond, time.Microsecond} {
+		if ts.Nanosecond()%int(d) == 0 {
+			return t.Truncate(d)
+		}
+	}
+	return t
+}
+
+func (r *RangeReader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeekIndex(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	index := fmt.Sprintf("%d 0\n%d 120\n%d 240\n%d", base.UnixNano(), base.Add(time.Minute).UnixNano(),
+		base.Add(2*time.Minute).UnixNano(), base.Add(3*time.Minute).UnixNano())
+
+	for _, tt := range []struct {
+		t    time.Time
+		want int64
+	}{
+		{base.Add(-time.Hour), 0},
+		{base.Add(30 * time.Second), 0},
+		{base.Add(time.Minute), 0},
+		{base.Add(90 * time.Second), 120},
+		// the torn last line is skipped
+		{base.Add(time.Hour), 240},
+	} {
+		got, err := SeekIndex(strings.NewReader(index), tt.t)
+		if err != nil || got != tt.want {
+			t.Errorf("SeekIndex(%v) = %d, %v, want %d", tt.t.Sub(base), got, err, tt.want)
+		}
+	}
+}
+
+func TestRotateIndexEvery(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.IndexEvery = 2 })
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		w.Write([]byte(line))
+	}
+	w.Close()
+
+	var offsets []string
+	for _, line := range strings.Split(strings.TrimSpace(fs.read("/logs/"+today()+INDEX_EXT)), "\n") {
+		_, off, _ := strings.Cut(line, " ")
+		offsets = append(offsets, off)
+	}
+	// entries one, three and five
+	if want := []string{"0", "8", "19"}; !equalNames(offsets, want) {
+		t.Errorf("indexed offsets = %v, want %v", offsets, want)
+	}
+}
+
+func TestOpenRange(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.SetFormat(FORMAT_JSON)
+	var index strings.Builder
+	for i := 0; i < 6; i++ {
+		ts := base.Add(time.Duration(i) * time.Minute)
+		if i%2 == 0 {
+			fmt.Fprintf(&index, "%d %d\n", ts.UnixNano(), b.Len())
+		}
+		l.LogAt(LOG_INFO, ts, "entry", i)
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+INDEX_EXT, []byte(index.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenRange(path, base.Add(3*time.Minute), base.Add(4*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var got []string
+	for {
+		e, err := r.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, strings.TrimSpace(e.Message))
+	}
+	if want := []string{"entry 3", "entry 4"}; !equalNames(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
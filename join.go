@@ -0,0 +1,127 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Joiner assembles the message of Print-style calls (Info, Error, ...)
+// from their operands.
+type Joiner func(v []interface{}) string
+
+var (
+	// JoinLegacy is the historical layout: a space between all operands
+	// and one trailing space.
+	JoinLegacy Joiner = sprintln
+	// JoinSpace puts a space between all operands and nothing after them.
+	JoinSpace Joiner = JoinWith(" ")
+	// JoinSprint follows fmt.Sprint: spaces only between operands when
+	// neither is a string.
+	JoinSprint Joiner = func(v []interface{}) string { return fmt.Sprint(v...) }
+)
+
+// JoinWith formats every operand with fmt.Sprint and joins them with sep.
+func JoinWith(sep string) Joiner {
+	return func(v []interface{}) string {
+		switch len(v) {
+		case 0:
+			return ""
+		case 1:
+			return fmt.Sprint(v[0])
+		}
+		buf := getBuffer()
+		for i, a := range v {
+			if i > 0 {
+				buf.b = append(buf.b, sep...)
+			}
+			buf.b = appendOperand(buf.b, a)
+		}
+		s := string(buf.b)
+		buf.Free()
+		return s
+	}
+}
+
+// SetJoiner changes how Print-style calls assemble their message, for
+// every logger sharing l's root. nil restores JoinLegacy.
+func (l *Logger) SetJoiner(j Joiner) {
+	r := l.root()
+	r.lock.Lock()
+	r.joiner = j
+	r.lock.Unlock()
+}
+
+// joinerByName maps the MessageJoin config values to joiners.
+func joinerByName(name string) (Joiner, error) {
+	switch strings.ToLower(name) {
+	case "", "legacy":
+		return nil, nil
+	case "space":
+		return JoinSpace, nil
+	case "sprint":
+		return JoinSprint, nil
+	}
+	return nil, errors.New("unknown MessageJoin: " + name)
+}
+
+// SetExpandErrors moves non-nil errors passed to Print-style calls out of
+// the message into error fields, with a stack field for the call, for every
+// logger sharing l's root.
+func (l *Logger) SetExpandErrors(on bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.ExpandErrors = on
+	r.lock.Unlock()
+}
+
+// message joins the operands of a Print-style call, and returns the error
+// fields taken out of them with ExpandErrors.
+func (l *Logger) message(v []interface{}) (string, []Field) {
+	r := l.root()
+	r.lock.Lock()
+	j := r.joiner
+	expand := r.ExpandErrors
+	r.lock.Unlock()
+
+	v = lazyOperands(v)
+	var fields []Field
+	if expand {
+		v, fields = expandErrors(v)
+	}
+	v = operands(v)
+	if j == nil {
+		return sprintln(v), fields
+	}
+	return j(v), fields
+}
+
+// expandErrors splits errors off v as error, error2, ... fields followed by
+// a stack field.
+func expandErrors(v []interface{}) ([]interface{}, []Field) {
+	var rest []interface{}
+	var fields []Field
+	for i, a := range v {
+		err, ok := a.(error)
+		if !ok || isNil(err) {
+			if fields != nil {
+				rest = append(rest, a)
+			}
+			continue
+		}
+		if fields == nil {
+			rest = append(rest, v[:i]...)
+		}
+		key := "error"
+		if len(fields) > 0 {
+			key += strconv.Itoa(len(fields) + 1)
+		}
+		fields = append(fields, Field{key, err})
+	}
+	if fields == nil {
+		return v, nil
+	}
+	return rest, append(fields, Field{"stack", string(debug.Stack())})
+}
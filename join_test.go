@@ -0,0 +1,45 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJoiners(t *testing.T) {
+	v := []interface{}{"count", 1, 2, "done"}
+	for _, tt := range []struct {
+		name string
+		j    Joiner
+		want string
+	}{
+		{"legacy", JoinLegacy, "count 1 2 done "},
+		{"space", JoinSpace, "count 1 2 done"},
+		{"sprint", JoinSprint, "count1 2done"},
+		{"comma", JoinWith(", "), "count, 1, 2, done"},
+	} {
+		if got := tt.j(v); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := JoinSpace(nil); got != "" {
+		t.Errorf("no operands: %q", got)
+	}
+}
+
+func TestSetJoiner(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.Named("child").SetJoiner(JoinSpace)
+	l.Info("a", 1)
+	l.SetJoiner(nil)
+	l.Info("a", 1)
+
+	if want := "[info] a 1\n[info] a 1 \n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	for name, ok := range map[string]bool{"": true, "Legacy": true, "space": true, "sprint": true, "tabs": false} {
+		if _, err := joinerByName(name); (err == nil) != ok {
+			t.Errorf("MessageJoin %q: err = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// reserved JSON keys; fields using one of them are written as "fields.<key>"
+var jsonKeys = map[string]bool{
+	"timestamp": true,
+	"level":     true,
+	"caller":    true,
+	"logger":    true,
+	"message":   true,
+	"replayed":  true,
+	"seq":       true,
+}
+
+// structuredMessage drops the space sprintln ends messages with for the
+// text layout, e.g. "done " for Info("done").
+func structuredMessage(msg string) string {
+	return strings.TrimSuffix(msg, " ")
+}
+
+// jsonEncoder renders entries as one JSON object per line: timestamp,
+// level, caller, logger, message, replayed and seq, followed by the fields.
+type jsonEncoder struct {
+	// shortFile writes the caller as file:line without the directory
+	shortFile bool
+}
+
+func encodeJSON(buf []byte, e *Entry) []byte {
+	enc := jsonEncoder{}
+	return enc.encode(buf, e)
+}
+
+func (enc *jsonEncoder) encode(buf []byte, e *Entry) []byte {
+	buf = append(buf, `{"timestamp":"`...)
+	buf = e.Time.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, `","level":"`...)
+	buf = append(buf, LogTypeToString(e.Level)...)
+	buf = append(buf, '"')
+	if e.File != "" {
+		file := e.File
+		if enc.shortFile {
+			file = shortFileName(file)
+		}
+		buf = append(buf, `,"caller":`...)
+		// file:line without building the string
+		buf = appendJSONString(buf, file)
+		buf = append(buf[:len(buf)-1], ':')
+		buf = strconv.AppendInt(buf, int64(e.Line), 10)
+		buf = append(buf, '"')
+	}
+	if e.Name != "" {
+		buf = append(buf, `,"logger":`...)
+		buf = appendJSONString(buf, e.Name)
+	}
+	buf = append(buf, `,"message":`...)
+	buf = appendJSONString(buf, structuredMessage(e.Message))
+	if e.Replayed {
+		buf = append(buf, `,"replayed":true`...)
+	}
+	if e.Seq != 0 {
+		buf = append(buf, `,"seq":`...)
+		buf = strconv.AppendUint(buf, e.Seq, 10)
+	}
+	for _, f := range e.Fields {
+		key := f.Key
+		if jsonKeys[key] {
+			key = "fields." + key
+		}
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, key)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, f.Value)
+	}
+	buf = append(buf, "}\n"...)
+
+	return buf
+}
+
+// indentJSON re-indents one encoded entry in place of b.
+func indentJSON(b []byte, indent string) []byte {
+	var out bytes.Buffer
+	err := json.Indent(&out, bytes.TrimSuffix(b, []byte("\n")), "", indent)
+	if err != nil {
+		return b
+	}
+	out.WriteByte('\n')
+	return append(b[:0], out.Bytes()...)
+}
+
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	if isNil(v) {
+		return append(buf, "null"...)
+	}
+	switch v := v.(type) {
+	case string:
+		return appendJSONString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(v), 32)
+	case float64:
+		return appendJSONFloat(buf, v, 64)
+	case json.Number:
+		return append(buf, v...)
+	case time.Duration:
+		return appendJSONString(buf, v.String())
+	case time.Time:
+		return appendJSONString(buf, v.Format(time.RFC3339Nano))
+	case error:
+		return appendJSONString(buf, errorString(v))
+	case json.Marshaler:
+		b, err := v.MarshalJSON()
+		if err != nil {
+			return appendJSONString(buf, err.Error())
+		}
+		return append(buf, b...)
+	case fmt.Stringer:
+		return appendJSONString(buf, v.String())
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprint(v))
+	}
+	return append(buf, b...)
+}
+
+func appendJSONFloat(buf []byte, f float64, bits int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bits)
+}
+
+const hexDigits = "0123456789abcdef"
+
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, `�`...)
+			} else {
+				buf = append(buf, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+		switch c {
+		case '"':
+			buf = append(buf, `\"`...)
+		case '\\':
+			buf = append(buf, `\\`...)
+		case '\n':
+			buf = append(buf, `\n`...)
+		case '\r':
+			buf = append(buf, `\r`...)
+		case '\t':
+			buf = append(buf, `\t`...)
+		default:
+			if c < 0x20 {
+				buf = append(buf, `\u00`...)
+				buf = append(buf, hexDigits[c>>4], hexDigits[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+		i++
+	}
+	return append(buf, '"')
+}
@@ -0,0 +1,31 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStructuredMessageTrimmed(t *testing.T) {
+	for _, tt := range []struct {
+		format, want string
+	}{
+		{FORMAT_JSON, `"message":"done",`},
+		{FORMAT_LOGFMT, ` msg=done `},
+	} {
+		var out bytes.Buffer
+		l := NewLogger(&out, "", 0)
+		l.SetFormat(tt.format)
+		l.With("k", 1).Info("done")
+		if !strings.Contains(out.String(), tt.want) {
+			t.Errorf("%s: %q does not contain %q", tt.format, out.String(), tt.want)
+		}
+	}
+
+	// the text layout keeps it
+	var out bytes.Buffer
+	NewLogger(&out, "", 0).Info("done")
+	if got := out.String(); got != "[info] done \n" {
+		t.Errorf("text = %q, want %q", got, "[info] done \n")
+	}
+}
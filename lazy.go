@@ -0,0 +1,82 @@
+package log
+
+import (
+	"fmt"
+)
+
+// Lazy is an operand or field value computed only for entries of enabled
+// levels, e.g. l.Debug("state:", log.Lazy(dumpState)). Plain
+// func() interface{} values are treated the same way. fmt.Stringer values
+// are already only formatted for entries that are written.
+type Lazy func() interface{}
+
+// IsLevelEnabled reports whether entries of level t are written, to skip
+// building expensive arguments otherwise. With SetVerbosityFlags it also
+// reports true if a flag may let them through.
+func (l *Logger) IsLevelEnabled(t LogType) bool {
+	return l.enabled(t)
+}
+
+// DebugEnabled reports whether debug entries are written.
+func (l *Logger) DebugEnabled() bool {
+	return l.enabled(LOG_DEBUG)
+}
+
+func lazyFunc(v interface{}) (Lazy, bool) {
+	switch fn := v.(type) {
+	case Lazy:
+		return fn, true
+	case func() interface{}:
+		return fn, true
+	}
+	return nil, false
+}
+
+// evalLazy calls fn, reporting a panic the way fmt does.
+func evalLazy(fn Lazy) (v interface{}) {
+	if fn == nil {
+		return nil
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			v = fmt.Sprintf("<PANIC=Lazy: %v>", p)
+		}
+	}()
+	return fn()
+}
+
+// lazyOperands returns v with its lazy operands evaluated.
+func lazyOperands(v []interface{}) []interface{} {
+	var out []interface{}
+	for i, a := range v {
+		fn, ok := lazyFunc(a)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = append([]interface{}(nil), v...)
+		}
+		out[i] = evalLazy(fn)
+	}
+	if out == nil {
+		return v
+	}
+	return out
+}
+
+// lazyFields evaluates the lazy values among the fields of e.
+func lazyFields(e *Entry) {
+	copied := false
+	for i, f := range e.Fields {
+		fn, ok := lazyFunc(f.Value)
+		if !ok {
+			continue
+		}
+		// the slice may belong to the caller
+		if !copied {
+			e.Fields = append([]Field(nil), e.Fields...)
+			copied = true
+		}
+		e.Fields[i].Value = evalLazy(fn)
+	}
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.SetLevel(LOG_LEVEL_INFO)
+	calls := 0
+	state := Lazy(func() interface{} {
+		calls++
+		return "ready"
+	})
+
+	l.Debug("state:", state)
+	l.LogFields(LOG_DEBUG, "state", F("s", state))
+	if calls != 0 || b.Len() != 0 {
+		t.Errorf("disabled level: %d calls, output %q", calls, b.String())
+	}
+	if l.DebugEnabled() || l.IsLevelEnabled(LOG_DEBUG) || !l.IsLevelEnabled(LOG_INFO) {
+		t.Error("wrong enabled levels")
+	}
+
+	fields := []Field{{"s", state}}
+	l.Info("state:", state, func() interface{} { return 2 })
+	l.Infof("state: %v", state)
+	l.LogFields(LOG_INFO, "state", fields...)
+	l.Info("bad", Lazy(func() interface{} { panic("boom") }))
+	want := "[info] state: ready 2 \n[info] state: ready\n[info] state s=ready\n[info] bad <PANIC=Lazy: boom> \n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	if calls != 3 {
+		t.Errorf("%d calls, want one per written entry", calls)
+	}
+	if _, ok := fields[0].Value.(Lazy); !ok {
+		t.Error("the fields of the caller were evaluated in place")
+	}
+}
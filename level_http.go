@@ -0,0 +1,100 @@
+package log
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Middleware wraps an admin handler, typically to authenticate requests.
+type Middleware func(http.Handler) http.Handler
+
+// LevelHandler serves the level of l: GET returns its name, PUT or POST with
+// a level name in the "level" query parameter or the body changes it. The
+// handler is wrapped by auth in order, the first one outermost, so it can be
+// exposed safely:
+//
+//	http.Handle("/debug/loglevel", log.LevelHandler(l, log.TokenAuth("X-Admin-Token", token)))
+//
+// Without auth the level can only be read: changing it is forbidden.
+func LevelHandler(l *Logger, auth ...Middleware) http.Handler {
+	readOnly := len(auth) == 0
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPost:
+			if readOnly {
+				http.Error(w, "changing the log level needs authentication", http.StatusForbidden)
+				return
+			}
+			name := r.URL.Query().Get("level")
+			if name == "" {
+				body, err := io.ReadAll(io.LimitReader(r.Body, 64))
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				name = strings.TrimSpace(string(body))
+			}
+			if StringToLogType(name) == 0 {
+				http.Error(w, "unknown level "+name, http.StatusBadRequest)
+				return
+			}
+			l.SetLevelByString(name)
+			l.Infof("log level set to %s by %s", name, r.RemoteAddr)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		io.WriteString(w, LogLevelToString(l.Level())+"\n")
+	})
+
+	for i := len(auth) - 1; i >= 0; i-- {
+		h = auth[i](h)
+	}
+	return h
+}
+
+// TokenAuth only lets through requests whose header carries token. For the
+// Authorization header a "Bearer " prefix is accepted.
+func TokenAuth(header, token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := r.Header.Get(header)
+			if strings.EqualFold(header, "Authorization") {
+				got = strings.TrimPrefix(got, "Bearer ")
+			}
+			if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientCertAuth only lets through TLS requests with a client certificate
+// the server verified (tls.Config.ClientAuth set to VerifyClientCertIfGiven
+// or RequireAndVerifyClientCert). verify, if not nil, can further restrict
+// the accepted leaf certificates, e.g. by subject.
+func ClientCertAuth(verify func(cert *x509.Certificate) error) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				http.Error(w, "client certificate required", http.StatusUnauthorized)
+				return
+			}
+			if verify != nil {
+				err := verify(r.TLS.VerifiedChains[0][0])
+				if err != nil {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveLevel(h http.Handler, method, target, token string) int {
+	r := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		r.Header.Set("X-Admin-Token", token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestLevelHandlerWithoutAuth(t *testing.T) {
+	l := NewLogger(&bytes.Buffer{}, "", 0)
+	l.SetLevel(LOG_LEVEL_INFO)
+	h := LevelHandler(l)
+
+	if code := serveLevel(h, http.MethodGet, "/", ""); code != http.StatusOK {
+		t.Errorf("GET: %d, want %d", code, http.StatusOK)
+	}
+	if code := serveLevel(h, http.MethodPut, "/?level=debug", ""); code != http.StatusForbidden {
+		t.Errorf("PUT: %d, want %d", code, http.StatusForbidden)
+	}
+	if l.Level() != LOG_LEVEL_INFO {
+		t.Errorf("level changed to %v without auth", l.Level())
+	}
+}
+
+func TestLevelHandlerTokenAuth(t *testing.T) {
+	l := NewLogger(&bytes.Buffer{}, "", 0)
+	l.SetLevel(LOG_LEVEL_INFO)
+	h := LevelHandler(l, TokenAuth("X-Admin-Token", "secret"))
+
+	if code := serveLevel(h, http.MethodPut, "/?level=debug", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := serveLevel(h, http.MethodPut, "/?level=debug", "secret"); code != http.StatusOK {
+		t.Errorf("PUT: %d, want %d", code, http.StatusOK)
+	}
+	if l.Level() != LOG_LEVEL_DEBUG {
+		t.Errorf("level %v, want debug", l.Level())
+	}
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"log"
+	"sync"
+	"time"
+)
+
+// longer lines are written in pieces of this size
+const LEVEL_WRITER_MAX_LINE = 64 << 10
+
+// LevelWriter is an io.Writer writing every line written to it as an entry
+// of its level, for APIs taking an io.Writer, such as the Stdout and Stderr
+// of an exec.Cmd. It holds back an incomplete last line until it is
+// completed or Close is called.
+type LevelWriter struct {
+	l     *Logger
+	level LogType
+
+	buf  []byte
+	lock sync.Mutex
+}
+
+// Writer returns a LevelWriter writing lines as entries of level t:
+//
+//	cmd.Stderr = l.Writer(log.LOG_WARNING)
+func (l *Logger) Writer(t LogType) *LevelWriter {
+	return &LevelWriter{l: l, level: t}
+}
+
+// StdLogger returns a standard library logger writing every message as an
+// entry of level t through l, e.g. for http.Server.ErrorLog.
+func (l *Logger) StdLogger(t LogType) *log.Logger {
+	return log.New(l.Writer(t), "", 0)
+}
+
+func (w *LevelWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.buf = append(w.buf, p...)
+			for len(w.buf) >= LEVEL_WRITER_MAX_LINE {
+				w.line(w.buf[:LEVEL_WRITER_MAX_LINE])
+				w.buf = append(w.buf[:0], w.buf[LEVEL_WRITER_MAX_LINE:]...)
+			}
+			break
+		}
+		if len(w.buf) > 0 {
+			w.buf = append(w.buf, p[:i]...)
+			w.line(w.buf)
+			w.buf = w.buf[:0]
+		} else {
+			w.line(p[:i])
+		}
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Close writes the incomplete last line, if any.
+func (w *LevelWriter) Close() error {
+	w.lock.Lock()
+	if len(w.buf) > 0 {
+		w.line(w.buf)
+		w.buf = w.buf[:0]
+	}
+	w.lock.Unlock()
+	return nil
+}
+
+func (w *LevelWriter) line(b []byte) {
+	b = bytes.TrimSuffix(b, []byte("\r"))
+	if len(b) == 0 || !w.l.enabled(w.level) {
+		return
+	}
+	// the code calling Write is not where the line came from
+	w.l.output(2, &Entry{Level: w.level, Time: time.Now(), Message: string(b), File: "???"})
+}
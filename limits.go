@@ -0,0 +1,95 @@
+package log
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// appended to values cut by SetFieldLimit, with the number of bytes cut
+const TRUNCATED_MARKER = "...[truncated %d bytes]"
+
+// SetFieldLimit cuts values of the field key longer than max bytes, e.g.
+// "sql" at 2 KB or "stack" at 8 KB, for every logger sharing l's root. The
+// cut is marked with TRUNCATED_MARKER. Strings, byte slices, errors and
+// Stringers are limited, other values are left alone. max <= 0 removes the
+// limit.
+func (l *Logger) SetFieldLimit(key string, max int) {
+	r := l.root()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	limits := make(map[string]int, len(r.fieldLimits)+1)
+	for k, v := range r.fieldLimits {
+		limits[k] = v
+	}
+	if max > 0 {
+		limits[key] = max
+	} else {
+		delete(limits, key)
+	}
+	r.fieldLimits = limits
+	r.FieldLimits = limits
+}
+
+// limitFields applies limits to the fields of e, after the hooks ran.
+func limitFields(limits map[string]int, e *Entry) {
+	if len(limits) == 0 {
+		return
+	}
+	copied := false
+	for i, f := range e.Fields {
+		max, ok := limits[f.Key]
+		if !ok {
+			continue
+		}
+		var s string
+		switch v := f.Value.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case error:
+			if isNil(v) {
+				continue
+			}
+			s = errorString(v)
+		case fmt.Stringer:
+			if isNil(v) {
+				continue
+			}
+			s = v.String()
+		default:
+			continue
+		}
+		if len(s) <= max {
+			continue
+		}
+		// the slice may belong to the caller
+		if !copied {
+			e.Fields = append([]Field(nil), e.Fields...)
+			copied = true
+		}
+		e.Fields[i].Value = truncate(s, max)
+	}
+}
+
+// truncate cuts s to at most max bytes without splitting a rune.
+func truncate(s string, max int) string {
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf(TRUNCATED_MARKER, len(s)-cut)
+}
+
+// fieldLimits checks the FieldLimits config.
+func fieldLimits(m map[string]int) (map[string]int, error) {
+	limits := make(map[string]int, len(m))
+	for k, v := range m {
+		if v <= 0 {
+			return nil, fmt.Errorf("FieldLimits: limit of %s must be positive, got %d", k, v)
+		}
+		limits[k] = v
+	}
+	return limits, nil
+}
@@ -2,13 +2,18 @@
 package log
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime/debug"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -48,29 +53,275 @@ const FORMAT_TIME_DAY string = "20060102"
 
 const FORMAT_TIME_HOUR string = "2006010215"
 
+const (
+	FORMAT_TEXT   = "text"
+	FORMAT_JSON   = "json"
+	FORMAT_LOGFMT = "logfmt"
+)
+
 type Logger struct {
 	_log  *log.Logger
-	level LogLevel
+	level int32 // LogLevel, accessed atomically
+
+	// TimeFormat names rotated files, EntryTimeFormat (if set) replaces the
+	// Ldate/Ltime/Lmicroseconds timestamp of each entry
+	TimeFormat      string
+	EntryTimeFormat string
+	SuffixName      string
+	FileName        string
+	rw              *RotatingWriter
+	rotatePolicy    RotatePolicy
+	rotateHooks     []func(oldPath, newPath string)
+
+	// RotateEvery rotates at multiples of this duration since the Unix
+	// epoch ("6h"), in addition to TimeFormat; see SetRotateEvery
+	RotateEvery string
+	rotateEvery time.Duration
+	// MaxSize rotates the file once it reaches this size, in MB
+	MaxSize int
+	// MaxTotalSize caps the size of the current plus rotated files, in MB
+	MaxTotalSize int
+	// MaxBackups is how many rotated files are kept, 0 for all
+	MaxBackups int
+	// MaxAge removes rotated files older than this, as a duration ("168h")
+	// or days ("7d")
+	MaxAge string
+	maxAge time.Duration
+	// Compress names the method rotated files are compressed with: "gzip",
+	// or one added by RegisterCompressor such as "zstd" from logzstd
+	Compress string
+	// Symlink is kept pointing at the active file, e.g. "app.log"
+	Symlink string
+	// NormalizeKeys rewrites field keys: "lower" or "snake"; see
+	// SetNormalizeKeys
+	NormalizeKeys string
+	// FieldLimits cuts values of the given fields at this many bytes; see
+	// SetFieldLimit
+	FieldLimits map[string]int
+	// AsyncQueue writes entries from a background goroutine through a queue
+	// of this many entries; see SetAsync
+	AsyncQueue int
+	// IndexEvery writes a time index entry every IndexEvery entries, for
+	// OpenRange
+	IndexEvery int
+	// NoAppend starts a new indexed file instead of appending to the one
+	// of the current period
+	NoAppend bool
+
+	// UseUTC writes entry times and names rotated files in UTC instead of
+	// local time; see SetUseUTC
+	UseUTC bool
+
+	// DisableCaller skips looking up the file and line of entries; see
+	// EnableCaller
+	DisableCaller bool
+	// FuncName attaches the function of the call site as a func field; see
+	// SetFuncName
+	FuncName bool
+
+	// BuildInfo attaches version and vcs fields to every entry
+	BuildInfo bool
+	// ContainerInfo attaches container and pod identity fields
+	ContainerInfo bool
+	// Severity attaches the numeric syslog severity of the level
+	Severity bool
+	// Development makes failed assertions panic
+	Development bool
+	// runs instead of os.Exit after fatal entries, see TestMode
+	onFatal func()
+	// StackLevel is the least severe level ("error", "warn", ...) whose
+	// entries always carry a stack
+	StackLevel string
+	stackLevel LogType
+
+	// LevelNames replaces level tags in the output, keyed by the default
+	// name, e.g. {"error": "ERR", "warning": "WARN"}
+	LevelNames map[string]string
+	levelNames map[LogType]string
+
+	// Format is the output format: FORMAT_TEXT (default), FORMAT_JSON or
+	// FORMAT_LOGFMT
+	Format    string
+	formatter Formatter
+	// Template renders every line with a text/template instead; see
+	// TemplateFormatter
+	Template string
+
+	// Color colors level tags: COLOR_NEVER (default), COLOR_AUTO or
+	// COLOR_ALWAYS; see SetColor
+	Color string
+	theme Theme
+
+	// MessageJoin selects how Print-style calls join their operands:
+	// "legacy" (default), "space" or "sprint"; see SetJoiner
+	MessageJoin string
+	joiner      Joiner
+	// ExpandErrors turns errors passed to Print-style calls into fields;
+	// see SetExpandErrors
+	ExpandErrors bool
+
+	// Sampling is the kept fraction of entries per level name, sampled
+	// by the SampleKey field if set; see SetSampling
+	Sampling  map[string]float64
+	SampleKey string
+	sampler   *sampler
+	cooldown  *cooldown
+	everyN    *everyN
+	dedup     *dedup
+
+	escalation *escalation
+	rateLimit  *rateLimiter
+	verbosity  atomic.Value // *verbosityFlags
+
+	// DebugFile is watched with WatchDebugFile
+	DebugFile  string
+	debugWatch *debugWatch
+
+	mirrorLevel LogType
+	sinks       []*sinkConfig
+
+	parent *Logger
+	name   string
+	// attached to every entry, see With
+	fields []Field
+
+	errorHandler  atomic.Value // errorHandler
+	callerStats   *callerStats
+	dynamicFields []*dynamicField
+	hooks         []*hook
+	filters       []*hook
+	stages        map[string][]*hook
+	fieldLimits   map[string]int
+	counters      counters
 
-	TimeFormat string
-	SuffixName string
-	FileName   string
-	logSuffix  string
-	fd         *os.File
+	// set by NewWithContext
+	ctx    context.Context
+	cancel context.CancelFunc
+	// set by the first Close of a logger from NewWithContext, accessed atomically
+	ctxClosed int32
 
+	once sync.Once
 	lock sync.Mutex
 }
 
 func (l *Logger) Init(jsonConfig string) error {
+	l.lazyInit()
+
 	err := json.Unmarshal([]byte(jsonConfig), l)
 	if err != nil {
 		return err
 	}
+	err = l.applyProfile([]byte(jsonConfig))
+	if err != nil {
+		return err
+	}
 	if len(l.FileName) == 0 {
 		return errors.New("jsonconfig must have filename")
 	}
-	return l.SetOutputByName(l.FileName)
+	err = validateRotateFormat(l.TimeFormat)
+	if err != nil {
+		return err
+	}
+	err = validateEntryTimeFormat(l.EntryTimeFormat)
+	if err != nil {
+		return err
+	}
+	l.rotateEvery, err = parseRotateEvery(l.RotateEvery)
+	if err != nil {
+		return err
+	}
+	l.maxAge, err = ParseMaxAge(l.MaxAge)
+	if err != nil {
+		return err
+	}
+	err = validateCompress(l.Compress)
+	if err != nil {
+		return err
+	}
+	l.stackLevel = StringToLogType(l.StackLevel)
+	if len(l.LevelNames) > 0 {
+		names := make(map[LogType]string, len(l.LevelNames))
+		for k, v := range l.LevelNames {
+			t := StringToLogType(k)
+			if t == 0 {
+				return errors.New("unknown level in LevelNames: " + k)
+			}
+			names[t] = v
+		}
+		l.levelNames = names
+	}
+	err = l.SetFormat(l.Format)
+	if err != nil {
+		return err
+	}
+	if l.Template != "" {
+		l.formatter, err = NewTemplateFormatter(l.Template)
+		if err != nil {
+			return err
+		}
+	}
+	err = l.SetColor(l.Color)
+	if err != nil {
+		return err
+	}
+	l.joiner, err = joinerByName(l.MessageJoin)
+	if err != nil {
+		return err
+	}
+	err = l.SetNormalizeKeys(l.NormalizeKeys)
+	if err != nil {
+		return err
+	}
+	if l.FieldLimits != nil {
+		l.fieldLimits, err = fieldLimits(l.FieldLimits)
+		if err != nil {
+			return err
+		}
+	}
+	if l.Sampling != nil {
+		rates, err := samplingRates(l.Sampling)
+		if err != nil {
+			return err
+		}
+		l.SetSampling(rates, l.SampleKey)
+	}
+	err = l.SetOutputByName(l.FileName)
+	if err != nil {
+		return err
+	}
+	if l.DebugFile != "" {
+		l.WatchDebugFile(l.DebugFile, 0)
+	}
+	return nil
 }
+
+// MustInit is Init for setups that cannot run without their configured
+// logger: it panics if the configuration is invalid.
+func (l *Logger) MustInit(jsonConfig string) {
+	err := l.Init(jsonConfig)
+	if err != nil {
+		panic("log: " + err.Error())
+	}
+}
+
+// lazyInit gives a zero Logger the defaults of New (stderr, LstdFlags and all
+// levels) the first time it is used, so it works without a constructor.
+func (l *Logger) lazyInit() {
+	l.once.Do(func() {
+		if l._log != nil {
+			return
+		}
+		l._log = log.New(os.Stderr, "", LstdFlags)
+		l.level = int32(LOG_LEVEL_ALL)
+		if l.TimeFormat == "" {
+			l.TimeFormat = FORMAT_TIME_DAY
+		}
+		if l.SuffixName == "" {
+			l.SuffixName = ".log"
+		}
+	})
+}
+
 func (l *Logger) SetLogger(configs ...string) error {
 	config := append(configs, "{}")[0]
 	err := l.Init(config)
@@ -81,123 +332,664 @@ func (l *Logger) SetLogger(configs ...string) error {
 	return nil
 }
 func (l *Logger) SetLevel(level LogLevel) {
-	l.level = level
+	l.lazyInit()
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+func (l *Logger) Level() LogLevel {
+	l.lazyInit()
+	return LogLevel(atomic.LoadInt32(&l.level))
 }
 
 func (l *Logger) SetLevelByString(level string) {
-	l.level = StringToLogLevel(level)
+	l.SetLevel(StringToLogLevel(level))
+}
+
+// IncludeSeverity attaches a severity field with LogTypeToSeverity of the
+// level to every entry.
+func (l *Logger) IncludeSeverity(on bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.Severity = on
+	r.lock.Unlock()
+}
+
+// SetLevelNames replaces the tags written for the given levels, e.g.
+// {LOG_ERROR: "ERR"} to write "[ERR]" instead of "[error]". Levels not in
+// names keep their default tag.
+func (l *Logger) SetLevelNames(names map[LogType]string) {
+	m := make(map[LogType]string, len(names))
+	for k, v := range names {
+		m[k] = v
+	}
+
+	r := l.root()
+	r.lock.Lock()
+	r.levelNames = m
+	r.lock.Unlock()
+}
+
+// SetFormat switches the output between FORMAT_TEXT, FORMAT_JSON (one
+// JSON object per line with timestamp, level, caller and message) and
+// FORMAT_LOGFMT (key=value pairs), for ingestion without a custom parser.
+// "" means FORMAT_TEXT.
+func (l *Logger) SetFormat(format string) error {
+	format = strings.ToLower(format)
+	switch format {
+	case "":
+		format = FORMAT_TEXT
+	case FORMAT_TEXT, FORMAT_JSON, FORMAT_LOGFMT:
+	default:
+		return errors.New("unknown Format: " + format)
+	}
+
+	r := l.root()
+	r.lock.Lock()
+	r.Format = format
+	r.lock.Unlock()
+	return nil
+}
+
+// EnableCaller turns looking up the file and line of every entry on or off
+// at runtime, whatever the Lshortfile and Llongfile flags say; it is on by
+// default. Without it entries have no caller in any format, and cooldowns,
+// escalation, SetEveryN and caller stats keyed by call site see a single
+// one.
+func (l *Logger) EnableCaller(on bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.DisableCaller = !on
+	r.lock.Unlock()
+}
+
+// SetFuncName attaches the package and function of the call site, e.g.
+// "main.(*Server).handle", to every entry as a func field, which is more
+// stable across edits than its line. Entries without caller get none, see
+// EnableCaller.
+func (l *Logger) SetFuncName(on bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.FuncName = on
+	r.lock.Unlock()
+}
+
+// MirrorToStderr additionally writes entries at minLevel or more severe
+// (e.g. LOG_WARNING for warning, error and fatal) to stderr, whatever the
+// configured output is. LOG_FATAL is the most severe level; passing 0
+// turns mirroring off.
+func (l *Logger) MirrorToStderr(minLevel LogType) {
+	r := l.root()
+	r.lock.Lock()
+	r.mirrorLevel = minLevel
+	r.lock.Unlock()
 }
 
 func (l *Logger) SetRotateByTimeFormat(format string) {
 	l.TimeFormat = format
-	l.logSuffix = time.Now().Format(l.TimeFormat)
+	if l.rw != nil {
+		l.rw.SetTimeFormat(format)
+	}
 }
-func (l *Logger) rotate() error {
-	l.lock.Lock()
-	defer l.lock.Unlock()
 
-	var suffix string
-	//异常处理
-	suffix = time.Now().Format(l.TimeFormat)
+// SetRotateEvery rotates at multiples of d since the Unix epoch, e.g. every
+// 6 hours at 00:00, 06:00, ... UTC, so several instances rotate together.
+// Files are named by the start of their period with TimeFormat, which should
+// be fine enough to tell periods apart (FORMAT_TIME_HOUR). 0 turns it off.
+func (l *Logger) SetRotateEvery(d time.Duration) {
+	r := l.root()
+	r.lock.Lock()
+	r.rotateEvery = d
+	r.RotateEvery = ""
+	if d > 0 {
+		r.RotateEvery = d.String()
+	}
+	rw := r.rw
+	r.lock.Unlock()
+	if rw != nil {
+		rw.SetEvery(d)
+	}
+}
 
-	// Notice: if suffix is not equal to l.LogSuffix, then rotate
-	if suffix != l.logSuffix {
-		err := l.doRotate(suffix)
-		if err != nil {
-			return err
-		}
+// SetUseUTC makes entry times, in every format and for hooks and sinks, and
+// the time suffixes of rotated files UTC, so they agree with each other and
+// with tools working in UTC. Retention by MaxAge is unaffected: it compares
+// file ages. A changed suffix rotates at the next write.
+func (l *Logger) SetUseUTC(on bool) {
+	r := l.root()
+	r.lock.Lock()
+	r.UseUTC = on
+	rw := r.rw
+	r.lock.Unlock()
+	if rw != nil {
+		rw.SetUTC(on)
+	}
+}
+
+// SetRotateBySize rotates the file once it reaches mb megabytes, in
+// addition to the TimeFormat rotation; 0 turns it off.
+func (l *Logger) SetRotateBySize(mb int) {
+	r := l.root()
+	r.lock.Lock()
+	r.MaxSize = mb
+	rw := r.rw
+	r.lock.Unlock()
+	if rw != nil {
+		rw.SetMaxBytes(int64(mb) << 20)
 	}
+}
 
-	return nil
+// SetMaxBackups keeps only the n most recent rotated files; 0 keeps them
+// all.
+func (l *Logger) SetMaxBackups(n int) {
+	r := l.root()
+	r.lock.Lock()
+	r.MaxBackups = n
+	rw := r.rw
+	r.lock.Unlock()
+	if rw != nil {
+		rw.SetMaxBackups(n)
+	}
 }
 
-func (l *Logger) doRotate(suffix string) error {
-	// Notice: Not check error, is this ok?
-	l.fd.Close()
+// SetMaxAge removes rotated files older than d at each rotation; 0 keeps
+// them regardless of age.
+func (l *Logger) SetMaxAge(d time.Duration) {
+	r := l.root()
+	r.lock.Lock()
+	r.maxAge = d
+	r.MaxAge = d.String()
+	if d == 0 {
+		r.MaxAge = ""
+	}
+	rw := r.rw
+	r.lock.Unlock()
+	if rw != nil {
+		rw.SetMaxAge(d)
+	}
+}
 
-	//lastFileName := l.fileName + "." + l.logSuffix + l.SuffixName
-	/*err := os.Rename(l.fileName, lastFileName)
+// SetCompress sets how rotated files are compressed: COMPRESS_GZIP, a
+// method added by RegisterCompressor or COMPRESS_NONE. It takes effect at the next rotation.
+func (l *Logger) SetCompress(method string) error {
+	err := validateCompress(method)
 	if err != nil {
 		return err
-	}*/
+	}
+	r := l.root()
+	r.lock.Lock()
+	r.Compress = method
+	rw := r.rw
+	r.lock.Unlock()
+	if rw != nil {
+		rw.SetCompress(method)
+	}
+	return nil
+}
+
+// RegisterRotateHook runs fn after every rotation of the output file, with
+// the path of the file just closed and of the new one, e.g. to upload
+// sealed files. See RotatingWriter.RegisterRotateHook.
+func (l *Logger) RegisterRotateHook(fn func(oldPath, newPath string)) {
+	r := l.root()
+	r.lock.Lock()
+	r.rotateHooks = append(r.rotateHooks[:len(r.rotateHooks):len(r.rotateHooks)], fn)
+	rw := r.rw
+	r.lock.Unlock()
+	if rw != nil {
+		rw.RegisterRotateHook(fn)
+	}
+}
+
+// SetRotatePolicy replaces the TimeFormat and MaxSize triggers with p,
+// e.g. RotateAny(RotateByTime(), RotateByAge(6*time.Hour)). nil restores
+// them.
+func (l *Logger) SetRotatePolicy(p RotatePolicy) {
+	r := l.root()
+	r.lock.Lock()
+	r.rotatePolicy = p
+	rw := r.rw
+	r.lock.Unlock()
+	if rw != nil {
+		rw.SetPolicy(p)
+	}
+}
 
-	err := l.SetOutputByName(l.FileName)
+// SetEntryTimeFormat sets the layout of the timestamp written in front of
+// each entry, independently of the rotation TimeFormat. An empty format goes
+// back to the Ldate/Ltime/Lmicroseconds flags.
+func (l *Logger) SetEntryTimeFormat(format string) error {
+	err := validateEntryTimeFormat(format)
 	if err != nil {
 		return err
 	}
 
-	l.logSuffix = suffix
+	r := l.root()
+	r.lock.Lock()
+	r.EntryTimeFormat = format
+	r.lock.Unlock()
 
 	return nil
 }
 
 func (l *Logger) SetOutput(out io.Writer) {
+	l.lazyInit()
 	l._log = log.New(out, l._log.Prefix(), l._log.Flags())
+	if a, ok := out.(*AsyncWriter); ok {
+		out = a.W
+	}
+	l.rw, _ = out.(*RotatingWriter)
+
+	l.lock.Lock()
+	l.theme = ColorTheme(out, l.Color)
+	l.lock.Unlock()
 }
 
 func (l *Logger) SetOutputByName(path string) error {
-	f, err := os.OpenFile(path+"."+time.Now().Format(l.TimeFormat)+l.SuffixName, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
+	err := l.openFile(path)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	l.SetOutput(f)
+	return err
+}
+
+func (l *Logger) openFile(path string) error {
+	w := l.newRotatingWriter(path)
+	err := w.Open()
+	if err != nil {
+		return err
+	}
+
+	old, prev := l.rw, l._log.Writer()
+	if l.AsyncQueue > 0 {
+		l.SetOutput(l.newAsyncWriter(w))
+	} else {
+		l.SetOutput(w)
+	}
 
 	l.FileName = path
-	l.fd = f
+	if a, ok := prev.(*AsyncWriter); ok {
+		// write what was queued for the old file first
+		a.stop()
+	}
+	if old != nil {
+		old.Close()
+	} else {
+		maintenance.register(l)
+	}
 
-	return err
+	return nil
+}
+
+func (l *Logger) newRotatingWriter(path string) *RotatingWriter {
+	return &RotatingWriter{
+		FileName:      path,
+		TimeFormat:    l.TimeFormat,
+		SuffixName:    l.SuffixName,
+		Policy:        l.rotatePolicy,
+		Every:         l.rotateEvery,
+		UTC:           l.UseUTC,
+		MaxBytes:      int64(l.MaxSize) << 20,
+		MaxTotalBytes: int64(l.MaxTotalSize) << 20,
+		MaxBackups:    l.MaxBackups,
+		MaxAge:        l.maxAge,
+		Compress:      l.Compress,
+		IndexEvery:    l.IndexEvery,
+		Symlink:       l.Symlink,
+		hooks:         l.rotateHooks,
+		NoAppend:      l.NoAppend,
+	}
+}
+
+// Close closes the file opened by SetOutputByName, or the RotatingWriter
+// given to SetOutput, and the sinks that are io.Closers. Later entries fail
+// to write. On a child logger Close only stops its WatchDebugFile; the
+// outputs belong to the root.
+func (l *Logger) Close() error {
+	if l.cancel != nil {
+		// the end of ctx and the caller may both close the logger
+		if !atomic.CompareAndSwapInt32(&l.ctxClosed, 0, 1) {
+			return nil
+		}
+		l.cancel()
+	}
+	l.lock.Lock()
+	if l.debugWatch != nil {
+		l.debugWatch.cancel()
+		l.debugWatch = nil
+	}
+	l.lock.Unlock()
+	if l.parent != nil {
+		return nil
+	}
+	maintenance.unregister(l)
+	l.EnableCallerStats(0, 0)
+
+	errs := l.closeSinks()
+
+	l.lock.Lock()
+	rw := l.rw
+	l.lock.Unlock()
+
+	if a, ok := l._log.Writer().(*AsyncWriter); ok {
+		a.stop()
+	}
+	if rw != nil {
+		name := rw.Name()
+		if err := rw.Close(); err != nil {
+			errs = append(errs, &CloseError{"output " + name, err})
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (l *Logger) log(t LogType, v ...interface{}) {
-	if l.level|LogLevel(t) != l.level {
+	if !l.enabled(t) {
 		return
 	}
 
-	err := l.rotate()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+	msg, fields := l.message(v)
+	l.output(3, &Entry{Level: t, Time: time.Now(), Message: msg, Fields: fields})
+}
+
+func (l *Logger) logf(t LogType, format string, v ...interface{}) {
+	if !l.enabled(t) {
 		return
 	}
 
-	v1 := make([]interface{}, len(v)+2)
-	logStr := LogTypeToString(t)
+	l.output(3, &Entry{Level: t, Time: time.Now(), Message: sprintf(format, v)})
+}
 
-	v1[0] = "[" + logStr + "]"
-	copy(v1[1:], v)
-	v1[len(v)+1] = ""
+// LogAt writes an entry with the caller-supplied timestamp ts instead of
+// the current time and marks it as replayed.
+func (l *Logger) LogAt(t LogType, ts time.Time, v ...interface{}) {
+	if !l.enabled(t) {
+		return
+	}
 
-	s := fmt.Sprintln(v1...)
-	l._log.Output(4, s)
+	msg, fields := l.message(v)
+	l.output(2, &Entry{Level: t, Time: ts, Message: msg, Fields: fields, Replayed: true})
 }
 
-func (l *Logger) logf(t LogType, format string, v ...interface{}) {
-	if l.level|LogLevel(t) != l.level {
+func (l *Logger) LogAtf(t LogType, ts time.Time, format string, v ...interface{}) {
+	if !l.enabled(t) {
 		return
 	}
 
-	err := l.rotate()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+	l.output(2, &Entry{Level: t, Time: ts, Message: sprintf(format, v), Replayed: true})
+}
+
+// LogFields writes msg with fields at level t, for callers building fields
+// themselves.
+func (l *Logger) LogFields(t LogType, msg string, fields ...Field) {
+	l.logFields(t, msg, fields)
+}
+
+// LogFieldsSkip is LogFields for helpers such as the functions generated by
+// loggen: the caller reported is skip frames above the one calling
+// LogFieldsSkip, as with runtime.Caller.
+func (l *Logger) LogFieldsSkip(skip int, t LogType, msg string, fields ...Field) {
+	if !l.enabled(t) {
+		return
+	}
+
+	l.output(skip+2, &Entry{Level: t, Time: time.Now(), Message: msg, Fields: fields})
+}
+
+func (l *Logger) logFields(t LogType, msg string, fields []Field) {
+	if !l.enabled(t) {
+		return
+	}
+
+	l.output(3, &Entry{Level: t, Time: time.Now(), Message: msg, Fields: fields})
+}
+
+func (l *Logger) enabled(t LogType) bool {
+	l.lazyInit()
+	if l.levelEnabled(t) {
+		return true
+	}
+	// decided in output, with the fields of the entry
+	v := l.verbosityFlags()
+	return v != nil && v.covers(l.name, t)
+}
+
+func (l *Logger) levelEnabled(t LogType) bool {
+	level := LogLevel(atomic.LoadInt32(&l.level))
+	return level|LogLevel(t) == level
+}
+
+// output writes e. calldepth is the number of frames between output and the
+// call site the entry reports, as with runtime.Caller: 3 for Info, which
+// calls log, which calls output.
+func (l *Logger) output(calldepth int, e *Entry) {
+	if e.Name == "" {
+		e.Name = l.name
+	}
+	if len(l.fields) > 0 {
+		e.Fields = append(l.fields[:len(l.fields):len(l.fields)], e.Fields...)
+	}
+	if v := l.verbosityFlags(); v != nil && !l.levelEnabled(e.Level) && !v.enabled(l.name, e) {
+		return
+	}
+	// children write through the outputs of their root logger
+	r := l.root()
+	r.lock.Lock()
+	stages := r.stages
+	utc := r.UseUTC
+	cs := r.callerStats
+	dynamic := r.dynamicFields
+	hooks := r.hooks
+	filters := r.filters
+	format := r.Format
+	cd := r.cooldown
+	every := r.everyN
+	dd := r.dedup
+	formatter := r.formatter
+	esc := r.escalation
+	limits := r.fieldLimits
+	normalize := r.NormalizeKeys
+	noCaller := r.DisableCaller
+	funcName := r.FuncName && !noCaller
+	r.lock.Unlock()
+	if utc {
+		e.Time = e.Time.UTC()
+	}
+	if format == "" {
+		format = FORMAT_TEXT
+	}
+
+	flags := r._log.Flags()
+	if noCaller {
+		flags &^= Lshortfile | Llongfile
+	}
+	// JSON, logfmt and formatters always get the caller
+	needCaller := !noCaller && (flags&(Lshortfile|Llongfile) != 0 || format != FORMAT_TEXT || formatter != nil || cs != nil || cd != nil || every != nil || esc != nil || funcName)
+	if needCaller && e.File == "" {
+		var ok bool
+		if e.pc == 0 {
+			e.pc = callerPC(calldepth)
+		}
+		e.File, e.Line, ok = pcCaller(e.pc)
+		if !ok {
+			e.File = "???"
+			e.Line = 0
+		}
+	}
+	if !runHooks(stages[STAGE_START], e) {
+		return
+	}
+
+	lazyFields(e)
+	if funcName {
+		if fn := pcFunc(e.pc); fn != "" {
+			e.Fields = append(e.Fields, Field{"func", fn})
+		}
+	}
+	if r.Severity {
+		e.Fields = append(e.Fields, Field{"severity", LogTypeToSeverity(e.Level)})
+	}
+	if r.BuildInfo {
+		e.Fields = append(e.Fields, buildInfoFields()...)
+	}
+	if r.ContainerInfo {
+		e.Fields = append(e.Fields, containerInfoFields()...)
+	}
+	for _, d := range dynamic {
+		e.Fields = append(e.Fields, d.field(time.Now()))
+	}
+	if r.needStack(e) {
+		e.Fields = append(e.Fields, Field{"stack", string(debug.Stack())})
+	}
+	if !runHooks(stages[STAGE_FIELDS], e) {
+		return
+	}
+	if !runHooks(hooks, e) || !runHooks(stages[STAGE_HOOKS], e) {
+		return
+	}
+	if !runHooks(filters, e) || !runHooks(stages[STAGE_FILTERS], e) {
+		return
+	}
+	normalizeFields(normalize, e)
+	limitFields(limits, e)
+	if !runHooks(stages[STAGE_REDACTION], e) {
 		return
 	}
 
-	logStr := LogTypeToString(t)
-	var s string
+	if r.sampled(e) || l.rateLimited(e) || !runHooks(stages[STAGE_SAMPLING], e) {
+		return
+	}
+	// warnings held back by cooldowns still count towards escalation
+	if esc != nil {
+		if summary := esc.observe(e); summary != nil {
+			// run by this frame, one more below the call site
+			defer r.output(calldepth+1, summary)
+		}
+	}
+	if r.cooledDown(cd, e) || r.repeated(every, e) || r.duplicate(dd, e, calldepth) || !runHooks(stages[STAGE_COOLDOWN], e) {
+		return
+	}
+	buf := getBuffer()
+	buf.b = r.encode(buf.b, e, format, formatter, flags)
+	// changes made here only reach the sinks
+	if !runHooks(stages[STAGE_ENCODE], e) {
+		buf.Free()
+		return
+	}
+	if cs != nil {
+		cs.add(e)
+	}
+	r.counters.entry(e.Level)
+
+	r.lock.Lock()
+	if r.mirrorLevel != 0 && e.Level <= r.mirrorLevel && r._log.Writer() != io.Writer(os.Stderr) {
+		os.Stderr.Write(buf.b)
+	}
+	var err error
+	if bw, ok := r._log.Writer().(BufferWriter); ok {
+		// a full queue blocks until its worker catches up, which may need
+		// the lock to report an error
+		r.lock.Unlock()
+		err = r.writeBuffer(bw, buf)
+		r.lock.Lock()
+	} else {
+		err = r.write(buf)
+	}
+	var name string
+	if err != nil {
+		name = r.outputName()
+	}
+	r.lock.Unlock()
+
+	if err != nil {
+		atomic.AddInt64(&r.counters.writeErrors, 1)
+		if err != ErrQuotaExceeded && err != ErrMemoryLimit {
+			r.handleError(&WriteError{"output " + name, err})
+		}
+	}
 
-	s = "[" + logStr + "] " + fmt.Sprintf(format, v...)
+	r.writeSinks(e)
+	runHooks(stages[STAGE_SINKS], e)
+}
+
+// encode renders e for the output of l.
+func (l *Logger) encode(buf []byte, e *Entry, format string, formatter Formatter, flags int) []byte {
+	if formatter != nil {
+		b, ok := encodeFormatter(buf, formatter, flags, e)
+		if ok {
+			return b
+		}
+	}
+
+	switch format {
+	case FORMAT_JSON:
+		enc := jsonEncoder{shortFile: flags&Llongfile == 0}
+		return enc.encode(buf, e)
+	case FORMAT_LOGFMT:
+		enc := logfmtEncoder{shortFile: flags&Llongfile == 0}
+		return enc.encode(buf, e)
+	}
+	enc := textEncoder{
+		prefix:     l._log.Prefix(),
+		flags:      flags,
+		timeFormat: l.EntryTimeFormat,
+		levelNames: l.levelNames,
+		theme:      l.theme,
+	}
+	return enc.encode(buf, e)
+}
+
+// write copies buf to an output that is no BufferWriter and frees it.
+func (l *Logger) write(buf *Buffer) error {
+	_, err := buf.WriteTo(l._log.Writer())
+	buf.Free()
+	return err
+}
+
+// writeBuffer hands buf to bw, within the memory limit.
+func (l *Logger) writeBuffer(bw BufferWriter, buf *Buffer) error {
+	size := int64(cap(buf.b))
+	if !memory.reserve(size) {
+		buf.Free()
+		memory.drop()
+		return ErrMemoryLimit
+	}
+	buf.accounted = size
+	return bw.WriteBuffer(buf)
+}
+
+// sprintln keeps the historical layout of fmt.Sprintln("[level]", v..., ""),
+// minus the level tag and the trailing newline. It is built in a pooled
+// buffer, so the message string is its only allocation.
+func sprintln(v []interface{}) string {
+	buf := getBuffer()
+	for _, a := range v {
+		buf.b = appendOperand(buf.b, a)
+		buf.b = append(buf.b, ' ')
+	}
+	s := string(buf.b)
+	buf.Free()
+	return s
+}
 
-	l._log.Output(4, s)
+func sprintf(format string, v []interface{}) string {
+	buf := getBuffer()
+	buf.b = fmt.Appendf(buf.b, format, lazyOperands(v)...)
+	s := string(bytes.TrimSuffix(buf.b, []byte("\n")))
+	buf.Free()
+	return s
 }
 
 func (l *Logger) Fatal(v ...interface{}) {
 	l.log(LOG_FATAL, v...)
-	os.Exit(-1)
+	l.drainAsync()
+	l.exit()
 }
 
 func (l *Logger) Fatalf(format string, v ...interface{}) {
 	l.logf(LOG_FATAL, format, v...)
-	os.Exit(-1)
+	l.drainAsync()
+	l.exit()
 }
 
 func (l *Logger) Error(v ...interface{}) {
@@ -250,6 +1042,60 @@ func StringToLogLevel(level string) LogLevel {
 	return LOG_LEVEL_ALL
 }
 
+// LogTypeToSeverity maps a level to its syslog severity (RFC 5424), from 0
+// (emergency) to 7 (debug), for routing rules that compare numbers.
+func LogTypeToSeverity(t LogType) int {
+	switch t {
+	case LOG_FATAL:
+		return 2
+	case LOG_ERROR:
+		return 3
+	case LOG_WARNING:
+		return 4
+	case LOG_INFO:
+		return 6
+	case LOG_DEBUG:
+		return 7
+	}
+	return 5
+}
+
+func StringToLogType(t string) LogType {
+	switch t {
+	case "fatal":
+		return LOG_FATAL
+	case "error":
+		return LOG_ERROR
+	case "warn":
+		return LOG_WARNING
+	case "warning":
+		return LOG_WARNING
+	case "debug":
+		return LOG_DEBUG
+	case "info":
+		return LOG_INFO
+	}
+	return 0
+}
+
+func LogLevelToString(level LogLevel) string {
+	switch level {
+	case LOG_LEVEL_NONE:
+		return "none"
+	case LOG_LEVEL_FATAL:
+		return "fatal"
+	case LOG_LEVEL_ERROR:
+		return "error"
+	case LOG_LEVEL_WARN:
+		return "warn"
+	case LOG_LEVEL_INFO:
+		return "info"
+	case LOG_LEVEL_DEBUG:
+		return "debug"
+	}
+	return "custom"
+}
+
 func LogTypeToString(t LogType) string {
 	switch t {
 	case LOG_FATAL:
@@ -265,10 +1111,26 @@ func LogTypeToString(t LogType) string {
 	}
 	return "unknown"
 }
+
+var std atomic.Value
+
+func init() {
+	std.Store(New())
+}
+
+// Default returns the logger used by the package-level helpers such as Wrap.
+func Default() *Logger {
+	return std.Load().(*Logger)
+}
+
+func SetDefault(l *Logger) {
+	std.Store(l)
+}
+
 func New() *Logger {
 	return NewLogger(os.Stderr, "", Ldate|Ltime|Lshortfile)
 }
 
 func NewLogger(w io.Writer, prefix string, flags int) *Logger {
-	return &Logger{_log: log.New(w, prefix, flags), level: LOG_LEVEL_ALL, TimeFormat: FORMAT_TIME_DAY, SuffixName: ".log"}
+	return &Logger{_log: log.New(w, prefix, flags), level: int32(LOG_LEVEL_ALL), TimeFormat: FORMAT_TIME_DAY, SuffixName: ".log"}
 }
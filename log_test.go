@@ -0,0 +1,147 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLogAt(t *testing.T) {
+	ts := time.Date(2020, 3, 4, 5, 6, 7, 8, time.UTC)
+	l, b := jsonLogger()
+	l.LogAt(LOG_WARNING, ts, "imported")
+	l.LogAtf(LOG_INFO, ts.Add(time.Hour), "imported %d", 2)
+	l.Info("live")
+
+	got := decodeLines(t, b)
+	if len(got) != 3 {
+		t.Fatalf("%d entries, want 3", len(got))
+	}
+	for i, want := range []time.Time{ts, ts.Add(time.Hour)} {
+		if got[i]["timestamp"] != want.Format(time.RFC3339Nano) || got[i]["replayed"] != true {
+			t.Errorf("%s: timestamp %v, replayed %v; want %v, true", got[i]["message"], got[i]["timestamp"], got[i]["replayed"], want)
+		}
+	}
+	if got[2]["replayed"] != nil {
+		t.Error("live entry marked as replayed")
+	}
+
+	var out bytes.Buffer
+	NewLogger(&out, "", LstdFlags).LogAt(LOG_INFO, ts, "imported")
+	if want := "2020/03/04 05:06:07 [info] [replayed] imported \n"; out.String() != want {
+		t.Errorf("text = %q, want %q", out.String(), want)
+	}
+}
+
+// captureStderr returns what fn writes to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	r.Close()
+	return string(out)
+}
+
+func TestMirrorToStderr(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.MirrorToStderr(LOG_ERROR)
+	mirrored := captureStderr(t, func() {
+		l.Error("failed")
+		l.Warning("slow")
+	})
+
+	if want := "[error] failed \n"; mirrored != want {
+		t.Errorf("stderr = %q, want %q", mirrored, want)
+	}
+	if want := "[error] failed \n[warning] slow \n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+
+	l.MirrorToStderr(0)
+	if mirrored := captureStderr(t, func() { l.Error("again") }); mirrored != "" {
+		t.Errorf("stderr = %q after turning mirroring off", mirrored)
+	}
+}
+
+func TestZeroLogger(t *testing.T) {
+	var b bytes.Buffer
+	var l Logger
+	l.SetOutput(&b)
+	l.Debug("zero")
+	if got := b.String(); !bytes.HasSuffix([]byte(got), []byte("[debug] zero \n")) {
+		t.Errorf("output = %q, want the defaults of New", got)
+	}
+	if l.Level() != LOG_LEVEL_ALL {
+		t.Errorf("level = %v, want all", l.Level())
+	}
+}
+
+func TestMustInitPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustInit did not panic on an invalid configuration")
+		}
+	}()
+	var l Logger
+	l.MustInit(`{"EntryTimeFormat": "constant"}`)
+}
+
+func TestSetLevelNames(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	names := map[LogType]string{LOG_ERROR: "FEHLER"}
+	l.SetLevelNames(names)
+	names[LOG_ERROR] = "changed"
+	l.Error("a")
+	l.Info("b")
+	l.SetLevelNames(nil)
+	l.Error("c")
+
+	if want := "[FEHLER] a \n[info] b \n[error] c \n"; b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+
+	// structured formats keep the canonical names
+	l, jb := jsonLogger()
+	l.SetLevelNames(map[LogType]string{LOG_ERROR: "FEHLER"})
+	l.Error("d")
+	if got := decodeLines(t, jb); len(got) != 1 || got[0]["level"] != "error" {
+		t.Errorf("JSON = %s, want level error", jb.String())
+	}
+}
+
+func TestIncludeSeverity(t *testing.T) {
+	l, b := jsonLogger()
+	l.Error("a")
+	l.IncludeSeverity(true)
+	l.Error("b")
+	l.Warning("c")
+	l.Info("d")
+	l.Debug("e")
+
+	got := decodeLines(t, b)
+	if len(got) != 5 {
+		t.Fatalf("%d entries, want 5", len(got))
+	}
+	if got[0]["severity"] != nil {
+		t.Error("severity written before IncludeSeverity")
+	}
+	// syslog numbers: err, warning, info, debug
+	for i, want := range []float64{3, 4, 6, 7} {
+		if got[i+1]["severity"] != want {
+			t.Errorf("%s: severity %v, want %v", got[i+1]["level"], got[i+1]["severity"], want)
+		}
+	}
+}
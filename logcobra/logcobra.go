@@ -0,0 +1,73 @@
+// Package logcobra binds the standard logging flags of package log to cobra
+// commands and hands out a named child logger per command.
+package logcobra
+
+import (
+	"strings"
+	"sync"
+
+	log "github.com/Yprolic/log"
+	"github.com/spf13/cobra"
+)
+
+// Binding ties a logger to a cobra command tree.
+type Binding struct {
+	Flags log.Flags
+
+	l        *log.Logger
+	lock     sync.Mutex
+	children map[*cobra.Command]*log.Logger
+}
+
+// Bind adds the persistent flags --log-level, --log-file and -v/--verbose
+// (-v for info, -vv for debug) to root and applies them to l before any
+// command runs. Subcommands defining their own PersistentPreRunE must call
+// the parent's, as usual with cobra.
+func Bind(root *cobra.Command, l *log.Logger) *Binding {
+	b := &Binding{l: l, children: make(map[*cobra.Command]*log.Logger)}
+
+	pf := root.PersistentFlags()
+	pf.StringVar(&b.Flags.Level, "log-level", "", "log level: fatal, error, warn, info or debug")
+	pf.StringVar(&b.Flags.File, "log-file", "", "write logs to rotating files with this base name")
+	pf.CountVarP(&b.Flags.Verbosity, "verbose", "v", "increase verbosity: -v for info, -vv for debug")
+
+	prev := root.PersistentPreRunE
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// only what was given on the command line, so l keeps the rest
+		var f log.Flags
+		if cmd.Flags().Changed("log-level") {
+			f.Level = b.Flags.Level
+		}
+		if cmd.Flags().Changed("log-file") {
+			f.File = b.Flags.File
+		}
+		if cmd.Flags().Changed("verbose") {
+			f.Verbosity = b.Flags.Verbosity
+		}
+		err := f.Apply(l)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			return prev(cmd, args)
+		}
+		return nil
+	}
+
+	return b
+}
+
+// Logger returns the child logger of cmd, named after its command path
+// ("app serve" becomes "app.serve"). The same logger is returned for every
+// call with the same command.
+func (b *Binding) Logger(cmd *cobra.Command) *log.Logger {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if c, ok := b.children[cmd]; ok {
+		return c
+	}
+	c := b.l.Named(strings.ReplaceAll(cmd.CommandPath(), " ", "."))
+	b.children[cmd] = c
+	return c
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"strconv"
+	"time"
+)
+
+// logfmtEncoder renders entries as logfmt lines:
+// time=... level=info caller=x.go:12 logger=a.b msg="..." key=value...
+type logfmtEncoder struct {
+	shortFile bool
+}
+
+func (enc *logfmtEncoder) encode(buf []byte, e *Entry) []byte {
+	buf = append(buf, "time="...)
+	buf = e.Time.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, " level="...)
+	buf = append(buf, LogTypeToString(e.Level)...)
+	if e.File != "" {
+		file := e.File
+		if enc.shortFile {
+			file = shortFileName(file)
+		}
+		buf = append(buf, " caller="...)
+		if needsQuote(file) {
+			buf = appendValue(buf, file+":"+strconv.Itoa(e.Line))
+		} else {
+			buf = append(buf, file...)
+			buf = append(buf, ':')
+			buf = strconv.AppendInt(buf, int64(e.Line), 10)
+		}
+	}
+	if e.Name != "" {
+		buf = append(buf, " logger="...)
+		buf = appendValue(buf, e.Name)
+	}
+	buf = append(buf, " msg="...)
+	buf = appendValue(buf, structuredMessage(e.Message))
+	if e.Replayed {
+		buf = append(buf, " replayed=true"...)
+	}
+	if e.Seq != 0 {
+		buf = append(buf, " seq="...)
+		buf = strconv.AppendUint(buf, e.Seq, 10)
+	}
+	for _, f := range e.Fields {
+		buf = append(buf, ' ')
+		buf = appendLogfmtKey(buf, f.Key)
+		buf = append(buf, '=')
+		buf = appendValue(buf, f.Value)
+	}
+	return append(buf, '\n')
+}
+
+// appendLogfmtKey writes key with the characters logfmt keys cannot hold
+// replaced by '_'.
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
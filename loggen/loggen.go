@@ -0,0 +1,189 @@
+// Package loggen generates typed logging functions for package log from a
+// schema of events, so the fields of every event are checked at compile
+// time. The schema is JSON:
+//
+//	{"events": [
+//		{"name": "UserLogin", "level": "info", "message": "user logged in",
+//		 "fields": [{"name": "user_id", "type": "string"}, {"name": "attempts", "type": "int"}]}
+//	]}
+//
+// becomes
+//
+//	func UserLogin(l *log.Logger, userID string, attempts int)
+//
+// Run it with go generate through cmd/loggen.
+package loggen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strings"
+	"unicode"
+
+	log "github.com/Yprolic/log"
+)
+
+type Schema struct {
+	Events []Event `json:"events"`
+}
+
+// Event is one generated function. Level is a level name such as "info".
+type Event struct {
+	Name    string  `json:"name"`
+	Level   string  `json:"level"`
+	Message string  `json:"message"`
+	Doc     string  `json:"doc"`
+	Fields  []Field `json:"fields"`
+}
+
+// Field is a parameter of an event, written as the field Name.
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// field types and the Go types of their parameters
+var types = map[string]string{
+	"string":   "string",
+	"int":      "int",
+	"int64":    "int64",
+	"uint64":   "uint64",
+	"float64":  "float64",
+	"bool":     "bool",
+	"duration": "time.Duration",
+	"time":     "time.Time",
+	"error":    "error",
+	"any":      "interface{}",
+}
+
+var levels = map[log.LogType]string{
+	log.LOG_FATAL:   "log.LOG_FATAL",
+	log.LOG_ERROR:   "log.LOG_ERROR",
+	log.LOG_WARNING: "log.LOG_WARNING",
+	log.LOG_INFO:    "log.LOG_INFO",
+	log.LOG_DEBUG:   "log.LOG_DEBUG",
+}
+
+// Parse reads a schema and checks it.
+func Parse(r io.Reader) (*Schema, error) {
+	var s Schema
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	err := d.Decode(&s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, s.check()
+}
+
+func (s *Schema) check() error {
+	names := map[string]bool{}
+	for _, e := range s.Events {
+		if !token.IsIdentifier(e.Name) || !token.IsExported(e.Name) {
+			return fmt.Errorf("loggen: event name %q is not an exported Go identifier", e.Name)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("loggen: duplicate event %s", e.Name)
+		}
+		names[e.Name] = true
+		if _, ok := levels[log.StringToLogType(e.Level)]; !ok {
+			return fmt.Errorf("loggen: event %s: unknown level %q", e.Name, e.Level)
+		}
+
+		// the logger and the packages the generated code uses
+		params := map[string]bool{"l": true, "log": true, "time": true}
+		for _, f := range e.Fields {
+			if f.Name == "" {
+				return fmt.Errorf("loggen: event %s: field without name", e.Name)
+			}
+			if _, ok := types[f.Type]; !ok {
+				return fmt.Errorf("loggen: event %s: field %s has unknown type %q", e.Name, f.Name, f.Type)
+			}
+			p := paramName(f.Name)
+			if !token.IsIdentifier(p) || params[p] {
+				return fmt.Errorf("loggen: event %s: field %s gives parameter %s, which is not usable", e.Name, f.Name, p)
+			}
+			params[p] = true
+		}
+	}
+	return nil
+}
+
+// Generate returns the Go source of package pkg with a function per event
+// of s, formatted. source names the schema in the generated header.
+func Generate(s *Schema, pkg, source string) ([]byte, error) {
+	err := s.check()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by loggen from %s; DO NOT EDIT.\n\npackage %s\n\nimport (\n", source, pkg)
+	if s.usesTime() {
+		b.WriteString("\t\"time\"\n\n")
+	}
+	b.WriteString("\t\"github.com/Yprolic/log\"\n)\n")
+
+	for _, e := range s.Events {
+		level := levels[log.StringToLogType(e.Level)]
+		doc := e.Doc
+		if doc == "" {
+			doc = "writes the " + e.Name + " event"
+		}
+		fmt.Fprintf(&b, "\n// %s %s.\nfunc %s(l *log.Logger", e.Name, strings.TrimSuffix(doc, "."), e.Name)
+		for _, f := range e.Fields {
+			fmt.Fprintf(&b, ", %s %s", paramName(f.Name), types[f.Type])
+		}
+		fmt.Fprintf(&b, ") {\n\tif !l.IsLevelEnabled(%s) {\n\t\treturn\n\t}\n", level)
+		fmt.Fprintf(&b, "\tl.LogFieldsSkip(1, %s, %q", level, e.Message)
+		for _, f := range e.Fields {
+			fmt.Fprintf(&b, ",\n\t\tlog.F(%q, %s)", f.Name, paramName(f.Name))
+		}
+		b.WriteString(")\n}\n")
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func (s *Schema) usesTime() bool {
+	for _, e := range s.Events {
+		for _, f := range e.Fields {
+			if strings.HasPrefix(types[f.Type], "time.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// initialisms kept upper case in parameter names, as Go style wants
+var initialisms = map[string]bool{"id": true, "ip": true, "url": true, "http": true, "api": true, "uuid": true}
+
+// paramName turns a field name such as user_id into userID.
+func paramName(field string) string {
+	parts := strings.FieldsFunc(field, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, p := range parts {
+		r := []rune(p)
+		switch {
+		case i == 0:
+			r[0] = unicode.ToLower(r[0])
+		case initialisms[strings.ToLower(p)]:
+			r = []rune(strings.ToUpper(p))
+		default:
+			r[0] = unicode.ToUpper(r[0])
+		}
+		b.WriteString(string(r))
+	}
+	name := b.String()
+	if token.Lookup(name).IsKeyword() {
+		name += "_"
+	}
+	return name
+}
@@ -0,0 +1,61 @@
+package loggen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const schema = `{"events": [
+	{"name": "UserLogin", "level": "info", "message": "user logged in", "doc": "records a login.",
+	 "fields": [{"name": "user_id", "type": "string"}, {"name": "attempts", "type": "int"}]},
+	{"name": "RequestSlow", "level": "warning", "message": "slow request",
+	 "fields": [{"name": "http-url", "type": "string"}, {"name": "took", "type": "duration"}, {"name": "type", "type": "any"}]}
+]}`
+
+func TestGenerate(t *testing.T) {
+	s, err := Parse(strings.NewReader(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Generate(s, "events", "events.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "events.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		"// Code generated by loggen from events.json; DO NOT EDIT.",
+		"package events",
+		`"time"`,
+		"// UserLogin records a login.\nfunc UserLogin(l *log.Logger, userID string, attempts int) {",
+		"if !l.IsLevelEnabled(log.LOG_INFO) {",
+		`l.LogFieldsSkip(1, log.LOG_INFO, "user logged in",`,
+		`log.F("user_id", userID)`,
+		"// RequestSlow writes the RequestSlow event.\nfunc RequestSlow(l *log.Logger, httpURL string, took time.Duration, type_ interface{}) {",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code lacks %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for name, schema := range map[string]string{
+		"unexported":    `{"events": [{"name": "login", "level": "info"}]}`,
+		"duplicate":     `{"events": [{"name": "Login", "level": "info"}, {"name": "Login", "level": "info"}]}`,
+		"level":         `{"events": [{"name": "Login", "level": "loud"}]}`,
+		"type":          `{"events": [{"name": "Login", "level": "info", "fields": [{"name": "n", "type": "complex"}]}]}`,
+		"unnamed field": `{"events": [{"name": "Login", "level": "info", "fields": [{"type": "int"}]}]}`,
+		"clash":         `{"events": [{"name": "Login", "level": "info", "fields": [{"name": "user_id", "type": "int"}, {"name": "userID", "type": "int"}]}]}`,
+		"logger param":  `{"events": [{"name": "Login", "level": "info", "fields": [{"name": "l", "type": "int"}]}]}`,
+		"unknown key":   `{"events": [{"name": "Login", "level": "info", "severity": 3}]}`,
+	} {
+		if _, err := Parse(strings.NewReader(schema)); err == nil {
+			t.Errorf("%s: schema accepted", name)
+		}
+	}
+}
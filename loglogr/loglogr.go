@@ -0,0 +1,85 @@
+// Package loglogr adapts package log to github.com/go-logr/logr, for
+// libraries such as controller-runtime that take a logr.Logger:
+//
+//	ctrl.SetLogger(loglogr.New(log.Default()))
+//
+// V(0) writes info entries and higher verbosities debug entries.
+package loglogr
+
+import (
+	"runtime"
+	"time"
+
+	log "github.com/Yprolic/log"
+	"github.com/go-logr/logr"
+)
+
+// ERROR_KEY is the field Error writes its error as.
+const ERROR_KEY = "error"
+
+// New returns a logr.Logger writing through l.
+func New(l *log.Logger) logr.Logger {
+	return logr.New(NewSink(l))
+}
+
+// Sink is a logr.LogSink writing through a Logger. Names given to WithName
+// name child loggers with Named, values given to WithValues attach fields
+// with With.
+type Sink struct {
+	l *log.Logger
+	// frames between the caller and the methods of Sink
+	depth int
+}
+
+func NewSink(l *log.Logger) *Sink {
+	return &Sink{l: l}
+}
+
+// Level maps a V-level to the level of its entries.
+func Level(v int) log.LogType {
+	if v > 0 {
+		return log.LOG_DEBUG
+	}
+	return log.LOG_INFO
+}
+
+func (s *Sink) Init(info logr.RuntimeInfo) {
+	s.depth = info.CallDepth
+}
+
+func (s *Sink) Enabled(level int) bool {
+	return s.l.IsLevelEnabled(Level(level))
+}
+
+func (s *Sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.emit(Level(level), msg, log.Fields(keysAndValues...))
+}
+
+func (s *Sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	fields := append([]log.Field{{Key: ERROR_KEY, Value: err}}, log.Fields(keysAndValues...)...)
+	s.emit(log.LOG_ERROR, msg, fields)
+}
+
+func (s *Sink) emit(t log.LogType, msg string, fields []log.Field) {
+	if !s.l.IsLevelEnabled(t) {
+		return
+	}
+	e := &log.Entry{Level: t, Time: time.Now(), Message: msg, Fields: fields}
+	// emit, the method of Sink, then the frames of logr
+	_, e.File, e.Line, _ = runtime.Caller(2 + s.depth)
+	s.l.Emit(e)
+}
+
+func (s *Sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &Sink{l: s.l.With(keysAndValues...), depth: s.depth}
+}
+
+func (s *Sink) WithName(name string) logr.LogSink {
+	return &Sink{l: s.l.Named(name), depth: s.depth}
+}
+
+// WithCallDepth implements logr.CallDepthLogSink, for helpers logging on
+// behalf of their callers.
+func (s *Sink) WithCallDepth(depth int) logr.LogSink {
+	return &Sink{l: s.l, depth: s.depth + depth}
+}
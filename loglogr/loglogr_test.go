@@ -0,0 +1,55 @@
+package loglogr
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	log "github.com/Yprolic/log"
+	"github.com/go-logr/logr"
+)
+
+func TestLogr(t *testing.T) {
+	var b bytes.Buffer
+	l := log.NewLogger(&b, "", log.Lshortfile)
+	l.SetLevel(log.LOG_LEVEL_INFO)
+	lr := New(l).WithName("ctrl").WithValues("controller", "pods")
+
+	_, file, line, _ := runtime.Caller(0)
+	lr.Info("reconciled", "count", 3)
+	lr.V(1).Info("hidden")
+	lr.Error(errors.New("conflict"), "update failed", "pod", "web")
+
+	at := filepath.Base(file) + ":"
+	want := at + strconv.Itoa(line+1) + ": [info] [ctrl] reconciled controller=pods count=3\n" +
+		at + strconv.Itoa(line+3) + ": [error] [ctrl] update failed controller=pods error=conflict pod=web\n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	if lr.V(1).Enabled() || !lr.V(0).Enabled() {
+		t.Error("Enabled does not follow the level")
+	}
+}
+
+// logVia logs through a helper marking itself as one.
+func logVia(lr logr.Logger) {
+	lr.Info("helped")
+}
+
+func TestLogrCallDepth(t *testing.T) {
+	var b bytes.Buffer
+	lr := New(log.NewLogger(&b, "", log.Lshortfile))
+
+	_, _, line, _ := runtime.Caller(0)
+	logVia(lr.WithCallDepth(1))
+	if want := ":" + strconv.Itoa(line+1) + ": "; !strings.Contains(b.String(), want) {
+		t.Errorf("output = %q, want the caller of the helper", b.String())
+	}
+	if Level(0) != log.LOG_INFO || Level(2) != log.LOG_DEBUG {
+		t.Error("wrong V-level mapping")
+	}
+}
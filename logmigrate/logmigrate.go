@@ -0,0 +1,146 @@
+// Package logmigrate rewrites existing log files of package log after a
+// field schema change, renaming or removing fields in place.
+package logmigrate
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Yprolic/log"
+)
+
+// Rule renames the field From to To, or removes it if To is empty.
+type Rule struct {
+	From string
+	To   string
+}
+
+// ParseRule reads "old=new" as a rename and "-old" as a removal.
+func ParseRule(spec string) (Rule, error) {
+	if strings.HasPrefix(spec, "-") && len(spec) > 1 {
+		return Rule{From: spec[1:]}, nil
+	}
+	i := strings.IndexByte(spec, '=')
+	if i <= 0 || i == len(spec)-1 {
+		return Rule{}, errors.New("logmigrate: bad rule " + spec + ", want old=new or -old")
+	}
+	return Rule{From: spec[:i], To: spec[i+1:]}, nil
+}
+
+// Apply returns fields with the rules applied, in order.
+func Apply(rules []Rule, fields []log.Field) []log.Field {
+	out := fields[:0:0]
+	for _, f := range fields {
+		keep := true
+		for _, r := range rules {
+			if f.Key != r.From {
+				continue
+			}
+			if r.To == "" {
+				keep = false
+				break
+			}
+			f.Key = r.To
+		}
+		if keep {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Rewrite copies r to w line by line with the rules applied to every
+// entry and returns the number of lines and of lines that changed.
+func Rewrite(r io.Reader, w io.Writer, rules []Rule) (lines, changed int, err error) {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	for {
+		line, rerr := br.ReadString('\n')
+		if len(line) > 0 {
+			lines++
+			out, _ := log.RewriteFields(line, func(f []log.Field) []log.Field { return Apply(rules, f) })
+			if out != line {
+				changed++
+			}
+			_, err = bw.WriteString(out)
+			if err != nil {
+				return lines, changed, err
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return lines, changed, rerr
+		}
+	}
+	return lines, changed, bw.Flush()
+}
+
+// Result describes one rewritten file, with the SHA-256 of its content
+// before and after.
+type Result struct {
+	Path      string
+	Lines     int
+	Changed   int
+	OldSHA256 string
+	NewSHA256 string
+}
+
+// RewriteFile rewrites the file at path through a temporary file that
+// replaces it once complete, keeping its mode. With dryRun set the file is
+// left alone and NewSHA256 is what it would have been.
+func RewriteFile(path string, rules []Rule, dryRun bool) (Result, error) {
+	res := Result{Path: path}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return res, err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return res, err
+	}
+
+	oldSum, newSum := sha256.New(), sha256.New()
+	var out io.Writer = io.Discard
+	var tmp *os.File
+	if !dryRun {
+		tmp, err = os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".migrate")
+		if err != nil {
+			return res, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		out = tmp
+	}
+
+	res.Lines, res.Changed, err = Rewrite(io.TeeReader(in, oldSum), io.MultiWriter(out, newSum), rules)
+	if err != nil {
+		return res, err
+	}
+	res.OldSHA256 = hex.EncodeToString(oldSum.Sum(nil))
+	res.NewSHA256 = hex.EncodeToString(newSum.Sum(nil))
+	if dryRun || res.Changed == 0 {
+		return res, nil
+	}
+
+	err = tmp.Chmod(fi.Mode().Perm())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = tmp.Close()
+	}
+	if err != nil {
+		return res, err
+	}
+	return res, os.Rename(tmp.Name(), path)
+}
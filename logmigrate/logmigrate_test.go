@@ -0,0 +1,59 @@
+package logmigrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	for spec, want := range map[string]Rule{
+		"uid=user_id": {From: "uid", To: "user_id"},
+		"-password":   {From: "password"},
+	} {
+		if got, err := ParseRule(spec); err != nil || got != want {
+			t.Errorf("%q = %v, %v; want %v", spec, got, err, want)
+		}
+	}
+	for _, spec := range []string{"uid", "=x", "uid=", "-"} {
+		if _, err := ParseRule(spec); err == nil {
+			t.Errorf("%q: no error", spec)
+		}
+	}
+}
+
+func TestRewriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	in := "[info] login uid=7 password=x\n[info] logout \n"
+	if err := os.WriteFile(path, []byte(in), 0640); err != nil {
+		t.Fatal(err)
+	}
+	rules := []Rule{{From: "uid", To: "user_id"}, {From: "password"}}
+
+	dry, err := RewriteFile(path, rules, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != in {
+		t.Error("dry run changed the file")
+	}
+
+	res, err := RewriteFile(path, rules, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(path)
+	if want := "[info] login user_id=7\n[info] logout \n"; string(b) != want {
+		t.Errorf("file = %q, want %q", b, want)
+	}
+	if res.Lines != 2 || res.Changed != 1 || res.NewSHA256 != dry.NewSHA256 || res.OldSHA256 == res.NewSHA256 {
+		t.Errorf("result = %+v, dry run %+v", res, dry)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0640 {
+		t.Errorf("mode %v, want 0640", fi.Mode().Perm())
+	}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*migrate*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left: %v", matches)
+	}
+}
@@ -0,0 +1,44 @@
+// Package logsqlite stores entries of package log in a local SQLite
+// database, for appliances that need to query their logs without an
+// external log stack. It uses the pure Go driver modernc.org/sqlite.
+package logsqlite
+
+import (
+	"database/sql"
+
+	log "github.com/Yprolic/log"
+	_ "modernc.org/sqlite"
+)
+
+// DEFAULT_TABLE is the table Open writes to.
+const DEFAULT_TABLE = "log_entries"
+
+// Sink is a log.SQLSink that owns its database.
+type Sink struct {
+	*log.SQLSink
+	db *sql.DB
+}
+
+// Open opens (or creates) the database at path, in WAL mode so queries do
+// not block writers, and returns a sink writing to DEFAULT_TABLE.
+func Open(path string) (*Sink, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	s, err := log.NewSQLSink(db, DEFAULT_TABLE)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Sink{SQLSink: s, db: db}, nil
+}
+
+// Close closes the sink and the database.
+func (s *Sink) Close() error {
+	err := s.SQLSink.Close()
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
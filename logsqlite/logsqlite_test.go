@@ -0,0 +1,48 @@
+package logsqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/Yprolic/log"
+)
+
+func TestStoreAndQuery(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "logs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	base := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	for i, e := range []log.Entry{
+		{Level: log.LOG_INFO, Message: "started", Name: "api"},
+		{Level: log.LOG_ERROR, Message: "failed", Name: "api", Fields: []log.Field{{Key: "code", Value: 500}}},
+		{Level: log.LOG_DEBUG, Message: "detail", Name: "worker"},
+	} {
+		e.Time = base.Add(time.Duration(i) * time.Minute)
+		if err := s.WriteEntry(&e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.Query(log.StoreQuery{Level: log.LOG_INFO, Logger: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Message != "failed" || got[1].Message != "started" {
+		t.Fatalf("entries = %v, want failed and started, newest first", got)
+	}
+	if !got[0].Time.Equal(base.Add(time.Minute)) || len(got[0].Fields) != 1 || got[0].Fields[0].Key != "code" {
+		t.Errorf("entry = %+v", got[0])
+	}
+
+	got, err = s.Query(log.StoreQuery{From: base.Add(time.Minute), Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Message != "detail" {
+		t.Errorf("entries = %v, want only detail", got)
+	}
+}
@@ -0,0 +1,24 @@
+// Package logzstd adds zstd compression to package log, using
+// github.com/klauspost/compress/zstd. Importing it makes "zstd" a Compress
+// method for rotated files:
+//
+//	import _ "github.com/Yprolic/log/logzstd"
+//
+// StreamSink compresses entries as they are written instead.
+package logzstd
+
+import (
+	"io"
+
+	log "github.com/Yprolic/log"
+	"github.com/klauspost/compress/zstd"
+)
+
+// COMPRESS_ZSTD is the Compress value registered by this package.
+const COMPRESS_ZSTD = "zstd"
+
+func init() {
+	log.RegisterCompressor(COMPRESS_ZSTD, ".zst", func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w)
+	})
+}
@@ -0,0 +1,86 @@
+package logzstd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/Yprolic/log"
+	"github.com/klauspost/compress/zstd"
+)
+
+func decompress(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	d, err := zstd.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	b, err := io.ReadAll(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestCompressRotated(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Now().Add(-time.Hour)
+	for _, name := range []string{"app.20240101.log", "app.20240102.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mtime, mtime)
+		mtime = mtime.Add(time.Minute)
+	}
+
+	// the newest file is left alone
+	err := log.ApplyRetention(nil, filepath.Join(dir, "app"), ".log", log.Retention{Compress: COMPRESS_ZSTD})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decompress(t, filepath.Join(dir, "app.20240101.log.zst")); got != "app.20240101.log\n" {
+		t.Errorf("decompressed = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.20240102.log")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStreamSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.zst")
+	for _, msg := range []string{"first", "second"} {
+		s, err := NewStreamSink(path, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := log.NewLogger(io.Discard, "", 0)
+		l.AddSink(s)
+		l.Info(msg)
+		// Sync makes the entry readable before Close
+		if err := s.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(); err != nil {
+			t.Errorf("second Close: %v", err)
+		}
+	}
+
+	// reopening appends a frame, read as one stream
+	got := decompress(t, path)
+	if strings.Count(got, "\n") != 2 || !strings.Contains(got, "first") || !strings.Contains(got, "second") {
+		t.Errorf("stream = %q", got)
+	}
+}
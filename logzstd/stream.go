@@ -0,0 +1,125 @@
+package logzstd
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/Yprolic/log"
+	"github.com/klauspost/compress/zstd"
+)
+
+// how often a StreamSink makes what it wrote readable by default
+const DEFAULT_FLUSH_INTERVAL = time.Second
+
+// StreamSink writes entries to a file as one zstd stream, compressed as
+// they come instead of at rotation. The stream is flushed every interval,
+// so zstdcat or a tail of the file sees entries that late at most. Opening
+// an existing file appends a new frame, which zstd tools read as one
+// stream. Layout options are those of the embedded WriterSink.
+type StreamSink struct {
+	*log.WriterSink
+	stream *stream
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+type stream struct {
+	f     *os.File
+	enc   *zstd.Encoder
+	dirty bool
+	lock  sync.Mutex
+}
+
+// NewStreamSink opens (or appends to) the stream at path, flushed every
+// interval, DEFAULT_FLUSH_INTERVAL if 0.
+func NewStreamSink(path string, interval time.Duration) (*StreamSink, error) {
+	if interval <= 0 {
+		interval = DEFAULT_FLUSH_INTERVAL
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := zstd.NewWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	st := &stream{f: f, enc: enc}
+	s := &StreamSink{
+		WriterSink: log.NewWriterSink(st),
+		stream:     st,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go s.flushEvery(interval)
+	return s, nil
+}
+
+func (s *StreamSink) flushEvery(interval time.Duration) {
+	defer close(s.done)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.stream.flush()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Sync flushes the stream and syncs the file, for Logger.Flush.
+func (s *StreamSink) Sync() error {
+	err := s.stream.flush()
+	if err != nil {
+		return err
+	}
+	return s.stream.f.Sync()
+}
+
+// Close ends the stream and closes the file.
+func (s *StreamSink) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+	}
+	close(s.stop)
+	<-s.done
+	return s.WriterSink.Close()
+}
+
+func (st *stream) Write(p []byte) (int, error) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	st.dirty = true
+	return st.enc.Write(p)
+}
+
+func (st *stream) flush() error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if !st.dirty {
+		return nil
+	}
+	st.dirty = false
+	return st.enc.Flush()
+}
+
+func (st *stream) Close() error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	err := st.enc.Close()
+	if cerr := st.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
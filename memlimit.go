@@ -0,0 +1,120 @@
+package log
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrMemoryLimit = errors.New("log: memory limit reached, entry dropped")
+
+// memory accounts for what the package holds on to across all loggers:
+// entries retained by memory sinks, spool backlogs and encoded entries
+// queued by outputs.
+var memory = &memoryAccount{}
+
+type memoryAccount struct {
+	used    int64 // accessed atomically
+	limit   int64 // accessed atomically
+	dropped int64 // accessed atomically
+
+	lock  sync.Mutex
+	users []memoryUser
+}
+
+// memoryUser is a component that can give memory back under pressure.
+// shedMemory frees about need bytes if it can and returns what it freed;
+// it must not block on its own lock, which the caller of reserve may hold.
+type memoryUser interface {
+	shedMemory(need int64) int64
+}
+
+// SetMemoryLimit caps the approximate memory held by the package, in bytes;
+// 0 means no cap. Past the cap memory is reclaimed in this order:
+//
+//  1. memory sinks evict their oldest entries,
+//  2. spool sinks drop their in-memory backlog, which is read back from the
+//     spool file at the next delivery,
+//  3. new entries that would still not fit are dropped and counted as shed
+//     in Stats.
+func SetMemoryLimit(bytes int64) {
+	atomic.StoreInt64(&memory.limit, bytes)
+}
+
+// MemoryUsage returns the approximate memory held by the package, in bytes.
+func MemoryUsage() int64 {
+	return atomic.LoadInt64(&memory.used)
+}
+
+func (m *memoryAccount) register(u memoryUser) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, r := range m.users {
+		if r == u {
+			return
+		}
+	}
+	m.users = append(m.users, u)
+}
+
+func (m *memoryAccount) unregister(u memoryUser) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i, r := range m.users {
+		if r == u {
+			m.users = append(m.users[:i:i], m.users[i+1:]...)
+			return
+		}
+	}
+}
+
+// reserve accounts n more bytes, shedding memory if that goes over the
+// limit. It fails, and nothing is accounted, if the bytes do not fit.
+func (m *memoryAccount) reserve(n int64) bool {
+	used := atomic.AddInt64(&m.used, n)
+	limit := atomic.LoadInt64(&m.limit)
+	if limit <= 0 || used <= limit {
+		return true
+	}
+
+	m.lock.Lock()
+	users := append([]memoryUser(nil), m.users...)
+	m.lock.Unlock()
+
+	sortMemoryUsers(users)
+	for _, u := range users {
+		over := atomic.LoadInt64(&m.used) - limit
+		if over <= 0 {
+			return true
+		}
+		u.shedMemory(over)
+	}
+	if atomic.LoadInt64(&m.used) <= limit {
+		return true
+	}
+
+	atomic.AddInt64(&m.used, -n)
+	return false
+}
+
+// drop counts an entry lost to the limit.
+func (m *memoryAccount) drop() {
+	atomic.AddInt64(&m.dropped, 1)
+}
+
+func (m *memoryAccount) release(n int64) {
+	atomic.AddInt64(&m.used, -n)
+}
+
+// sortMemoryUsers puts memory sinks before spool sinks.
+func sortMemoryUsers(users []memoryUser) {
+	n := 0
+	for i, u := range users {
+		if _, ok := u.(*MemorySink); ok {
+			users[n], users[i] = users[i], users[n]
+			n++
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// shedUser gives back up to what it holds in m.
+type shedUser struct {
+	m    *memoryAccount
+	held int64
+}
+
+func (u *shedUser) shedMemory(need int64) int64 {
+	n := need
+	if n > u.held {
+		n = u.held
+	}
+	u.held -= n
+	u.m.release(n)
+	return n
+}
+
+func TestMemoryAccountReserve(t *testing.T) {
+	m := &memoryAccount{limit: 100}
+	u := &shedUser{m: m}
+	m.register(u)
+	m.register(u)
+	if len(m.users) != 1 {
+		t.Fatalf("%d users after registering twice", len(m.users))
+	}
+
+	if !m.reserve(60) {
+		t.Fatal("60 of 100 bytes did not fit")
+	}
+	u.held = 60
+	// the user sheds 20 to make room
+	if !m.reserve(60) || u.held != 40 || m.used != 100 {
+		t.Errorf("used %d, user holds %d; want 100 and 40", m.used, u.held)
+	}
+	// nothing left to shed that makes 200 fit
+	if m.reserve(200) {
+		t.Error("200 bytes fit under a limit of 100")
+	}
+	if m.used > 100 {
+		t.Errorf("failed reserve left %d bytes accounted", m.used)
+	}
+
+	m.unregister(u)
+	if len(m.users) != 0 {
+		t.Error("user still registered")
+	}
+}
+
+func TestSetMemoryLimitEvictsMemorySinks(t *testing.T) {
+	defer SetMemoryLimit(0)
+	s := NewMemorySink(0, 0)
+	defer s.Close()
+	e := &Entry{Message: "entry"}
+	size := int64(entrySize(e))
+
+	base := MemoryUsage()
+	SetMemoryLimit(base + 3*size)
+	dropped := atomic.LoadInt64(&memory.dropped)
+	for i := 0; i < 10; i++ {
+		s.WriteEntry(e)
+	}
+	if n, _ := s.Size(); n != 3 {
+		t.Errorf("%d entries kept, want the 3 that fit", n)
+	}
+	if used := MemoryUsage(); used > base+3*size {
+		t.Errorf("usage %d over the limit %d", used, base+3*size)
+	}
+	if atomic.LoadInt64(&memory.dropped) != dropped {
+		t.Error("entries dropped although the sink could evict")
+	}
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"sync"
+)
+
+// MemorySink keeps the most recent entries in memory, bounded by count
+// and/or approximate size, e.g. to attach recent logs to error reports or
+// support bundles. A zero bound means no bound of that kind.
+type MemorySink struct {
+	MaxEntries int
+	MaxBytes   int
+
+	entries []*Entry
+	bytes   int
+
+	registered sync.Once
+	lock       sync.Mutex
+}
+
+func NewMemorySink(maxEntries, maxBytes int) *MemorySink {
+	return &MemorySink{MaxEntries: maxEntries, MaxBytes: maxBytes}
+}
+
+func (s *MemorySink) ConcurrentSafe() bool {
+	return true
+}
+
+func (s *MemorySink) WriteEntry(e *Entry) error {
+	c := e.Clone()
+	size := entrySize(c)
+
+	s.registered.Do(func() { memory.register(s) })
+	if !memory.reserve(int64(size)) {
+		memory.drop()
+		return nil
+	}
+
+	s.lock.Lock()
+	s.entries = append(s.entries, c)
+	s.bytes += size
+	drop, freed := 0, 0
+	for drop < len(s.entries)-1 &&
+		((s.MaxEntries > 0 && len(s.entries)-drop > s.MaxEntries) || (s.MaxBytes > 0 && s.bytes-freed > s.MaxBytes)) {
+		freed += entrySize(s.entries[drop])
+		drop++
+	}
+	s.evict(drop, freed)
+	s.lock.Unlock()
+
+	return nil
+}
+
+// evict drops the n oldest entries, of the given total size.
+func (s *MemorySink) evict(n, size int) {
+	if n == 0 {
+		return
+	}
+	m := copy(s.entries, s.entries[n:])
+	for i := m; i < len(s.entries); i++ {
+		s.entries[i] = nil
+	}
+	s.entries = s.entries[:m]
+	s.bytes -= size
+	memory.release(int64(size))
+}
+
+func (s *MemorySink) shedMemory(need int64) int64 {
+	if !s.lock.TryLock() {
+		return 0
+	}
+	defer s.lock.Unlock()
+
+	drop, freed := 0, 0
+	for drop < len(s.entries) && int64(freed) < need {
+		freed += entrySize(s.entries[drop])
+		drop++
+	}
+	s.evict(drop, freed)
+	return int64(freed)
+}
+
+// Snapshot returns a copy of the retained entries, oldest first.
+func (s *MemorySink) Snapshot() []Entry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	out := make([]Entry, len(s.entries))
+	for i, e := range s.entries {
+		out[i] = *e.Clone()
+	}
+	return out
+}
+
+// Size returns the number of retained entries and their approximate size.
+func (s *MemorySink) Size() (entries, bytes int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.entries), s.bytes
+}
+
+func (s *MemorySink) Clear() {
+	s.lock.Lock()
+	memory.release(int64(s.bytes))
+	s.entries = nil
+	s.bytes = 0
+	s.lock.Unlock()
+}
+
+// Close clears s and stops accounting for it; it can still be written to.
+func (s *MemorySink) Close() error {
+	s.Clear()
+	memory.unregister(s)
+	s.registered = sync.Once{}
+	return nil
+}
+
+// entrySize estimates the memory held by e.
+func entrySize(e *Entry) int {
+	n := 96 + len(e.Message) + len(e.File) + len(e.Name)
+	for _, f := range e.Fields {
+		n += 32 + len(f.Key)
+		if s, ok := f.Value.(string); ok {
+			n += len(s)
+		}
+	}
+	return n
+}
@@ -0,0 +1,81 @@
+package log
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func messages(entries []Entry) []string {
+	var msgs []string
+	for _, e := range entries {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestMemorySinkMaxEntries(t *testing.T) {
+	s := NewMemorySink(2, 0)
+	defer s.Close()
+	for i := 1; i <= 3; i++ {
+		s.WriteEntry(&Entry{Message: strconv.Itoa(i), Fields: []Field{{"k", i}}})
+	}
+
+	snap := s.Snapshot()
+	if got := messages(snap); !equalNames(got, []string{"2", "3"}) {
+		t.Errorf("entries = %v, want the two newest", got)
+	}
+	snap[0].Fields[0].Value = "changed"
+	if again := s.Snapshot(); again[0].Fields[0].Value != 2 {
+		t.Error("Snapshot shares fields with the sink")
+	}
+
+	s.Clear()
+	if n, size := s.Size(); n != 0 || size != 0 {
+		t.Errorf("size after Clear = %d entries, %d bytes", n, size)
+	}
+}
+
+func TestMemorySinkMaxBytes(t *testing.T) {
+	e := &Entry{Message: "0123456789"}
+	size := entrySize(e)
+	s := NewMemorySink(0, 3*size)
+	defer s.Close()
+	for i := 0; i < 10; i++ {
+		s.WriteEntry(e)
+	}
+	if n, bytes := s.Size(); n != 3 || bytes != 3*size {
+		t.Errorf("size = %d entries, %d bytes; want 3, %d", n, bytes, 3*size)
+	}
+
+	// an entry over the bound on its own is still kept
+	big := &Entry{Message: string(make([]byte, 4*size))}
+	s.WriteEntry(big)
+	if n, _ := s.Size(); n != 1 {
+		t.Errorf("%d entries, want only the big one", n)
+	}
+}
+
+func TestMemorySinkConcurrent(t *testing.T) {
+	s := NewMemorySink(100, 0)
+	defer s.Close()
+	l := NewLogger(io.Discard, "", 0)
+	l.AddSink(s)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				l.Info("entry")
+				s.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	if n, _ := s.Size(); n != 100 {
+		t.Errorf("%d entries, want 100", n)
+	}
+}
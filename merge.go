@@ -0,0 +1,139 @@
+package log
+
+import (
+	"container/heap"
+	"io"
+	"time"
+)
+
+// MergeSource is one input of Merge, e.g. the log of one host.
+type MergeSource struct {
+	// Label is written to the source field of its entries
+	Label string
+	R     io.Reader
+	// Offset corrects a known clock difference: it is added to the
+	// timestamps of the source
+	Offset time.Duration
+}
+
+// Merge interleaves the entries of srcs by timestamp and hands them to
+// sink with a source field set to their label, for reconstructing an
+// incident across hosts. Timestamps within one source may go back by up
+// to skew, e.g. from clock adjustments or buffered writers; entries are
+// only handed on once every source has moved skew past them. Entries
+// without a timestamp follow the entry before them. Lines that cannot be
+// parsed are skipped.
+func Merge(srcs []MergeSource, skew time.Duration, sink Sink) error {
+	m := &merger{}
+	for _, src := range srcs {
+		s := &mergeInput{src: src, d: NewDecoder(src.R)}
+		m.inputs = append(m.inputs, s)
+		err := m.read(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	for len(m.queue) > 0 {
+		top := m.queue[0]
+		if slow := m.slowest(); slow != nil && top.at.After(slow.mark.Add(-skew)) {
+			err := m.read(slow)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		heap.Pop(&m.queue)
+		err := sink.WriteEntry(top.e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type mergeInput struct {
+	src MergeSource
+	d   *Decoder
+	// timestamp of the last entry and latest timestamp read
+	last time.Time
+	mark time.Time
+	done bool
+}
+
+type mergeItem struct {
+	e  *Entry
+	at time.Time
+	// read order, for entries with the same timestamp
+	n int
+}
+
+type merger struct {
+	inputs []*mergeInput
+	queue  mergeQueue
+	n      int
+}
+
+// read adds the next entry of s to the queue.
+func (m *merger) read(s *mergeInput) error {
+	for {
+		e, err := s.d.Decode()
+		if err == io.EOF {
+			s.done = true
+			return nil
+		}
+		if _, ok := err.(*DecodeError); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if !e.Time.IsZero() {
+			e.Time = e.Time.Add(s.src.Offset)
+			s.last = e.Time
+			if s.last.After(s.mark) {
+				s.mark = s.last
+			}
+		}
+		e.Fields = append(e.Fields, Field{"source", s.src.Label})
+
+		m.n++
+		heap.Push(&m.queue, &mergeItem{e, s.last, m.n})
+		return nil
+	}
+}
+
+// slowest returns the unfinished input that read the least far.
+func (m *merger) slowest() *mergeInput {
+	var slow *mergeInput
+	for _, s := range m.inputs {
+		if !s.done && (slow == nil || s.mark.Before(slow.mark)) {
+			slow = s
+		}
+	}
+	return slow
+}
+
+type mergeQueue []*mergeItem
+
+func (q mergeQueue) Len() int { return len(q) }
+
+func (q mergeQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].n < q[j].n
+}
+
+func (q mergeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *mergeQueue) Push(x interface{}) { *q = append(*q, x.(*mergeItem)) }
+
+func (q *mergeQueue) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jsonLog writes the messages of order as JSON entries timed at base plus
+// their offset in entries.
+func jsonLog(base time.Time, entries map[string]time.Duration, order ...string) *bytes.Buffer {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.SetFormat(FORMAT_JSON)
+	for _, msg := range order {
+		l.LogAt(LOG_INFO, base.Add(entries[msg]), msg)
+	}
+	return &b
+}
+
+func TestMerge(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a := jsonLog(base, map[string]time.Duration{"a1": 0, "a2": 3 * time.Second, "a3": 2 * time.Second}, "a1", "a2", "a3")
+	a.WriteString("{not json\n")
+	// b runs a minute ahead
+	b := jsonLog(base, map[string]time.Duration{"b1": time.Minute + time.Second, "b2": time.Minute + 4*time.Second}, "b1", "b2")
+
+	s := &entriesSink{}
+	err := Merge([]MergeSource{{Label: "a", R: a}, {Label: "b", R: b, Offset: -time.Minute}}, 2*time.Second, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range s.entries {
+		var source interface{}
+		for _, f := range e.Fields {
+			if f.Key == "source" {
+				source = f.Value
+			}
+		}
+		if !strings.HasPrefix(strings.TrimSpace(e.Message), source.(string)) {
+			t.Errorf("%q has source %v", e.Message, source)
+		}
+		got = append(got, strings.TrimSpace(e.Message))
+	}
+	// a3 went back by less than the skew
+	if want := "a1 b1 a3 a2 b2"; strings.Join(got, " ") != want {
+		t.Errorf("merged %v, want %s", got, want)
+	}
+	if len(s.entries) > 1 && !s.entries[1].Time.Equal(base.Add(time.Second)) {
+		t.Errorf("b1 at %v, want the offset applied", s.entries[1].Time)
+	}
+}
@@ -0,0 +1,295 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_RESOLVE_INTERVAL = 30 * time.Second
+	DEFAULT_DIAL_TIMEOUT     = 5 * time.Second
+	// how long NetworkSink waits after failing to connect before dialing again
+	DEFAULT_NETWORK_RETRY_INTERVAL = time.Second
+)
+
+// NetworkSink writes entries as text lines to a TCP or UDP collector. The
+// collector host name is resolved again every ResolveInterval and whenever
+// connecting fails, and successive connections rotate among all its A and
+// AAAA records, so a collector fail-over behind DNS is followed without
+// restarting the process. After a failed connection attempt, writes fail
+// with its error without dialing until RetryInterval has passed.
+type NetworkSink struct {
+	Network         string // tcp, tcp4, tcp6, udp, udp4 or udp6
+	Addr            string // host:port
+	ResolveInterval time.Duration
+	DialTimeout     time.Duration
+	RetryInterval   time.Duration
+	Resolver        *net.Resolver
+
+	conn     net.Conn
+	failed   time.Time
+	dialErr  error
+	addrs    []string
+	next     int
+	resolved time.Time
+	closed   bool
+	cancel   func()
+	// a refresh started by the maintenance goroutine is running
+	refreshing bool
+
+	ctx       context.Context
+	cancelCtx context.CancelFunc
+
+	lock sync.Mutex
+}
+
+func NewNetworkSink(network, addr string) *NetworkSink {
+	return newNetworkSink(context.Background(), network, addr)
+}
+
+// NewNetworkSinkContext is NewNetworkSink for a sink that is closed when
+// ctx ends; dials and lookups in progress are aborted.
+func NewNetworkSinkContext(ctx context.Context, network, addr string) *NetworkSink {
+	s := newNetworkSink(ctx, network, addr)
+	closeOnDone(s.ctx, s)
+	return s
+}
+
+func newNetworkSink(ctx context.Context, network, addr string) *NetworkSink {
+	s := &NetworkSink{
+		Network:         network,
+		Addr:            addr,
+		ResolveInterval: DEFAULT_RESOLVE_INTERVAL,
+		DialTimeout:     DEFAULT_DIAL_TIMEOUT,
+		RetryInterval:   DEFAULT_NETWORK_RETRY_INTERVAL,
+	}
+	s.ctx, s.cancelCtx = context.WithCancel(ctx)
+	s.cancel = maintenance.schedule(s.ResolveInterval, s.startRefresh)
+	return s
+}
+
+func (s *NetworkSink) ConcurrentSafe() bool {
+	return true
+}
+
+func (s *NetworkSink) WriteEntry(e *Entry) error {
+	enc := textEncoder{timeFormat: time.RFC3339Nano, flags: Lshortfile}
+
+	buf := getBuffer()
+	buf.b = enc.encode(buf.b, e)
+	_, err := s.Write(buf.b)
+	buf.Free()
+
+	return err
+}
+
+// Write sends p as is, reconnecting once if the connection broke.
+func (s *NetworkSink) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+
+	var err error
+	for try := 0; try < 2; try++ {
+		if s.conn == nil {
+			err = s.connect()
+			if err != nil {
+				return 0, err
+			}
+		}
+		var n int
+		n, err = s.conn.Write(p)
+		if err == nil {
+			return n, nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return 0, err
+}
+
+func (s *NetworkSink) Close() error {
+	// abort a dial holding the lock
+	if s.cancelCtx != nil {
+		s.cancelCtx()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// connect dials the resolved addresses in turn, starting after the one
+// used last time, unless the last attempt failed less than RetryInterval
+// ago.
+func (s *NetworkSink) connect() error {
+	if !s.failed.IsZero() && time.Since(s.failed) < s.RetryInterval {
+		return s.dialErr
+	}
+	err := s.dial()
+	if err != nil {
+		s.failed, s.dialErr = time.Now(), err
+		return err
+	}
+	s.failed, s.dialErr = time.Time{}, nil
+	return nil
+}
+
+func (s *NetworkSink) dial() error {
+	if len(s.addrs) == 0 || time.Since(s.resolved) > s.ResolveInterval {
+		err := s.resolve()
+		if err != nil && len(s.addrs) == 0 {
+			return err
+		}
+	}
+
+	timeout := s.DialTimeout
+	if timeout <= 0 {
+		timeout = DEFAULT_DIAL_TIMEOUT
+	}
+
+	var err error
+	for i := 0; i < len(s.addrs); i++ {
+		j := (s.next + i) % len(s.addrs)
+		d := net.Dialer{Timeout: timeout}
+		var conn net.Conn
+		conn, err = d.DialContext(s.context(), s.Network, s.addrs[j])
+		if err == nil {
+			s.conn = conn
+			s.next = j + 1
+			return nil
+		}
+	}
+
+	// resolve again on the next attempt, the records may have moved
+	s.resolved = time.Time{}
+	return err
+}
+
+func (s *NetworkSink) resolve() error {
+	addrs, err := s.lookup()
+	if err != nil {
+		return err
+	}
+	s.addrs = addrs
+	s.resolved = time.Now()
+	return nil
+}
+
+// lookup returns the addresses of the collector. It only reads the
+// configuration of s and is called without the lock.
+func (s *NetworkSink) lookup() ([]string, error) {
+	host, port, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := s.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	timeout := s.DialTimeout
+	if timeout <= 0 {
+		timeout = DEFAULT_DIAL_TIMEOUT
+	}
+	ctx, cancel := context.WithTimeout(s.context(), timeout)
+	ips, err := resolver.LookupIPAddr(ctx, host)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []string
+	for _, ip := range ips {
+		v4 := ip.IP.To4() != nil
+		if (strings.HasSuffix(s.Network, "4") && !v4) || (strings.HasSuffix(s.Network, "6") && v4) {
+			continue
+		}
+		addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("log: no usable address for " + s.Addr)
+	}
+	return addrs, nil
+}
+
+// startRefresh runs refresh in its own goroutine, so a slow lookup does not
+// hold up the other maintenance tasks. A refresh still running is not
+// started again.
+func (s *NetworkSink) startRefresh() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed || s.refreshing {
+		return
+	}
+	s.refreshing = true
+	go func() {
+		s.refresh()
+		s.lock.Lock()
+		s.refreshing = false
+		s.lock.Unlock()
+	}()
+}
+
+// refresh re-resolves the collector and drops the connection if its address
+// is no longer among the records. The lookup is done without the lock, so
+// writes do not wait for DNS.
+func (s *NetworkSink) refresh() {
+	s.lock.Lock()
+	closed := s.closed
+	s.lock.Unlock()
+	if closed {
+		return
+	}
+	addrs, err := s.lookup()
+	if err != nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.addrs = addrs
+	s.resolved = time.Now()
+	if s.conn == nil {
+		return
+	}
+	remote := s.conn.RemoteAddr().String()
+	for _, a := range s.addrs {
+		if a == remote {
+			return
+		}
+	}
+	s.conn.Close()
+	s.conn = nil
+}
+
+func (s *NetworkSink) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// closedAddr returns a local address nothing listens on.
+func closedAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestNetworkSinkRetryInterval(t *testing.T) {
+	s := NewNetworkSink("tcp", closedAddr(t))
+	defer s.Close()
+	s.RetryInterval = time.Hour
+
+	_, err := s.Write([]byte("a\n"))
+	if err == nil {
+		t.Fatal("write to a closed port succeeded")
+	}
+	_, again := s.Write([]byte("b\n"))
+	if again != err {
+		t.Errorf("second write dialed again: %v", again)
+	}
+
+	s.lock.Lock()
+	s.failed = time.Now().Add(-2 * time.Hour)
+	s.lock.Unlock()
+	_, again = s.Write([]byte("c\n"))
+	if again == nil || again == err {
+		t.Errorf("write after RetryInterval did not dial again: %v", again)
+	}
+}
+
+func TestNetworkSinkRefresh(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	s := NewNetworkSink("tcp", ln.Addr().String())
+	defer s.Close()
+	if _, err := s.Write([]byte("a\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the collector is still among the records: the connection stays
+	s.refresh()
+	if s.conn == nil {
+		t.Fatal("refresh dropped a connection to a current address")
+	}
+	s.lock.Lock()
+	s.Addr = closedAddr(t)
+	s.lock.Unlock()
+	s.refresh()
+	if s.conn != nil {
+		t.Error("refresh kept a connection to an address no longer resolved")
+	}
+}
+
+func TestNetworkSinkRefreshInBackground(t *testing.T) {
+	release := make(chan struct{})
+	s := NewNetworkSink("tcp", "collector.invalid:514")
+	defer s.Close()
+	s.Resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			<-release
+			return nil, errors.New("no DNS")
+		},
+	}
+
+	within(t, "startRefresh", s.startRefresh)
+	s.lock.Lock()
+	running := s.refreshing
+	s.lock.Unlock()
+	if !running {
+		t.Fatal("no refresh running")
+	}
+	// a second one while the lookup hangs does not start another
+	within(t, "startRefresh", s.startRefresh)
+
+	close(release)
+	within(t, "refresh", func() {
+		for {
+			s.lock.Lock()
+			running := s.refreshing
+			s.lock.Unlock()
+			if !running {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
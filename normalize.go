@@ -0,0 +1,110 @@
+package log
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// how SetNormalizeKeys rewrites field keys
+const (
+	NORMALIZE_NONE  = ""
+	NORMALIZE_LOWER = "lower"
+	NORMALIZE_SNAKE = "snake"
+)
+
+// SetNormalizeKeys rewrites the keys of fields before they are encoded, so
+// call sites writing userID, UserId and user-id end up in one column:
+// NORMALIZE_LOWER lowercases them, NORMALIZE_SNAKE turns them into
+// snake_case (user_id). Keys that collide afterwards get a suffix: user_id,
+// user_id_2, ... Field limits apply to the normalized keys.
+func (l *Logger) SetNormalizeKeys(mode string) error {
+	mode = strings.ToLower(mode)
+	switch mode {
+	case NORMALIZE_NONE, NORMALIZE_LOWER, NORMALIZE_SNAKE:
+	default:
+		return errors.New("unknown NormalizeKeys: " + mode)
+	}
+
+	r := l.root()
+	r.lock.Lock()
+	r.NormalizeKeys = mode
+	r.lock.Unlock()
+	return nil
+}
+
+func normalizeFields(mode string, e *Entry) {
+	if mode == NORMALIZE_NONE || len(e.Fields) == 0 {
+		return
+	}
+	copied := false
+	for i, f := range e.Fields {
+		key := normalizeKey(mode, f.Key)
+		key = uniqueKey(e.Fields[:i], key)
+		if key == f.Key {
+			continue
+		}
+		// the slice may belong to the caller
+		if !copied {
+			e.Fields = append([]Field(nil), e.Fields...)
+			copied = true
+		}
+		e.Fields[i].Key = key
+	}
+}
+
+func normalizeKey(mode, key string) string {
+	if mode == NORMALIZE_LOWER {
+		return strings.ToLower(key)
+	}
+
+	var b strings.Builder
+	prev, last := rune(0), rune(0)
+	for i, c := range key {
+		r := c
+		switch {
+		case c == '-' || c == ' ':
+			r = '_'
+		case unicode.IsUpper(c):
+			next, _ := utf8.DecodeRuneInString(key[i+utf8.RuneLen(c):])
+			// userID -> user_id, HTTPStatus -> http_status
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && unicode.IsLower(next) {
+				if last != '_' {
+					b.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(c)
+		}
+		prev = c
+		if r == '_' && last == '_' {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+// uniqueKey returns key, with a suffix if one of fields already has it.
+func uniqueKey(fields []Field, key string) string {
+	if !hasKey(fields, key) {
+		return key
+	}
+	for n := 2; ; n++ {
+		k := key + "_" + strconv.Itoa(n)
+		if !hasKey(fields, k) {
+			return k
+		}
+	}
+}
+
+func hasKey(fields []Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
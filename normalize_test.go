@@ -0,0 +1,55 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	for _, tt := range []struct {
+		in, lower, snake string
+	}{
+		{"userID", "userid", "user_id"},
+		{"UserId", "userid", "user_id"},
+		{"user-id", "user-id", "user_id"},
+		{"HTTPStatus", "httpstatus", "http_status"},
+		{"retry count", "retry count", "retry_count"},
+		{"Already_Snake", "already_snake", "already_snake"},
+		{"ip4Addr", "ip4addr", "ip4_addr"},
+		{"ÄrgerCount", "ärgercount", "ärger_count"},
+	} {
+		if got := normalizeKey(NORMALIZE_LOWER, tt.in); got != tt.lower {
+			t.Errorf("lower %q = %q, want %q", tt.in, got, tt.lower)
+		}
+		if got := normalizeKey(NORMALIZE_SNAKE, tt.in); got != tt.snake {
+			t.Errorf("snake %q = %q, want %q", tt.in, got, tt.snake)
+		}
+	}
+}
+
+func TestSetNormalizeKeys(t *testing.T) {
+	l, b := jsonLogger()
+	if err := l.SetNormalizeKeys("camel"); err == nil {
+		t.Error("SetNormalizeKeys accepted an unknown mode")
+	}
+	if err := l.Named("api").SetNormalizeKeys("Snake"); err != nil {
+		t.Fatal(err)
+	}
+	fields := []Field{{"userID", 1}, {"user-id", 2}, {"UserId", 3}, {"requestPath", "/"}}
+	l.LogFields(LOG_INFO, "done", fields...)
+
+	got := decodeLines(t, b)
+	if len(got) != 1 {
+		t.Fatalf("entries = %v", got)
+	}
+	for k, v := range map[string]float64{"user_id": 1, "user_id_2": 2, "user_id_3": 3} {
+		if got[0][k] != v {
+			t.Errorf("%s = %v, want %v in %v", k, got[0][k], v, got[0])
+		}
+	}
+	if got[0]["request_path"] != "/" {
+		t.Errorf("request_path missing in %v", got[0])
+	}
+	if fields[0].Key != "userID" {
+		t.Error("the fields of the caller were renamed")
+	}
+}
@@ -0,0 +1,85 @@
+package log
+
+import (
+	"context"
+	"io"
+)
+
+// Option configures a Logger built by NewWithContext.
+type Option func(*Logger) error
+
+func WithOutput(w io.Writer) Option {
+	return func(l *Logger) error {
+		l.SetOutput(w)
+		return nil
+	}
+}
+
+// WithFile writes to a rotating file, as SetOutputByName does. Options
+// changing the rotation (WithRotate) must come before it.
+func WithFile(path string) Option {
+	return func(l *Logger) error {
+		return l.openFile(path)
+	}
+}
+
+// WithRotate sets the time format naming rotated files, e.g. FORMAT_TIME_HOUR.
+func WithRotate(format string) Option {
+	return func(l *Logger) error {
+		err := validateRotateFormat(format)
+		if err != nil {
+			return err
+		}
+		l.SetRotateByTimeFormat(format)
+		return nil
+	}
+}
+
+func WithLevel(level LogLevel) Option {
+	return func(l *Logger) error {
+		l.SetLevel(level)
+		return nil
+	}
+}
+
+func WithFlags(flags int) Option {
+	return func(l *Logger) error {
+		l._log.SetFlags(flags)
+		return nil
+	}
+}
+
+func WithPrefix(prefix string) Option {
+	return func(l *Logger) error {
+		l._log.SetPrefix(prefix)
+		return nil
+	}
+}
+
+func WithSink(s Sink, opts ...SinkOption) Option {
+	return func(l *Logger) error {
+		l.AddSink(s, opts...)
+		return nil
+	}
+}
+
+// NewWithContext builds a logger from opts, writing to stderr unless an
+// option says otherwise. When ctx ends the logger is closed, and with it its
+// file, its scheduled work and the sinks that are io.Closers. ctx only bounds
+// the life of the logger: sinks and writers are not given it. Closing the
+// logger more than once, or both closing it and ending ctx, closes it once.
+func NewWithContext(ctx context.Context, opts ...Option) (*Logger, error) {
+	l := New()
+	for _, opt := range opts {
+		err := opt(l)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+
+	l.ctx, l.cancel = context.WithCancel(ctx)
+	closeOnDone(l.ctx, l)
+
+	return l, nil
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type closeSink struct {
+	closed int32
+}
+
+func (s *closeSink) WriteEntry(e *Entry) error { return nil }
+
+func (s *closeSink) Close() error {
+	atomic.AddInt32(&s.closed, 1)
+	return nil
+}
+
+func TestNewWithContextClosesOnce(t *testing.T) {
+	for _, name := range []string{"Close", "ctx", "both"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		s := &closeSink{}
+		l, err := NewWithContext(ctx, WithSink(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch name {
+		case "Close":
+			l.Close()
+		case "ctx":
+			cancel()
+		case "both":
+			go cancel()
+			l.Close()
+		}
+		l.Close()
+		cancel()
+
+		deadline := time.Now().Add(time.Second)
+		for atomic.LoadInt32(&s.closed) == 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		// give a second Close the time to happen
+		time.Sleep(10 * time.Millisecond)
+		if n := atomic.LoadInt32(&s.closed); n != 1 {
+			t.Errorf("%s: sink closed %d times, want 1", name, n)
+		}
+	}
+}
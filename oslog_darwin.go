@@ -0,0 +1,66 @@
+//go:build darwin && cgo
+
+package log
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+static void log_with_type(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// OSLogSink sends entries to the macOS unified logging system, so they show
+// up in Console.app and `log stream` under its subsystem and category.
+type OSLogSink struct {
+	log C.os_log_t
+}
+
+// NewOSLogSink creates a sink for subsystem (usually a reverse DNS name such
+// as com.example.agent) and category. The underlying os_log object lives
+// for the rest of the process.
+func NewOSLogSink(subsystem, category string) *OSLogSink {
+	cs := C.CString(subsystem)
+	defer C.free(unsafe.Pointer(cs))
+	cc := C.CString(category)
+	defer C.free(unsafe.Pointer(cc))
+
+	return &OSLogSink{log: C.os_log_create(cs, cc)}
+}
+
+// ConcurrentSafe reports true: os_log may be called from any thread.
+func (s *OSLogSink) ConcurrentSafe() bool {
+	return true
+}
+
+func (s *OSLogSink) WriteEntry(e *Entry) error {
+	buf := getBuffer()
+	buf.b = append(buf.b, e.Message...)
+	buf.b = appendFields(buf.b, e.Fields)
+	buf.b = append(buf.b, 0)
+
+	C.log_with_type(s.log, osLogType(e.Level), (*C.char)(unsafe.Pointer(&buf.b[0])))
+
+	buf.Free()
+	return nil
+}
+
+func osLogType(t LogType) C.os_log_type_t {
+	switch t {
+	case LOG_FATAL:
+		return C.OS_LOG_TYPE_FAULT
+	case LOG_ERROR:
+		return C.OS_LOG_TYPE_ERROR
+	case LOG_INFO:
+		return C.OS_LOG_TYPE_INFO
+	case LOG_DEBUG:
+		return C.OS_LOG_TYPE_DEBUG
+	}
+	return C.OS_LOG_TYPE_DEFAULT
+}
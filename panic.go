@@ -0,0 +1,34 @@
+package log
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// PanicFields renders a recovered panic value as fields: panic_type is its
+// Go type, panic_value its message (Error for errors, the string itself,
+// String for fmt.Stringers, %v otherwise). Panics raised by the runtime,
+// such as nil dereferences or out of range indexes, also carry
+// panic_runtime=true.
+func PanicFields(p interface{}) []Field {
+	fields := []Field{
+		{"panic_type", fmt.Sprintf("%T", p)},
+		{"panic_value", panicValue(p)},
+	}
+	if _, ok := p.(runtime.Error); ok {
+		fields = append(fields, Field{"panic_runtime", true})
+	}
+	return fields
+}
+
+func panicValue(p interface{}) string {
+	switch v := p.(type) {
+	case error:
+		return errorString(v)
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", p)
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"errors"
+	"testing"
+)
+
+type panicStringer struct{}
+
+func (panicStringer) String() string { return "stringer" }
+
+func recovered(fn func()) (p interface{}) {
+	defer func() { p = recover() }()
+	fn()
+	return nil
+}
+
+func TestPanicFields(t *testing.T) {
+	var m map[string]int
+	for _, tt := range []struct {
+		p          interface{}
+		typ, value string
+		runtime    bool
+	}{
+		{"boom", "string", "boom", false},
+		{errors.New("failed"), "*errors.errorString", "failed", false},
+		{panicStringer{}, "log.panicStringer", "stringer", false},
+		{42, "int", "42", false},
+		// the type of runtime panics varies between Go versions
+		{recovered(func() { m["x"] = 1 }), "", "assignment to entry in nil map", true},
+	} {
+		f := PanicFields(tt.p)
+		if tt.typ != "" && f[0] != F("panic_type", tt.typ) || f[1] != F("panic_value", tt.value) {
+			t.Errorf("%v: fields %v", tt.p, f)
+		}
+		if got := len(f) == 3 && f[2] == F("panic_runtime", true); got != tt.runtime {
+			t.Errorf("%v: panic_runtime %v, want %v", tt.p, got, tt.runtime)
+		}
+	}
+}
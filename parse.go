@@ -0,0 +1,292 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errNoLevel = errors.New("log: no level tag found")
+
+// Parse reads back one line written by this package, in the text layout
+// or as JSON. In text lines, trailing key=value pairs are taken as fields
+// and their values are strings; a message that itself ends in key=value
+// pairs is indistinguishable from fields. Lines written with custom level
+// names or EntryTimeFormat need a Parser.
+func Parse(line string) (*Entry, error) {
+	return defaultParser.Parse(line)
+}
+
+// Parser reads back lines written with custom level names or entry time
+// format. The zero Parser reads the default layout, as Parse does.
+type Parser struct {
+	// LevelNames are the tags written instead of the default ones, see
+	// SetLevelNames
+	LevelNames map[LogType]string
+	// TimeFormat is the EntryTimeFormat the lines were written with
+	TimeFormat string
+}
+
+var defaultParser Parser
+
+// Parser returns a Parser for the lines l writes.
+func (l *Logger) Parser() *Parser {
+	r := l.root()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return &Parser{LevelNames: r.levelNames, TimeFormat: r.EntryTimeFormat}
+}
+
+// NewDecoder returns a Decoder reading the lines of r with p.
+func (p *Parser) NewDecoder(r io.Reader) *Decoder {
+	d := NewDecoder(r)
+	d.parser = p
+	return d
+}
+
+func (p *Parser) Parse(line string) (*Entry, error) {
+	s := strings.TrimSpace(line)
+	if strings.HasPrefix(s, "{") {
+		return parseJSON([]byte(s))
+	}
+	return p.parseText(line)
+}
+
+func (p *Parser) levelName(t LogType) string {
+	if name, ok := p.LevelNames[t]; ok {
+		return name
+	}
+	return LogTypeToString(t)
+}
+
+// parseText reads back a line written in the package's text layout:
+// [prefix] [date] [time] [file:line: ] [level] [[replayed] ][[seq N] ][[name] ]message[ key=value...].
+func (p *Parser) parseText(line string) (*Entry, error) {
+	e, body, err := p.parseTextHead(strings.TrimSuffix(line, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	splitBody(e, body)
+	return e, nil
+}
+
+// parseTextHead parses everything up to the message and returns the rest.
+func (p *Parser) parseTextHead(line string) (*Entry, string, error) {
+	e := &Entry{}
+	at, tagLen := -1, 0
+	for _, t := range []LogType{LOG_FATAL, LOG_ERROR, LOG_WARNING, LOG_INFO, LOG_DEBUG} {
+		tag := "[" + p.levelName(t) + "] "
+		i := strings.Index(line, tag)
+		if i >= 0 && (at < 0 || i < at) {
+			at, tagLen = i, len(tag)
+			e.Level = t
+		}
+	}
+	if at < 0 {
+		return nil, "", errNoLevel
+	}
+
+	header := line[:at]
+	body := line[at+tagLen:]
+	if strings.HasPrefix(body, "[replayed] ") {
+		e.Replayed = true
+		body = body[len("[replayed] "):]
+	}
+	if strings.HasPrefix(body, "[seq ") {
+		if i := strings.Index(body, "] "); i > 0 {
+			if seq, err := strconv.ParseUint(body[len("[seq "):i], 10, 64); err == nil {
+				e.Seq = seq
+				body = body[i+2:]
+			}
+		}
+	}
+	if strings.HasPrefix(body, "[") {
+		if i := strings.Index(body, "] "); i > 1 && !strings.ContainsAny(body[1:i], " []") {
+			e.Name = body[1:i]
+			body = body[i+2:]
+		}
+	}
+
+	tokens := strings.Fields(header)
+	if p.TimeFormat != "" {
+		// the time takes as many tokens as its layout
+		n := len(strings.Fields(p.TimeFormat))
+		for i := 0; i+n <= len(tokens); i++ {
+			t, err := time.ParseInLocation(p.TimeFormat, strings.Join(tokens[i:i+n], " "), time.Local)
+			if err == nil {
+				e.Time = t
+				tokens = append(tokens[:i:i], tokens[i+n:]...)
+				break
+			}
+		}
+	}
+
+	var date, clock string
+	for _, tok := range tokens {
+		switch {
+		case p.TimeFormat == "" && isDate(tok):
+			date = tok
+		case p.TimeFormat == "" && isClock(tok):
+			clock = tok
+		case strings.HasSuffix(tok, ":"):
+			tok = tok[:len(tok)-1]
+			if i := strings.LastIndexByte(tok, ':'); i > 0 {
+				if n, err := strconv.Atoi(tok[i+1:]); err == nil {
+					e.File, e.Line = tok[:i], n
+				}
+			}
+		}
+	}
+	if p.TimeFormat == "" {
+		e.Time = parseHeaderTime(date, clock)
+	}
+
+	return e, body, nil
+}
+
+// splitBody sets the message and the fields of e from body, taking the
+// longest run of key=value pairs at its end as fields.
+func splitBody(e *Entry, body string) {
+	e.Message = body
+	for i := 0; i < len(body); i++ {
+		if body[i] != ' ' {
+			continue
+		}
+		fields, ok := parseFields(body[i+1:])
+		if !ok {
+			continue
+		}
+		e.Message = body[:i]
+		e.Fields = fields
+		return
+	}
+}
+
+// parseFields parses s as space separated key=value pairs written by
+// appendFields. It fails unless all of s is consumed.
+func parseFields(s string) ([]Field, bool) {
+	var fields []Field
+	for len(s) > 0 {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || strings.IndexByte(s[:eq], ' ') >= 0 {
+			return nil, false
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, "\"") {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, false
+			}
+			value, _ = strconv.Unquote(q)
+			s = s[len(q):]
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			if needsQuote(value) {
+				return nil, false
+			}
+			s = s[end:]
+		}
+		fields = append(fields, Field{key, value})
+
+		if len(s) > 0 {
+			if s[0] != ' ' || len(s) == 1 {
+				return nil, false
+			}
+			s = s[1:]
+		}
+	}
+	return fields, len(fields) > 0
+}
+
+func isDate(s string) bool {
+	return len(s) == 10 && s[4] == '/' && s[7] == '/'
+}
+
+func isClock(s string) bool {
+	return len(s) >= 8 && s[2] == ':' && s[5] == ':'
+}
+
+func parseHeaderTime(date, clock string) time.Time {
+	if date == "" && clock == "" {
+		return time.Time{}
+	}
+	if date == "" {
+		date = time.Now().Format("2006/01/02")
+	}
+	if clock == "" {
+		clock = "00:00:00"
+	}
+	t, err := time.ParseInLocation("2006/01/02 15:04:05", date+" "+clock, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// parseJSON reads back a line written by encodeJSON. Keys other than the
+// reserved ones become fields, in key order.
+func parseJSON(line []byte) (*Entry, error) {
+	var m map[string]json.RawMessage
+	err := json.Unmarshal(line, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Entry{}
+	var level, caller string
+	for key, raw := range m {
+		var err error
+		switch key {
+		case "timestamp":
+			err = json.Unmarshal(raw, &e.Time)
+		case "level":
+			err = json.Unmarshal(raw, &level)
+		case "caller":
+			err = json.Unmarshal(raw, &caller)
+		case "logger":
+			err = json.Unmarshal(raw, &e.Name)
+		case "message":
+			err = json.Unmarshal(raw, &e.Message)
+		case "replayed":
+			err = json.Unmarshal(raw, &e.Replayed)
+		case "seq":
+			err = json.Unmarshal(raw, &e.Seq)
+		default:
+			// keep numbers as written, large integers included
+			var v interface{}
+			d := json.NewDecoder(bytes.NewReader(raw))
+			d.UseNumber()
+			err = d.Decode(&v)
+			e.Fields = append(e.Fields, Field{strings.TrimPrefix(key, "fields."), v})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e.Level = StringToLogType(level)
+	if e.Level == 0 {
+		return nil, errNoLevel
+	}
+	if i := strings.LastIndexByte(caller, ':'); i > 0 {
+		if n, err := strconv.Atoi(caller[i+1:]); err == nil {
+			e.File, e.Line = caller[:i], n
+		}
+	}
+	sort.Slice(e.Fields, func(i, j int) bool { return e.Fields[i].Key < e.Fields[j].Key })
+
+	return e, nil
+}
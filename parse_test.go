@@ -0,0 +1,120 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func roundTripEntries() []*Entry {
+	at := time.Date(2024, 3, 9, 14, 5, 7, 0, time.Local)
+	return []*Entry{
+		{Level: LOG_INFO, Time: at, Message: "plain", File: "a.go", Line: 1},
+		{Level: LOG_WARNING, Time: at, Message: "with fields", File: "b.go", Line: 2,
+			Fields: []Field{{"path", "/x y"}, {"user", "bob"}}},
+		{Level: LOG_ERROR, Time: at, Message: "named", Name: "db.pool", File: "c.go", Line: 3},
+		{Level: LOG_DEBUG, Time: at, Message: "replayed", Replayed: true, File: "d.go", Line: 4},
+		{Level: LOG_INFO, Time: at, Message: "spooled", Seq: 42, Name: "net", File: "e.go", Line: 5,
+			Fields: []Field{{"k", "v"}}},
+		// a user field named seq stays a field
+		{Level: LOG_INFO, Time: at, Message: "user seq", File: "f.go", Line: 6,
+			Fields: []Field{{"seq", "7"}}},
+		{Level: LOG_INFO, Time: at, Message: "both seqs", Seq: 9, File: "g.go", Line: 7,
+			Fields: []Field{{"seq", "7"}}},
+		{Level: LOG_ERROR, Time: at, Message: "multi\nline\n\tmessage", File: "h.go", Line: 8,
+			Fields: []Field{{"k", "v"}}},
+	}
+}
+
+// normalize makes entries read back from JSON comparable with written ones.
+func normalize(e *Entry) string {
+	c := e.Clone()
+	for i, f := range c.Fields {
+		if n, ok := f.Value.(json.Number); ok {
+			c.Fields[i].Value = n.String()
+		}
+	}
+	return fmt.Sprintf("%v %v %q %v %q %d %v %s:%d", c.Level, c.Time.Unix(), c.Message, c.Fields, c.Name, c.Seq, c.Replayed, c.File, c.Line)
+}
+
+func writeEntries(t *testing.T, l *Logger, out *bytes.Buffer) []*Entry {
+	entries := roundTripEntries()
+	for _, e := range entries {
+		l.Emit(e)
+	}
+	if out.Len() == 0 {
+		t.Fatal("nothing written")
+	}
+	return entries
+}
+
+func decodeAll(t *testing.T, d *Decoder) []*Entry {
+	var got []*Entry
+	for {
+		e, err := d.Decode()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+}
+
+func checkRoundTrip(t *testing.T, want, got []*Entry) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("read %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if a, b := normalize(got[i]), normalize(want[i]); a != b {
+			t.Errorf("entry %d:\n got %s\nwant %s", i, a, b)
+		}
+	}
+}
+
+func TestRoundTripText(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(&out, "", Ldate|Ltime|Lshortfile)
+	want := writeEntries(t, l, &out)
+	checkRoundTrip(t, want, decodeAll(t, NewDecoder(&out)))
+}
+
+func TestRoundTripJSON(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(&out, "", Ldate|Ltime|Lshortfile)
+	if err := l.SetFormat(FORMAT_JSON); err != nil {
+		t.Fatal(err)
+	}
+	want := writeEntries(t, l, &out)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	var got []*Entry
+	for _, line := range lines {
+		e, err := Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+	checkRoundTrip(t, want, got)
+}
+
+func TestParseCustomLevelNamesAndTimeFormat(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(&out, "", Ldate|Ltime|Lshortfile)
+	l.SetLevelNames(map[LogType]string{LOG_ERROR: "ERR", LOG_WARNING: "WRN", LOG_INFO: "INF", LOG_DEBUG: "DBG"})
+	l.EntryTimeFormat = "Jan 02 2006 15:04:05.000"
+	want := writeEntries(t, l, &out)
+
+	checkRoundTrip(t, want, decodeAll(t, l.Parser().NewDecoder(&out)))
+
+	if _, err := Parse("2024/03/09 14:05:07 a.go:1: [INF] m"); err != errNoLevel {
+		t.Errorf("Parse without the level names: %v, want %v", err, errNoLevel)
+	}
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Built-in stages of the pipeline every entry goes through, in order:
+//
+//	start      nothing done yet
+//	fields     fields of With, severity, build, container, dynamic and
+//	           stack fields
+//	hooks      AddHook, in the order they were added
+//	filters    AddFilter, in the order they were added
+//	redaction  SetNormalizeKeys and SetFieldLimit applied
+//	sampling   SetSampling and SetRateLimit
+//	cooldown   EscalateWarnings, SetCooldown, SetEveryN and SetDedup
+//	encode     encoded for the output
+//	sinks      written to the output and the sinks
+//
+// Fields to keep from some sinks only are up to their AllowFields and
+// DenyFields options.
+const (
+	STAGE_START     = "start"
+	STAGE_FIELDS    = "fields"
+	STAGE_HOOKS     = "hooks"
+	STAGE_FILTERS   = "filters"
+	STAGE_REDACTION = "redaction"
+	STAGE_SAMPLING  = "sampling"
+	STAGE_COOLDOWN  = "cooldown"
+	STAGE_ENCODE    = "encode"
+	STAGE_SINKS     = "sinks"
+)
+
+var stageOrder = []string{STAGE_START, STAGE_FIELDS, STAGE_HOOKS, STAGE_FILTERS, STAGE_REDACTION, STAGE_SAMPLING, STAGE_COOLDOWN, STAGE_ENCODE, STAGE_SINKS}
+
+// AddStage runs fn right after the built-in stage named after, following
+// the stages added there before; entries for which fn returns false go no
+// further, neither to the output nor to the sinks. Changes made after
+// STAGE_ENCODE only reach the sinks, stages after STAGE_SINKS only observe. Stages are isolated like hooks and listed
+// by HookStats.
+func (l *Logger) AddStage(name, after string, fn func(e *Entry) bool) error {
+	known := false
+	for _, s := range stageOrder {
+		known = known || s == after
+	}
+	if !known {
+		return errors.New("unknown pipeline stage: " + after)
+	}
+
+	r := l.root()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	stages := make(map[string][]*hook, len(r.stages)+1)
+	for k, v := range r.stages {
+		stages[k] = v
+	}
+	hooks := stages[after]
+	stages[after] = append(hooks[:len(hooks):len(hooks)], &hook{name: name, fn: fn})
+	r.stages = stages
+	return nil
+}
+
+// Pipeline lists the built-in and added stages in the order entries go
+// through them.
+func (l *Logger) Pipeline() []string {
+	r := l.root()
+	r.lock.Lock()
+	stages := r.stages
+	r.lock.Unlock()
+
+	var names []string
+	for _, s := range stageOrder {
+		names = append(names, s)
+		for _, h := range stages[s] {
+			names = append(names, h.name)
+		}
+	}
+	return names
+}
+
+func (l *Logger) stageStats() []HookStat {
+	l.lock.Lock()
+	stages := l.stages
+	l.lock.Unlock()
+
+	var stats []HookStat
+	for _, s := range stageOrder {
+		for _, h := range stages[s] {
+			stats = append(stats, HookStat{h.name, atomic.LoadInt64(&h.panics), atomic.LoadInt32(&h.disabled) != 0})
+		}
+	}
+	return stats
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// entrySink records the messages of the entries it gets.
+type entrySink struct {
+	msgs []string
+}
+
+func (s *entrySink) WriteEntry(e *Entry) error {
+	s.msgs = append(s.msgs, e.Message)
+	return nil
+}
+
+func TestPipelineOrder(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(&out, "", 0)
+	var seen []string
+	for _, stage := range stageOrder {
+		stage := stage
+		l.AddStage("after-"+stage, stage, func(e *Entry) bool {
+			seen = append(seen, stage)
+			return true
+		})
+	}
+	l.With("global", 1).Info("m")
+
+	if got := strings.Join(seen, " "); got != strings.Join(stageOrder, " ") {
+		t.Errorf("stages ran as %q, want %q", got, strings.Join(stageOrder, " "))
+	}
+	if got, want := l.Pipeline()[:3], []string{STAGE_START, "after-start", STAGE_FIELDS}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Pipeline() starts with %v, want %v", got, want)
+	}
+}
+
+func TestPipelineHooksBeforeFiltersAndSampling(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(&out, "", 0)
+	var filtered []interface{}
+	// added first, but filters run after the hooks
+	l.AddFilter("filter", func(e *Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == "hooked" {
+				filtered = append(filtered, f.Value)
+			}
+		}
+		return true
+	})
+	hooked := 0
+	l.AddHook("hook", func(e *Entry) {
+		for _, f := range e.Fields {
+			if f.Key == "global" {
+				hooked++
+				e.Fields = append(e.Fields, Field{"hooked", true})
+				return
+			}
+		}
+	})
+	l.SetSampling(map[LogType]float64{LOG_INFO: 0}, "")
+
+	l.With("global", 1).Info("dropped by sampling")
+	if hooked != 1 {
+		t.Errorf("hook saw the global field %d times, want 1", hooked)
+	}
+	if len(filtered) != 1 {
+		t.Errorf("filter saw the hook field %d times, want 1", len(filtered))
+	}
+	if out.Len() != 0 {
+		t.Errorf("sampled entry written: %q", out.String())
+	}
+}
+
+func TestPipelineEncodeStageDrops(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(&out, "", 0)
+	s := &entrySink{}
+	l.AddSink(s)
+	l.AddStage("drop", STAGE_ENCODE, func(e *Entry) bool {
+		return strings.TrimSpace(e.Message) != "drop"
+	})
+
+	l.Info("drop")
+	l.Info("keep")
+	if strings.Contains(out.String(), "drop") {
+		t.Errorf("output has the dropped entry: %q", out.String())
+	}
+	if len(s.msgs) != 1 || strings.TrimSpace(s.msgs[0]) != "keep" {
+		t.Errorf("sink got %q, want [keep]", s.msgs)
+	}
+}
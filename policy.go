@@ -0,0 +1,85 @@
+package log
+
+import (
+	"time"
+)
+
+// RotateState describes the current file of a RotatingWriter to its policy.
+type RotateState struct {
+	// Suffix is the time suffix of the current file, NowSuffix the one for
+	// the current time
+	Suffix    string
+	NowSuffix string
+	Size      int64
+	Opened    time.Time
+	Now       time.Time
+}
+
+// RotatePolicy decides when a RotatingWriter moves to a new file. It is
+// asked before every write of n bytes and on idle checks with n == 0. The
+// new file is named by NowSuffix, with the next free index if that is the
+// current suffix.
+type RotatePolicy interface {
+	ShouldRotate(s RotateState, n int) bool
+}
+
+// RotatePolicyFunc adapts a function to RotatePolicy.
+type RotatePolicyFunc func(s RotateState, n int) bool
+
+func (f RotatePolicyFunc) ShouldRotate(s RotateState, n int) bool {
+	return f(s, n)
+}
+
+// RotateByTime rotates when the time suffix changes, e.g. every day with
+// FORMAT_TIME_DAY.
+func RotateByTime() RotatePolicy {
+	return RotatePolicyFunc(func(s RotateState, n int) bool {
+		return s.NowSuffix != s.Suffix
+	})
+}
+
+// RotateBySize rotates before a write would take a non-empty file past
+// max bytes. max <= 0 never rotates.
+func RotateBySize(max int64) RotatePolicy {
+	return RotatePolicyFunc(func(s RotateState, n int) bool {
+		return max > 0 && s.Size > 0 && s.Size+int64(n) > max
+	})
+}
+
+// RotateByAge rotates files open for longer than d.
+func RotateByAge(d time.Duration) RotatePolicy {
+	return RotatePolicyFunc(func(s RotateState, n int) bool {
+		return d > 0 && s.Now.Sub(s.Opened) >= d
+	})
+}
+
+// RotateEvery rotates when a multiple of d since the Unix epoch passed
+// since the file was opened, so instances using the same d rotate
+// together.
+func RotateEvery(d time.Duration) RotatePolicy {
+	return RotatePolicyFunc(func(s RotateState, n int) bool {
+		return d > 0 && !periodStart(s.Now, d).Equal(periodStart(s.Opened, d))
+	})
+}
+
+// periodStart returns the start of the period of length d t is in.
+func periodStart(t time.Time, d time.Duration) time.Time {
+	ns := t.UnixNano()
+	start := ns - ns%int64(d)
+	if ns < 0 && start != ns {
+		start -= int64(d)
+	}
+	return time.Unix(0, start).In(t.Location())
+}
+
+// RotateAny rotates as soon as one of policies says so.
+func RotateAny(policies ...RotatePolicy) RotatePolicy {
+	return RotatePolicyFunc(func(s RotateState, n int) bool {
+		for _, p := range policies {
+			if p.ShouldRotate(s, n) {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRotatePolicies(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	s := RotateState{Suffix: "20240501", NowSuffix: "20240501", Size: 90, Opened: now.Add(-20 * time.Minute), Now: now}
+	next := s
+	next.NowSuffix = "20240502"
+
+	tests := []struct {
+		name string
+		p    RotatePolicy
+		s    RotateState
+		n    int
+		want bool
+	}{
+		{"time same suffix", RotateByTime(), s, 0, false},
+		{"time new suffix", RotateByTime(), next, 0, true},
+		{"size fits", RotateBySize(100), s, 10, false},
+		{"size over", RotateBySize(100), s, 11, true},
+		{"size empty file", RotateBySize(10), RotateState{}, 50, false},
+		{"size disabled", RotateBySize(0), s, 1000, false},
+		{"age young", RotateByAge(time.Hour), s, 0, false},
+		{"age old", RotateByAge(10 * time.Minute), s, 0, true},
+		{"every same period", RotateEvery(time.Hour), s, 0, false},
+		{"every new period", RotateEvery(15 * time.Minute), s, 0, true},
+		{"any none", RotateAny(RotateByTime(), RotateBySize(100)), s, 10, false},
+		{"any size", RotateAny(RotateByTime(), RotateBySize(100)), s, 11, true},
+		{"any time", RotateAny(RotateByTime(), RotateBySize(100)), next, 0, true},
+	}
+	for _, tt := range tests {
+		if got := tt.p.ShouldRotate(tt.s, tt.n); got != tt.want {
+			t.Errorf("%s: ShouldRotate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRotatePolicySizeAndTime(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, func(w *RotatingWriter) {
+		w.Policy = RotateAny(RotateByTime(), RotateBySize(8))
+	})
+	w.Write([]byte("12345\n"))
+	w.Write([]byte("678\n"))
+	w.Write([]byte("9\n"))
+	checkNames(t, fs, today(), today("1"))
+	if got := fs.read("/logs/" + today("1")); got != "678\n9\n" {
+		t.Errorf("second file = %q", got)
+	}
+}
+
+func TestChildSetRotatePolicy(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, nil)
+	l := NewLogger(w, "", 0)
+	l.SetOutput(w)
+	l.Named("cmd").SetRotatePolicy(RotateBySize(8))
+
+	l.Info("12345")
+	l.Info("678")
+	checkNames(t, fs, today(), today("1"))
+	if l.rotatePolicy == nil {
+		t.Error("policy set on a child does not reach the root")
+	}
+}
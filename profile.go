@@ -0,0 +1,42 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// environment variable selecting the profile of an Init config, overriding
+// its "active" key
+const PROFILE_ENV = "LOG_PROFILE"
+
+type profileConfig struct {
+	Profiles map[string]json.RawMessage `json:"profiles"`
+	Active   string                     `json:"active"`
+}
+
+// applyProfile overlays the selected profile of a config such as
+//
+//	{"FileName": "app", "profiles": {"dev": {...}, "prod": {...}}, "active": "prod"}
+//
+// on l. Keys outside "profiles" apply to every profile.
+func (l *Logger) applyProfile(jsonConfig []byte) error {
+	var pc profileConfig
+	err := json.Unmarshal(jsonConfig, &pc)
+	if err != nil || pc.Profiles == nil {
+		return err
+	}
+
+	name := pc.Active
+	if env := os.Getenv(PROFILE_ENV); env != "" {
+		name = env
+	}
+	if name == "" {
+		return errors.New("jsonconfig has profiles but none is active")
+	}
+	raw, ok := pc.Profiles[name]
+	if !ok {
+		return errors.New("unknown profile: " + name)
+	}
+	return json.Unmarshal(raw, l)
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+const profilesConfig = `{
+	"MaxBackups": 3,
+	"profiles": {
+		"dev": {"Format": "text", "MaxBackups": 1},
+		"prod": {"Format": "json"}
+	},
+	"active": "prod"
+}`
+
+func TestApplyProfile(t *testing.T) {
+	t.Setenv(PROFILE_ENV, "")
+	var l Logger
+	config := strings.Replace(profilesConfig, "{", `{"FileName": "`+t.TempDir()+`/app",`, 1)
+	if err := l.Init(config); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.Format != FORMAT_JSON || l.MaxBackups != 3 {
+		t.Errorf("Format %q, MaxBackups %d; want the prod profile over the shared keys", l.Format, l.MaxBackups)
+	}
+
+	t.Setenv(PROFILE_ENV, "dev")
+	var d Logger
+	if err := d.applyProfile([]byte(profilesConfig)); err != nil {
+		t.Fatal(err)
+	}
+	if d.Format != FORMAT_TEXT || d.MaxBackups != 1 {
+		t.Errorf("Format %q, MaxBackups %d; want the dev profile chosen by %s", d.Format, d.MaxBackups, PROFILE_ENV)
+	}
+}
+
+func TestApplyProfileErrors(t *testing.T) {
+	t.Setenv(PROFILE_ENV, "")
+	var l Logger
+	for _, config := range []string{
+		`{"profiles": {"dev": {}}}`,
+		`{"profiles": {"dev": {}}, "active": "staging"}`,
+	} {
+		if err := l.applyProfile([]byte(config)); err == nil {
+			t.Errorf("%s: no error", config)
+		}
+	}
+	if err := l.applyProfile([]byte(`{"Format": "json"}`)); err != nil {
+		t.Errorf("config without profiles: %v", err)
+	}
+}
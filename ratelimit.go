@@ -0,0 +1,146 @@
+package log
+
+import (
+	"math/bits"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// how often entries dropped by rate limits are reported
+const RATE_LIMIT_REPORT_INTERVAL = 10 * time.Second
+
+type tokenBucket struct {
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+type rateLimiter struct {
+	lock    sync.Mutex
+	buckets [5]*tokenBucket
+	cancel  func()
+}
+
+// SetRateLimit lets at most perSecond entries of level through, with bursts
+// of up to burst entries (perSecond if burst <= 0), so an error storm
+// cannot fill the disk. A limit set on a root logger applies to all its
+// entries, one set on a child logger to the entries of that child. Dropped
+// entries are counted in Stats and reported every RATE_LIMIT_REPORT_INTERVAL
+// by an entry of the same level: "rate limit dropped 1234 entries".
+// perSecond <= 0 removes the limit.
+func (l *Logger) SetRateLimit(level LogType, perSecond int, burst int) {
+	i := bits.TrailingZeros(uint(level))
+	if i >= len(l.counters.entries) {
+		return
+	}
+	if burst <= 0 {
+		burst = perSecond
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	rl := l.rateLimit
+	if rl == nil {
+		if perSecond <= 0 {
+			return
+		}
+		rl = &rateLimiter{}
+		rl.cancel = maintenance.schedule(RATE_LIMIT_REPORT_INTERVAL, func() { l.reportRateLimits(rl) })
+		l.rateLimit = rl
+	}
+
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	if perSecond <= 0 {
+		rl.buckets[i] = nil
+		for _, b := range rl.buckets {
+			if b != nil {
+				return
+			}
+		}
+		rl.cancel()
+		l.rateLimit = nil
+		return
+	}
+	rl.buckets[i] = &tokenBucket{rate: float64(perSecond), burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// allow takes a token for e if its level is limited.
+func (rl *rateLimiter) allow(e *Entry) bool {
+	i := bits.TrailingZeros(uint(e.Level))
+	if i >= len(rl.buckets) {
+		return true
+	}
+
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	b := rl.buckets[i]
+	if b == nil {
+		return true
+	}
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		b.dropped++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (l *Logger) rateLimited(e *Entry) bool {
+	if e.report {
+		return false
+	}
+	for _, c := range []*Logger{l, l.parent} {
+		if c == nil {
+			continue
+		}
+		c.lock.Lock()
+		rl := c.rateLimit
+		c.lock.Unlock()
+		if rl != nil && !rl.allow(e) {
+			atomic.AddInt64(&l.root().counters.rateLimited, 1)
+			return true
+		}
+	}
+	return false
+}
+
+// reportRateLimits writes how many entries each bucket of rl dropped since
+// the last report.
+func (l *Logger) reportRateLimits(rl *rateLimiter) {
+	var reports []*Entry
+	now := time.Now()
+
+	rl.lock.Lock()
+	for i, b := range rl.buckets {
+		if b == nil || b.dropped == 0 {
+			continue
+		}
+		reports = append(reports, &Entry{
+			Level:   LogType(1 << uint(i)),
+			Time:    now,
+			Message: "rate limit dropped " + strconv.Itoa(b.dropped) + " entries",
+			Fields:  []Field{{"dropped", b.dropped}, {"interval", RATE_LIMIT_REPORT_INTERVAL}},
+			File:    "???",
+			report:  true,
+		})
+		b.dropped = 0
+	}
+	rl.lock.Unlock()
+
+	for _, e := range reports {
+		l.output(2, e)
+	}
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRateLimit(t *testing.T) {
+	l, b := jsonLogger()
+	l.SetRateLimit(LOG_ERROR, 1, 3)
+	defer l.SetRateLimit(LOG_ERROR, 0, 0)
+	for i := 0; i < 10; i++ {
+		l.Error("storm")
+		l.Warning("unlimited")
+	}
+	c := l.Named("db")
+	c.SetRateLimit(LOG_WARNING, 1, 1)
+	c.Warning("slow")
+	c.Warning("slow")
+
+	count := map[string]int{}
+	for _, e := range decodeLines(t, b) {
+		count[strings.TrimSpace(e["message"].(string))]++
+	}
+	if count["storm"] != 3 || count["unlimited"] != 10 || count["slow"] != 1 {
+		t.Errorf("written %v", count)
+	}
+	if s := l.Stats(); s.RateLimited != 8 {
+		t.Errorf("RateLimited = %d, want 8", s.RateLimited)
+	}
+
+	b.Reset()
+	l.reportRateLimits(l.rateLimit)
+	l.reportRateLimits(l.rateLimit)
+	got := decodeLines(t, b)
+	if len(got) != 1 || got[0]["level"] != "error" || got[0]["dropped"] != float64(7) ||
+		!strings.HasPrefix(got[0]["message"].(string), "rate limit dropped 7 entries") {
+		t.Errorf("reports = %v, want one for the errors", got)
+	}
+
+	c.SetRateLimit(LOG_WARNING, 0, 0)
+	if c.rateLimit != nil {
+		t.Error("removing the last limit keeps the limiter")
+	}
+}
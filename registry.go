@@ -0,0 +1,76 @@
+package log
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// registry holds the loggers of GetLogger and the levels set for them.
+var registry = struct {
+	lock    sync.Mutex
+	loggers map[string]*Logger
+	levels  map[string]LogLevel
+}{loggers: map[string]*Logger{}, levels: map[string]LogLevel{}}
+
+// GetLogger returns the logger of a subsystem, e.g. GetLogger("storage"),
+// creating it as a Named child of the default logger on first use. Every
+// call with the same name returns the same logger, so it need not be passed
+// around; its level can be set centrally with SetLoggerLevel.
+func GetLogger(name string) *Logger {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	l := registry.loggers[name]
+	if l == nil {
+		l = Default().Named(name)
+		if level, ok := levelFor(name); ok {
+			l.SetLevel(level)
+		}
+		registry.loggers[name] = l
+	}
+	return l
+}
+
+// SetLoggerLevel sets the level of the GetLogger logger name and of the
+// ones below it ("storage" covers "storage.s3"), unless one of them has a
+// level of its own set; it also applies to loggers created later.
+func SetLoggerLevel(name string, level LogLevel) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	registry.levels[name] = level
+	for n, l := range registry.loggers {
+		if n == name || strings.HasPrefix(n, name+".") {
+			level, _ := levelFor(n)
+			l.SetLevel(level)
+		}
+	}
+}
+
+// Loggers returns the names of the loggers created by GetLogger, sorted.
+func Loggers() []string {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	names := make([]string, 0, len(registry.loggers))
+	for n := range registry.loggers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// levelFor returns the level set for name or the closest name above it.
+func levelFor(name string) (LogLevel, bool) {
+	for {
+		if level, ok := registry.levels[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
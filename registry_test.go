@@ -0,0 +1,62 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetLogger(t *testing.T) {
+	defer func() {
+		registry.lock.Lock()
+		for n := range registry.loggers {
+			if strings.HasPrefix(n, "regtest") {
+				delete(registry.loggers, n)
+			}
+		}
+		for n := range registry.levels {
+			if strings.HasPrefix(n, "regtest") {
+				delete(registry.levels, n)
+			}
+		}
+		registry.lock.Unlock()
+	}()
+
+	s := GetLogger("regtest.storage")
+	if GetLogger("regtest.storage") != s || s.Name() != "regtest.storage" {
+		t.Fatal("GetLogger does not return one logger per name")
+	}
+	s3 := GetLogger("regtest.storage.s3")
+	api := GetLogger("regtest.api")
+
+	SetLoggerLevel("regtest.storage", LOG_LEVEL_ERROR)
+	SetLoggerLevel("regtest.storage.s3", LOG_LEVEL_DEBUG)
+	late := GetLogger("regtest.storage.disk")
+	for _, tt := range []struct {
+		l    *Logger
+		want LogLevel
+	}{
+		{s, LOG_LEVEL_ERROR},
+		{s3, LOG_LEVEL_DEBUG},
+		{late, LOG_LEVEL_ERROR},
+		{api, Default().Level()},
+	} {
+		if got := tt.l.Level(); got != tt.want {
+			t.Errorf("%s: level %v, want %v", tt.l.Name(), got, tt.want)
+		}
+	}
+	// a level of its own is kept when the area changes
+	SetLoggerLevel("regtest.storage", LOG_LEVEL_WARN)
+	if s3.Level() != LOG_LEVEL_DEBUG || late.Level() != LOG_LEVEL_WARN {
+		t.Errorf("levels %v and %v after changing the area", s3.Level(), late.Level())
+	}
+
+	var names []string
+	for _, n := range Loggers() {
+		if strings.HasPrefix(n, "regtest") {
+			names = append(names, n)
+		}
+	}
+	if want := []string{"regtest.api", "regtest.storage", "regtest.storage.disk", "regtest.storage.s3"}; !equalNames(names, want) {
+		t.Errorf("Loggers = %v, want %v", names, want)
+	}
+}
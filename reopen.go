@@ -0,0 +1,152 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+// Reopen closes the current file and opens it again by name, for external
+// rotation: after logrotate renames the file, entries go to a new one under
+// the original name instead of following the renamed inode; after
+// copytruncate the size is read again.
+func (w *RotatingWriter) Reopen() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.reopen()
+}
+
+// reopen opens the current file again by name. If that fails, e.g. because
+// its directory was removed, entries keep going to the open file.
+func (w *RotatingWriter) reopen() error {
+	if w.fd == nil {
+		return nil
+	}
+	name := w.fd.Name()
+	f, err := w.fs().OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	w.fd.Close()
+	w.idx.close()
+	w.idx = nil
+
+	w.fd = f
+	w.size = 0
+	if fi, err := f.Stat(); err == nil {
+		w.size = fi.Size()
+	}
+	if w.IndexEvery > 0 {
+		w.idx = openIndex(w.fs(), name, w.IndexEvery)
+	}
+	return nil
+}
+
+// checkFile reopens the current file if its path no longer leads to it,
+// e.g. after rm or mv by an external tool, so entries are not written to a
+// file nobody can see. A removed directory is created again if the
+// FileSystem can. Until reopening works entries go to the old file and it
+// is retried at every check. It also notices truncation by copytruncate.
+func (w *RotatingWriter) checkFile() {
+	if w.fd == nil {
+		return
+	}
+	open, err := w.fd.Stat()
+	if err != nil {
+		return
+	}
+	cur, err := w.fs().Stat(w.fd.Name())
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return
+	case open.Sys() == nil || cur.Sys() == nil || os.SameFile(open, cur):
+		// only files of package os can be compared
+		if cur.Size() < w.size {
+			w.size = cur.Size()
+		}
+		return
+	}
+
+	if !w.reopenFailed {
+		fmt.Fprintf(os.Stderr, "log: %s was removed or replaced, reopening it\n", w.fd.Name())
+	}
+	err = w.reopen()
+	if os.IsNotExist(err) {
+		if m, ok := w.fs().(interface {
+			MkdirAll(path string, perm os.FileMode) error
+		}); ok && m.MkdirAll(filepath.Dir(w.fd.Name()), 0755) == nil {
+			err = w.reopen()
+		}
+	}
+	// reported once, not at every check
+	if err != nil && !w.reopenFailed {
+		fmt.Fprintf(os.Stderr, "log: reopen %s: %s\n", w.FileName, err)
+	}
+	w.reopenFailed = err != nil
+}
+
+// Reopen reopens the output file of l, see RotatingWriter.Reopen. It does
+// nothing for other outputs.
+func (l *Logger) Reopen() error {
+	if l.rw == nil {
+		return nil
+	}
+	return l.rw.Reopen()
+}
+
+// ReopenAll reopens the files of every open logger and rotating writer.
+func ReopenAll() error {
+	maintenance.lock.Lock()
+	closers := append([]io.Closer(nil), maintenance.closers...)
+	maintenance.lock.Unlock()
+
+	var errs []error
+	for _, c := range closers {
+		if w, ok := c.(*RotatingWriter); ok {
+			err := w.Reopen()
+			if err != nil && err != os.ErrClosed {
+				errs = append(errs, fmt.Errorf("%s: %w", closerName(w), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReopenOnSignal calls ReopenAll whenever one of sigs, SIGHUP by default,
+// is received, until the returned stop func is called. This is what
+// logrotate expects from a daemon after rotating its files.
+func ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := ReopenAll(); err != nil {
+					fmt.Fprintf(os.Stderr, "log: reopen: %s\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
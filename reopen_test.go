@@ -0,0 +1,90 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReopenFailureKeepsFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	os.Mkdir(dir, 0755)
+	w, err := NewRotatingWriter(filepath.Join(dir, "app"), FORMAT_TIME_DAY, ".log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	name := w.fd.Name()
+
+	os.RemoveAll(dir)
+	if err := w.Reopen(); err == nil {
+		t.Fatal("Reopen without directory succeeded")
+	}
+	if _, err := w.Write([]byte("lost\n")); err != nil {
+		t.Fatalf("write after failed reopen: %v", err)
+	}
+
+	os.Mkdir(dir, 0755)
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("kept\n")); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(name)
+	if string(b) != "kept\n" {
+		t.Errorf("file = %q, want %q", b, "kept\n")
+	}
+}
+
+func TestWriteNotOpen(t *testing.T) {
+	w := &RotatingWriter{FileName: filepath.Join(t.TempDir(), "app")}
+	if _, err := w.Write([]byte("x\n")); err != ErrNotOpen {
+		t.Errorf("err = %v, want ErrNotOpen", err)
+	}
+}
+
+// a FileSystem without MkdirAll
+type noMkdirFS struct {
+	FileSystem
+}
+
+func TestCheckFileRemovedDirectory(t *testing.T) {
+	for _, recreate := range []bool{true, false} {
+		dir := filepath.Join(t.TempDir(), "logs")
+		os.Mkdir(dir, 0755)
+		fs := OSFileSystem
+		if !recreate {
+			fs = noMkdirFS{OSFileSystem}
+		}
+		w, err := NewRotatingWriterFS(fs, filepath.Join(dir, "app"), FORMAT_TIME_DAY, ".log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := w.fd.Name()
+
+		os.RemoveAll(dir)
+		w.maintain()
+		if _, err := w.Write([]byte("x\n")); err != nil {
+			t.Errorf("recreate %v: write after rm -r: %v", recreate, err)
+		}
+		b, err := os.ReadFile(name)
+		switch {
+		case recreate && string(b) != "x\n":
+			t.Errorf("recreated file = %q, %v; want %q", b, err, "x\n")
+		case !recreate && !os.IsNotExist(err):
+			t.Errorf("file without MkdirAll: err = %v, want not exist", err)
+		}
+
+		// retried at the next check once the directory is back
+		if !recreate {
+			os.Mkdir(dir, 0755)
+			w.maintain()
+			w.Write([]byte("y\n"))
+			if b, _ := os.ReadFile(name); string(b) != "y\n" {
+				t.Errorf("file after retry = %q, want %q", b, "y\n")
+			}
+		}
+		w.Close()
+	}
+}
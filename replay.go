@@ -0,0 +1,44 @@
+package log
+
+import (
+	"io"
+	"time"
+)
+
+// Replay reads entries previously written by this package from r and hands
+// them to sink, marked as replayed. The gaps between entry timestamps are
+// reproduced divided by speed, so 1 keeps the original pacing and 10 replays
+// ten times faster; speed <= 0 replays without pausing. Lines that cannot be
+// parsed are skipped.
+func Replay(r io.Reader, speed float64, sink Sink) error {
+	d := NewDecoder(r)
+
+	var prev time.Time
+	for {
+		e, err := d.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if _, ok := err.(*DecodeError); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		e.Replayed = true
+
+		if !e.Time.IsZero() {
+			if speed > 0 && !prev.IsZero() {
+				if d := e.Time.Sub(prev); d > 0 {
+					time.Sleep(time.Duration(float64(d) / speed))
+				}
+			}
+			prev = e.Time
+		}
+
+		err = sink.WriteEntry(e)
+		if err != nil {
+			return err
+		}
+	}
+}
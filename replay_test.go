@@ -0,0 +1,64 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// entriesSink keeps copies of the entries it gets.
+type entriesSink struct {
+	entries []*Entry
+	err     error
+}
+
+func (s *entriesSink) WriteEntry(e *Entry) error {
+	s.entries = append(s.entries, e.Clone())
+	return s.err
+}
+
+func TestReplay(t *testing.T) {
+	in := strings.Join([]string{
+		`{"timestamp":"2020-01-01T00:00:00Z","level":"info","message":"first"}`,
+		`not a log line`,
+		`{"timestamp":"2020-01-01T00:00:00.2Z","level":"warning","message":"second"}`,
+		`2020/01/01 00:00:00 [error] third `,
+	}, "\n")
+
+	s := &entriesSink{}
+	start := time.Now()
+	if err := Replay(strings.NewReader(in), 10, s); err != nil {
+		t.Fatal(err)
+	}
+	// 200ms between the JSON entries at ten times the speed
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("replay took %v, want the gaps reproduced", d)
+	}
+
+	want := []struct {
+		level LogType
+		msg   string
+	}{{LOG_INFO, "first"}, {LOG_WARNING, "second"}, {LOG_ERROR, "third"}}
+	if len(s.entries) != len(want) {
+		t.Fatalf("%d entries, want %d", len(s.entries), len(want))
+	}
+	for i, w := range want {
+		e := s.entries[i]
+		if e.Level != w.level || strings.TrimSpace(e.Message) != w.msg || !e.Replayed {
+			t.Errorf("entry %d = %v %q replayed %v, want %v %q replayed", i, e.Level, e.Message, e.Replayed, w.level, w.msg)
+		}
+	}
+}
+
+func TestReplaySinkError(t *testing.T) {
+	errSink := errors.New("collector down")
+	s := &entriesSink{err: errSink}
+	in := "[info] a \n[info] b \n"
+	if err := Replay(strings.NewReader(in), 0, s); err != errSink {
+		t.Errorf("err = %v, want %v", err, errSink)
+	}
+	if len(s.entries) != 1 {
+		t.Errorf("%d entries written, want replay to stop at the first error", len(s.entries))
+	}
+}
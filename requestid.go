@@ -0,0 +1,89 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	// the header a request ID is read from and echoed in
+	REQUEST_ID_HEADER = "X-Request-Id"
+	// the field carrying it
+	REQUEST_ID_FIELD = "request_id"
+	// longer incoming IDs are replaced by new ones
+	REQUEST_ID_MAX_LEN = 128
+)
+
+// NewRequestID returns a random 128-bit ID as 32 hex digits, the format of
+// a W3C trace ID.
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ContextWithRequestID returns a copy of ctx whose entries, through
+// FromContext or the Ctx methods such as InfoCtx, carry id as the
+// REQUEST_ID_FIELD field.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return NewContext(ctx, nil, Field{REQUEST_ID_FIELD, id})
+}
+
+// RequestIDFromContext returns the request ID of ctx, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	fields := ContextFields(ctx)
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == REQUEST_ID_FIELD {
+			id, _ := fields[i].Value.(string)
+			return id
+		}
+	}
+	return ""
+}
+
+// RequestIDMiddleware gives every request an ID: the X-Request-Id header,
+// the trace ID of a W3C traceparent header or a new one. It is echoed in the
+// X-Request-Id response header, and the request context passed on carries
+// l and the ID, so FromContext(r.Context()) logs it on every line of the
+// request. Wrap AccessLog with it to have the ID in access entries too:
+//
+//	h = l.RequestIDMiddleware(l.AccessLog(h))
+func (l *Logger) RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestID(r.Header)
+		w.Header().Set(REQUEST_ID_HEADER, id)
+		ctx := NewContext(r.Context(), l, Field{REQUEST_ID_FIELD, id})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestID(h http.Header) string {
+	if id := h.Get(REQUEST_ID_HEADER); validRequestID(id) {
+		return id
+	}
+	// version-traceid-parentid-flags
+	if parts := strings.Split(h.Get("Traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 && validRequestID(parts[1]) {
+		return parts[1]
+	}
+	return NewRequestID()
+}
+
+// validRequestID keeps IDs from clients short and free of characters that
+// could forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > REQUEST_ID_MAX_LEN {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
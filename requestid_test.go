@@ -0,0 +1,66 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	l, b := jsonLogger()
+	var inner string
+	h := l.RequestIDMiddleware(l.AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner = RequestIDFromContext(r.Context())
+		FromContext(r.Context()).Info("handling")
+	})))
+
+	for _, tt := range []struct {
+		header, value string
+		want          string
+	}{
+		{REQUEST_ID_HEADER, "abc-123", "abc-123"},
+		{"Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		// forged lines and oversized IDs get a new one
+		{REQUEST_ID_HEADER, "x\n[error] forged", ""},
+		{REQUEST_ID_HEADER, strings.Repeat("a", REQUEST_ID_MAX_LEN+1), ""},
+	} {
+		b.Reset()
+		r := httptest.NewRequest("GET", "/orders", nil)
+		r.Header.Set(tt.header, tt.value)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		id := w.Header().Get(REQUEST_ID_HEADER)
+		if tt.want != "" && id != tt.want || tt.want == "" && (len(id) != 32 || id == tt.value) {
+			t.Errorf("%s %.20q: request ID %q", tt.header, tt.value, id)
+		}
+		if inner != id {
+			t.Errorf("handler context has %q, response %q", inner, id)
+		}
+		entries := decodeLines(t, b)
+		if len(entries) != 2 {
+			t.Errorf("%d entries, want the handler and access entries", len(entries))
+		}
+		for _, e := range entries {
+			if e[REQUEST_ID_FIELD] != id {
+				t.Errorf("entry %v lacks the request ID %s", e, id)
+			}
+		}
+	}
+}
+
+func TestContextWithRequestID(t *testing.T) {
+	ctx := ContextWithRequestID(context.Background(), "first")
+	ctx = ContextWithRequestID(ctx, "second")
+	if id := RequestIDFromContext(ctx); id != "second" {
+		t.Errorf("RequestIDFromContext = %q, want the innermost", id)
+	}
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("RequestIDFromContext without ID = %q", id)
+	}
+	if a, b := NewRequestID(), NewRequestID(); len(a) != 32 || a == b {
+		t.Errorf("NewRequestID = %q, %q", a, b)
+	}
+}
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Retention is what happens to rotated files: how many, how old and how
+// large they may get, and how they are compressed.
+type Retention struct {
+	// TimeFormat is the TimeFormat of the Logger, FORMAT_TIME_DAY if empty;
+	// only files with a suffix in this format are rotated files
+	TimeFormat    string
+	MaxBackups    int
+	MaxAge        time.Duration
+	MaxTotalBytes int64
+	Compress      string
+}
+
+// ApplyRetention applies r to the rotated files of fileName and suffixName
+// (as in the Logger configuration) in fs, OSFileSystem if nil. The newest
+// file is left alone since it may still be written to. It does what a
+// RotatingWriter does after each rotation, for directories written by other
+// processes or older versions.
+func ApplyRetention(fs FileSystem, fileName, suffixName string, r Retention) error {
+	if fs == nil {
+		fs = OSFileSystem
+	}
+	err := validateCompress(r.Compress)
+	if err != nil {
+		return err
+	}
+
+	timeFormat := r.TimeFormat
+	if timeFormat == "" {
+		timeFormat = FORMAT_TIME_DAY
+	}
+	files := listBackups(fs, fileName, timeFormat, suffixName)
+	if len(files) == 0 {
+		return nil
+	}
+	active := files[len(files)-1]
+	files = r.prune(fs, files[:len(files)-1], active.size)
+
+	var errs []error
+	for _, b := range r.uncompressed(files) {
+		err := compressFile(fs, b.path, r.Compress)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type backupFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// listBackups lists the rotated files of fileName, compressed or not,
+// oldest first, except the ones named in exclude.
+func listBackups(fs FileSystem, fileName, timeFormat, suffixName string, exclude ...string) []backupFile {
+	pattern := fileName + ".*" + suffixName
+	matches, _ := fs.Glob(pattern)
+	for _, ext := range compressedExts() {
+		m, _ := fs.Glob(pattern + ext)
+		matches = append(matches, m...)
+	}
+
+	seen := make(map[string]bool, len(matches))
+	for _, x := range exclude {
+		seen[x] = true
+	}
+	var files []backupFile
+	for _, m := range matches {
+		if seen[m] || !isBackupName(m, fileName, timeFormat, suffixName) {
+			continue
+		}
+		seen[m] = true
+		if isSymlink(fs, m) {
+			continue
+		}
+		fi, err := fs.Stat(m)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, backupFile{m, fi.Size(), fi.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	return files
+}
+
+// isBackupName reports whether name is fileName.<suffix>[-N]suffixName,
+// possibly compressed, with a suffix in timeFormat. The glob of listBackups
+// also matches the files of other writers, such as app.access.<suffix>.log
+// next to app.<suffix>.log.
+func isBackupName(name, fileName, timeFormat, suffixName string) bool {
+	s, ok := strings.CutPrefix(name, fileName+".")
+	if !ok {
+		return false
+	}
+	for _, ext := range compressedExts() {
+		if t, ok := strings.CutSuffix(s, ext); ok {
+			s = t
+			break
+		}
+	}
+	s, ok = strings.CutSuffix(s, suffixName)
+	if !ok {
+		return false
+	}
+	if _, err := time.Parse(timeFormat, s); err == nil {
+		return true
+	}
+	// an indexed name
+	i := strings.LastIndexByte(s, '-')
+	if i < 0 || i == len(s)-1 || strings.Trim(s[i+1:], "0123456789") != "" {
+		return false
+	}
+	_, err := time.Parse(timeFormat, s[:i])
+	return err == nil
+}
+
+// isSymlink reports whether name is a symbolic link, such as the Symlink
+// of a RotatingWriter, if fs can tell.
+func isSymlink(fs FileSystem, name string) bool {
+	l, ok := fs.(interface {
+		Lstat(name string) (os.FileInfo, error)
+	})
+	if !ok {
+		return false
+	}
+	fi, err := l.Lstat(name)
+	return err == nil && fi.Mode()&os.ModeSymlink != 0
+}
+
+// prune deletes the oldest of files beyond MaxAge, MaxBackups and, with
+// reserved bytes held by the active file, MaxTotalBytes, and returns the
+// ones left.
+func (r Retention) prune(fs FileSystem, files []backupFile, reserved int64) []backupFile {
+	n := 0
+	if r.MaxAge > 0 {
+		for n < len(files) && time.Since(files[n].modTime) > r.MaxAge {
+			n++
+		}
+	}
+	if r.MaxBackups > 0 && len(files)-n > r.MaxBackups {
+		n = len(files) - r.MaxBackups
+	}
+	if r.MaxTotalBytes > 0 {
+		total := reserved
+		for _, b := range files[n:] {
+			total += b.size
+		}
+		for i := n; i < len(files) && total > r.MaxTotalBytes; i++ {
+			total -= files[i].size
+			n = i + 1
+		}
+	}
+
+	var left []backupFile
+	for i, b := range files {
+		if i < n {
+			err := fs.Remove(b.path)
+			if err == nil || os.IsNotExist(err) {
+				fs.Remove(b.path + INDEX_EXT)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "log: remove old log file: %s\n", err)
+		}
+		left = append(left, b)
+	}
+	return left
+}
+
+// uncompressed returns the files still to compress.
+func (r Retention) uncompressed(files []backupFile) []backupFile {
+	if r.Compress == COMPRESS_NONE {
+		return nil
+	}
+	var out []backupFile
+	for _, b := range files {
+		if !isCompressed(b.path) {
+			out = append(out, b)
+		}
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// touch creates the files of names in dir, each a minute older than the
+// next.
+func touch(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	mtime := time.Now().Add(-time.Duration(len(names)+1) * time.Minute)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte("x\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mtime, mtime)
+		mtime = mtime.Add(time.Minute)
+	}
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIsBackupName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"app.20260101.log", true},
+		{"app.20260101-3.log", true},
+		{"app.20260101.log.gz", true},
+		{"app.20260101-3.log.gz", true},
+		{"app.access.20260101.log", false},
+		{"app.access.20260101-1.log", false},
+		{"app.20260101.log.idx", false},
+		{"app.20260101.log.tmp", false},
+		{"app.2026010.log", false},
+		{"app.20260101-.log", false},
+		{"app.20260101-x.log", false},
+		{"apps.20260101.log", false},
+	}
+	for _, tt := range tests {
+		if got := isBackupName(tt.name, "app", FORMAT_TIME_DAY, ".log"); got != tt.want {
+			t.Errorf("isBackupName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetentionKeepsOtherWriters(t *testing.T) {
+	dir := t.TempDir()
+	others := []string{"app.access.20260101.log", "app.access.20260102.log", "app.access.20260103.log"}
+	touch(t, dir, append(others, "app.20260101.log", "app.20260102.log")...)
+
+	w := &RotatingWriter{FileName: filepath.Join(dir, "app"), TimeFormat: FORMAT_TIME_DAY, SuffixName: ".log", MaxBackups: 1}
+	err := w.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	want := append(others, "app.20260102.log", "app."+time.Now().Format(FORMAT_TIME_DAY)+".log")
+	sort.Strings(want)
+	if got := dirNames(t, dir); !equalNames(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestApplyRetentionKeepsOtherWriters(t *testing.T) {
+	dir := t.TempDir()
+	others := []string{"app.access.20260101.log", "app.access.20260102.log"}
+	touch(t, dir, append(others, "app.20260101.log", "app.20260102.log", "app.20260103.log")...)
+
+	err := ApplyRetention(nil, filepath.Join(dir, "app"), ".log", Retention{MaxBackups: 1, Compress: COMPRESS_GZIP})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(others, "app.20260102.log.gz", "app.20260103.log")
+	sort.Strings(want)
+	if got := dirNames(t, dir); !equalNames(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestSetMaxBackups(t *testing.T) {
+	fs := newMemFS()
+	now := time.Now()
+	for i, name := range []string{"app.20240101.log", "app.20240102.log", "app.20240103.log"} {
+		fs.write("/logs/"+name, "old\n", now.Add(time.Duration(i-3)*time.Hour))
+	}
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.MaxBytes = 8 })
+	l := NewLogger(w, "", 0)
+	l.SetOutput(w)
+	l.Named("cmd").SetMaxBackups(2)
+	if l.MaxBackups != 2 {
+		t.Errorf("MaxBackups = %d after SetMaxBackups(2) on a child", l.MaxBackups)
+	}
+
+	// the next rotation keeps the two most recent rotated files
+	l.Info("12345")
+	l.Info("678")
+	checkNames(t, fs, today(), today("1"), "app.20240103.log")
+}
+
+func TestParseMaxAge(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{"", 0, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"3", 3 * 24 * time.Hour, false},
+		{"168h", 168 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"-1d", 0, true},
+		{"-1h", 0, true},
+		{"week", 0, true},
+	} {
+		got, err := ParseMaxAge(tt.in)
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("ParseMaxAge(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestSetMaxAge(t *testing.T) {
+	fs := newMemFS()
+	now := time.Now()
+	fs.write("/logs/app.20240101.log", "old\n", now.Add(-48*time.Hour))
+	fs.write("/logs/app.20240102.log", "old\n", now.Add(-time.Hour))
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.MaxBytes = 8 })
+	l := NewLogger(w, "", 0)
+	l.SetOutput(w)
+	l.Named("cmd").SetMaxAge(24 * time.Hour)
+	if l.MaxAge != "24h0m0s" {
+		t.Errorf("MaxAge = %q after SetMaxAge on a child", l.MaxAge)
+	}
+
+	l.Info("12345")
+	l.Info("678")
+	checkNames(t, fs, today(), today("1"), "app.20240102.log")
+
+	l.SetMaxAge(0)
+	if l.MaxAge != "" {
+		t.Errorf("MaxAge = %q after SetMaxAge(0)", l.MaxAge)
+	}
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"strings"
+)
+
+// RewriteFields replaces the fields of one line written by this package
+// with what fn returns for them. In text lines the header and the message
+// are kept byte for byte; JSON lines are encoded again, reserved keys first.
+// ok is false, and line is returned as is, for lines that are not entries,
+// such as the continuation lines of a multi-line message.
+func RewriteFields(line string, fn func(fields []Field) []Field) (out string, ok bool) {
+	nl := ""
+	if strings.HasSuffix(line, "\n") {
+		line, nl = line[:len(line)-1], "\n"
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(line), "{") {
+		e, err := parseJSON([]byte(line))
+		if err != nil {
+			return line + nl, false
+		}
+		e.Fields = fn(e.Fields)
+		b := encodeJSON(nil, e)
+		return string(b[:len(b)-1]) + nl, true
+	}
+
+	e, body, err := defaultParser.parseTextHead(line)
+	if err != nil {
+		return line + nl, false
+	}
+	splitBody(e, body)
+
+	buf := []byte(line[:len(line)-len(body)+len(e.Message)])
+	buf = appendFields(buf, fn(e.Fields))
+	return string(buf) + nl, true
+}
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestRewriteFields(t *testing.T) {
+	rename := func(fields []Field) []Field {
+		for i := range fields {
+			if fields[i].Key == "uid" {
+				fields[i].Key = "user_id"
+			}
+		}
+		return fields
+	}
+	for _, tt := range []struct {
+		line, want string
+		ok         bool
+	}{
+		{"2024/01/02 03:04:05 [info] login uid=7\n", "2024/01/02 03:04:05 [info] login user_id=7\n", true},
+		{`{"timestamp":"2024-01-02T03:04:05Z","level":"info","message":"login","uid":"7"}`,
+			`{"timestamp":"2024-01-02T03:04:05Z","level":"info","message":"login","user_id":"7"}`, true},
+		{"  continuation of a message\n", "  continuation of a message\n", false},
+	} {
+		got, ok := RewriteFields(tt.line, rename)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q:\n got %q, %v\nwant %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrQuotaExceeded = errors.New("log: total log size quota exceeded")
+
+// ErrNotOpen is returned by writes to a RotatingWriter that was never
+// opened.
+var ErrNotOpen = errors.New("log: rotating writer not open")
+
+// how often idle writers check whether their rotation period ended
+const ROTATE_CHECK_INTERVAL = time.Second
+
+// RotatingWriter is an io.WriteCloser writing to
+// FileName + "." + <time suffix> + SuffixName, where the suffix is the current
+// time formatted with TimeFormat. A new file is opened whenever the suffix
+// changes. It is what a Logger writes to after SetOutputByName, but can be
+// used standalone by any other writer-based logger. Retention and
+// compression only touch files named this way with the current TimeFormat,
+// never those of other writers sharing the directory.
+type RotatingWriter struct {
+	FileName   string
+	TimeFormat string
+	SuffixName string
+
+	// MaxBytes rotates to a new indexed file (name.suffix-1.log, -2, ...)
+	// before a write would take the current one past this size
+	MaxBytes int64
+
+	// MaxTotalBytes caps the combined size of the current and the rotated
+	// files. The oldest rotated files are deleted to make room; when the
+	// current file alone is over the limit, writes fail with
+	// ErrQuotaExceeded until the next rotation.
+	MaxTotalBytes int64
+
+	// MaxBackups deletes the oldest rotated files once there are more than
+	// this many; 0 keeps them all
+	MaxBackups int
+
+	// MaxAge deletes rotated files last written longer ago than this; 0
+	// keeps them regardless of age
+	MaxAge time.Duration
+
+	// Compress names the method rotated files are compressed with, e.g.
+	// COMPRESS_GZIP
+	Compress string
+
+	// Symlink is kept pointing at the current file, e.g. FileName, so tail
+	// -F and other tools can follow one path across rotations
+	Symlink string
+
+	// IndexEvery writes the time and offset of every IndexEvery-th entry to
+	// a sidecar file for OpenRange; 0 writes none
+	IndexEvery int
+
+	// NoAppend opens a new indexed file (name.suffix-1.log, -2, ...)
+	// instead of appending when the file for the current period already
+	// exists, e.g. after a restart. Forced rotations within one period
+	// always get a new index.
+	NoAppend bool
+
+	// FS is where the files live, OSFileSystem if nil
+	FS FileSystem
+
+	// Every rotates at multiples of this interval since the Unix epoch, so
+	// instances rotate at the same time: 6h rotates at 00:00, 06:00, ...
+	// UTC. Files are named by the start of their period; with a TimeFormat
+	// too coarse to tell periods apart they get indexed names.
+	Every time.Duration
+
+	// UTC names files by the time in UTC instead of local time
+	UTC bool
+
+	// Policy decides when to rotate; nil rotates when the TimeFormat suffix
+	// changes, at Every or at MaxBytes, whichever comes first
+	Policy RotatePolicy
+
+	fd        File
+	idx       *indexWriter
+	suffix    string
+	opened    time.Time
+	closed    bool
+	size      int64
+	others    int64
+	overQuota bool
+	cancel    func()
+
+	// a compression goroutine is running
+	compressing bool
+	// checkFile could not reopen the file
+	reopenFailed bool
+
+	hooks []func(oldPath, newPath string)
+	// closed once the hooks of the last rotation ran
+	sealing chan struct{}
+
+	lock sync.Mutex
+}
+
+func NewRotatingWriter(path, timeFormat, suffixName string) (*RotatingWriter, error) {
+	return NewRotatingWriterFS(OSFileSystem, path, timeFormat, suffixName)
+}
+
+func NewRotatingWriterFS(fs FileSystem, path, timeFormat, suffixName string) (*RotatingWriter, error) {
+	w := &RotatingWriter{FileName: path, TimeFormat: timeFormat, SuffixName: suffixName, FS: fs}
+	err := w.Open()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Open opens the file for the current period. It is only needed for a
+// RotatingWriter built as a struct literal, after setting its options;
+// the constructors call it.
+func (w *RotatingWriter) Open() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.fd != nil || w.closed {
+		return nil
+	}
+	err := w.open(w.nowSuffix(time.Now()), false)
+	if err != nil {
+		return err
+	}
+
+	w.cancel = maintenance.schedule(ROTATE_CHECK_INTERVAL, w.maintain)
+	maintenance.register(w)
+
+	return nil
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.fd == nil {
+		return 0, ErrNotOpen
+	}
+
+	err := w.rotate(len(p))
+	if err != nil {
+		return 0, err
+	}
+
+	if w.MaxTotalBytes > 0 && w.size+w.others+int64(len(p)) > w.MaxTotalBytes {
+		err = w.enforceQuota(int64(len(p)))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	w.idx.mark(w.size)
+	n, err := w.fd.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file and opens the one for the current time,
+// even if the suffix did not change.
+func (w *RotatingWriter) Rotate() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.doRotate(w.nowSuffix(time.Now()), true)
+}
+
+// SetTimeFormat changes the rotation period. The current file is kept until
+// the suffix for the new format changes.
+func (w *RotatingWriter) SetTimeFormat(format string) {
+	w.lock.Lock()
+	w.TimeFormat = format
+	w.suffix = w.nowSuffix(time.Now())
+	w.lock.Unlock()
+}
+
+// SetEvery changes the rotation interval, see Every; it applies from the
+// next write.
+func (w *RotatingWriter) SetEvery(d time.Duration) {
+	w.lock.Lock()
+	w.Every = d
+	w.lock.Unlock()
+}
+
+// SetUTC changes the time zone of suffixes, see UTC; it applies from the
+// next write.
+func (w *RotatingWriter) SetUTC(on bool) {
+	w.lock.Lock()
+	w.UTC = on
+	w.lock.Unlock()
+}
+
+func (w *RotatingWriter) SetMaxBytes(n int64) {
+	w.lock.Lock()
+	w.MaxBytes = n
+	w.lock.Unlock()
+}
+
+// SetMaxBackups takes effect at the next rotation.
+func (w *RotatingWriter) SetMaxBackups(n int) {
+	w.lock.Lock()
+	w.MaxBackups = n
+	w.lock.Unlock()
+}
+
+// SetMaxAge takes effect at the next rotation.
+func (w *RotatingWriter) SetMaxAge(d time.Duration) {
+	w.lock.Lock()
+	w.MaxAge = d
+	w.lock.Unlock()
+}
+
+func (w *RotatingWriter) SetCompress(method string) {
+	w.lock.Lock()
+	w.Compress = method
+	w.lock.Unlock()
+}
+
+func (w *RotatingWriter) SetPolicy(p RotatePolicy) {
+	w.lock.Lock()
+	w.Policy = p
+	w.lock.Unlock()
+}
+
+// Name returns the path of the file currently written to.
+func (w *RotatingWriter) Name() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.fd == nil {
+		return ""
+	}
+	return w.fd.Name()
+}
+
+func (w *RotatingWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.cancel != nil {
+		w.cancel()
+	}
+	maintenance.unregister(w)
+	w.idx.close()
+	if w.fd == nil {
+		return nil
+	}
+	return w.fd.Close()
+}
+
+// maintain rotates idle files when their period ends, so a file is not
+// kept open past its suffix just because nothing was logged, and reopens
+// files removed behind the writer's back.
+func (w *RotatingWriter) maintain() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return
+	}
+	w.rotate(0)
+	w.checkFile()
+}
+
+// rotate asks the policy whether the next write of n bytes (0 for idle
+// checks) goes to a new file.
+func (w *RotatingWriter) rotate(n int) error {
+	now := time.Now()
+	state := RotateState{
+		Suffix:    w.suffix,
+		NowSuffix: w.nowSuffix(now),
+		Size:      w.size,
+		Opened:    w.opened,
+		Now:       now,
+	}
+	if !w.policy().ShouldRotate(state, n) {
+		return nil
+	}
+
+	// Notice: a new period gets a file named by its suffix, otherwise the
+	// next free index of the current one
+	return w.doRotate(state.NowSuffix, state.NowSuffix == w.suffix)
+}
+
+func (w *RotatingWriter) policy() RotatePolicy {
+	if w.Policy != nil {
+		return w.Policy
+	}
+	if w.Every > 0 {
+		return RotateAny(RotateByTime(), RotateEvery(w.Every), RotateBySize(w.MaxBytes))
+	}
+	return RotateAny(RotateByTime(), RotateBySize(w.MaxBytes))
+}
+
+// nowSuffix is the suffix of the file for now: the start of its period with
+// Every, in UTC with UTC.
+func (w *RotatingWriter) nowSuffix(now time.Time) string {
+	if w.UTC {
+		now = now.UTC()
+	}
+	if w.Every > 0 {
+		now = periodStart(now, w.Every)
+	}
+	return now.Format(w.TimeFormat)
+}
+
+func (w *RotatingWriter) doRotate(suffix string, fresh bool) error {
+	old := ""
+	// Notice: Not check error, is this ok?
+	if w.fd != nil {
+		old = w.fd.Name()
+		w.fd.Close()
+	}
+	w.idx.close()
+	w.idx = nil
+
+	//lastFileName := w.FileName + "." + w.suffix + w.SuffixName
+	/*err := os.Rename(w.FileName, lastFileName)
+	if err != nil {
+		return err
+	}*/
+
+	// set before open so compressing the old file waits for the hooks
+	var prev, done chan struct{}
+	if old != "" && len(w.hooks) > 0 {
+		prev, done = w.sealing, make(chan struct{})
+		w.sealing = done
+	}
+
+	err := w.open(suffix, fresh)
+	if done != nil {
+		newPath := ""
+		if err == nil {
+			newPath = w.fd.Name()
+		}
+		go runRotateHooks(w.hooks, old, newPath, prev, done)
+	}
+	return err
+}
+
+// RegisterRotateHook makes fn run after every rotation with the path of
+// the file just closed and of the new one. Hooks run in order in the
+// background, one rotation after the other; compression of the closed
+// file waits for them.
+func (w *RotatingWriter) RegisterRotateHook(fn func(oldPath, newPath string)) {
+	w.lock.Lock()
+	w.hooks = append(w.hooks[:len(w.hooks):len(w.hooks)], fn)
+	w.lock.Unlock()
+}
+
+func runRotateHooks(hooks []func(oldPath, newPath string), oldPath, newPath string, prev, done chan struct{}) {
+	defer close(done)
+	if prev != nil {
+		<-prev
+	}
+	if newPath == "" {
+		return
+	}
+	for _, h := range hooks {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					fmt.Fprintf(os.Stderr, "log: rotate hook panicked: %v\n", p)
+				}
+			}()
+			h(oldPath, newPath)
+		}()
+	}
+}
+
+// open opens the file for suffix. With fresh (or NoAppend) set, an existing
+// file is never appended to: the first free name.suffix-N is used instead.
+func (w *RotatingWriter) open(suffix string, fresh bool) error {
+	name := w.FileName + "." + suffix + w.SuffixName
+	// a compressed file keeps its name taken
+	if fresh || w.NoAppend || w.compressed(name) {
+		for i := 1; w.exists(name) || w.compressed(name); i++ {
+			name = w.FileName + "." + suffix + "-" + strconv.Itoa(i) + w.SuffixName
+		}
+	}
+
+	f, err := w.fs().OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+
+	w.fd = f
+	if w.IndexEvery > 0 {
+		w.idx = openIndex(w.fs(), name, w.IndexEvery)
+	}
+	w.suffix = suffix
+	w.opened = time.Now()
+	w.overQuota = false
+
+	w.size = 0
+	if fi, err := f.Stat(); err == nil {
+		w.size = fi.Size()
+	}
+	if w.Symlink != "" {
+		w.link(name)
+	}
+	w.others = 0
+	r := w.retention()
+	backups := r.prune(w.fs(), w.backups(), 0)
+	w.compress(r.uncompressed(backups))
+	for _, b := range backups {
+		w.others += b.size
+	}
+
+	return nil
+}
+
+// compress compresses files in the background, one batch at a time; files
+// rotated meanwhile are picked up by the next rotation.
+func (w *RotatingWriter) compress(files []backupFile) {
+	if w.compressing || len(files) == 0 {
+		return
+	}
+	w.compressing = true
+	fs, method, sealing := w.fs(), w.Compress, w.sealing
+
+	go func() {
+		if sealing != nil {
+			<-sealing
+		}
+		for _, b := range files {
+			err := compressFile(fs, b.path, method)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "log: compress %s: %s\n", b.path, err)
+			}
+		}
+		w.lock.Lock()
+		w.compressing = false
+		w.lock.Unlock()
+	}()
+}
+
+func (w *RotatingWriter) retention() Retention {
+	return Retention{MaxBackups: w.MaxBackups, MaxAge: w.MaxAge, Compress: w.Compress}
+}
+
+// link points Symlink at name, replacing the old link atomically. The
+// target is relative when both are in the same directory.
+func (w *RotatingWriter) link(name string) {
+	fs, ok := w.fs().(interface {
+		Symlink(oldname, newname string) error
+	})
+	if !ok {
+		return
+	}
+
+	target := name
+	if filepath.Dir(target) == filepath.Dir(w.Symlink) {
+		target = filepath.Base(target)
+	}
+	tmp := w.Symlink + ".tmp"
+	w.fs().Remove(tmp)
+	err := fs.Symlink(target, tmp)
+	if err == nil {
+		err = w.fs().Rename(tmp, w.Symlink)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log: update symlink %s: %s\n", w.Symlink, err)
+	}
+}
+
+func (w *RotatingWriter) exists(name string) bool {
+	_, err := w.fs().Stat(name)
+	return err == nil
+}
+
+func (w *RotatingWriter) compressed(name string) bool {
+	for _, ext := range compressedExts() {
+		if w.exists(name + ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *RotatingWriter) fs() FileSystem {
+	if w.FS == nil {
+		return OSFileSystem
+	}
+	return w.FS
+}
+
+// backups lists the rotated files of w, oldest first.
+func (w *RotatingWriter) backups() []backupFile {
+	exclude := []string{w.Symlink}
+	if w.fd != nil {
+		exclude = append(exclude, w.fd.Name())
+	}
+	return listBackups(w.fs(), w.FileName, w.TimeFormat, w.SuffixName, exclude...)
+}
+
+// enforceQuota deletes the oldest rotated files of w, never those of other
+// writers in the directory, until n more bytes fit in MaxTotalBytes.
+func (w *RotatingWriter) enforceQuota(n int64) error {
+	if w.overQuota {
+		return ErrQuotaExceeded
+	}
+
+	for _, b := range w.backups() {
+		if w.size+w.others+n <= w.MaxTotalBytes {
+			break
+		}
+		if w.fs().Remove(b.path) == nil {
+			w.fs().Remove(b.path + INDEX_EXT)
+			w.others -= b.size
+		}
+	}
+
+	if w.size+w.others+n > w.MaxTotalBytes {
+		w.overQuota = true
+		fmt.Fprintf(os.Stderr, "log: %s is over its %d byte quota, dropping entries until next rotation\n", w.FileName, w.MaxTotalBytes)
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// ParseMaxAge reads a MaxAge: a duration such as "168h", or a number of
+// days with an optional "d" suffix ("7d", "7").
+func ParseMaxAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+	if err == nil {
+		if days < 0 {
+			return 0, fmt.Errorf("negative MaxAge %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid MaxAge %q, want a duration like \"168h\" or days like \"7d\"", s)
+	}
+	return d, nil
+}
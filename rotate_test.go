@@ -0,0 +1,353 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestQuotaKeepsOtherWriters(t *testing.T) {
+	dir := t.TempDir()
+	others := []string{"app.access.20260101.log", "app.access.20260102.log"}
+	touch(t, dir, append(others, "app.20260101.log", "app.20260102.log")...)
+
+	w := &RotatingWriter{FileName: filepath.Join(dir, "app"), TimeFormat: FORMAT_TIME_DAY, SuffixName: ".log", MaxTotalBytes: 10}
+	err := w.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 2 bytes in each backup: the 9 bytes only fit once both are gone
+	_, err = w.Write([]byte("123456789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(others, "app."+time.Now().Format(FORMAT_TIME_DAY)+".log")
+	sort.Strings(want)
+	if got := dirNames(t, dir); !equalNames(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	_, err = w.Write([]byte("123"))
+	if err != ErrQuotaExceeded {
+		t.Errorf("write over quota: err = %v, want ErrQuotaExceeded", err)
+	}
+	if got := dirNames(t, dir); !equalNames(got, want) {
+		t.Errorf("files after failed write = %v, want %v", got, want)
+	}
+}
+
+// memWriter opens a RotatingWriter for /logs/app.<day>.log on a memFS
+// after setting its options with opt.
+func memWriter(t *testing.T, fs *memFS, opt func(w *RotatingWriter)) *RotatingWriter {
+	t.Helper()
+	w := &RotatingWriter{FileName: "/logs/app", TimeFormat: FORMAT_TIME_DAY, SuffixName: ".log", FS: fs}
+	if opt != nil {
+		opt(w)
+	}
+	err := w.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+// today is the name of the file of the current period, with an index if
+// one is given.
+func today(index ...string) string {
+	s := "app." + time.Now().Format(FORMAT_TIME_DAY)
+	for _, i := range index {
+		s += "-" + i
+	}
+	return s + ".log"
+}
+
+func checkNames(t *testing.T, fs *memFS, want ...string) {
+	t.Helper()
+	sort.Strings(want)
+	if got := fs.names(); !equalNames(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestRotateByTime(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, nil)
+
+	// as if the writer was opened on an earlier day
+	w.lock.Lock()
+	w.doRotate("20000101", false)
+	w.lock.Unlock()
+	fs.write("/logs/app.20000101.log", "old\n", time.Now())
+	checkNames(t, fs, "app.20000101.log", today())
+
+	// an idle writer rotates at the next check
+	w.maintain()
+	if got := w.Name(); got != "/logs/"+today() {
+		t.Errorf("file after the period ended = %s, want %s", got, today())
+	}
+
+	// a write rotates before it is written
+	w.lock.Lock()
+	w.doRotate("20000102", false)
+	w.lock.Unlock()
+	w.Write([]byte("new\n"))
+	if got := fs.read("/logs/" + today()); got != "new\n" {
+		t.Errorf("file of the new period = %q, want %q", got, "new\n")
+	}
+	checkNames(t, fs, "app.20000101.log", "app.20000102.log", today())
+}
+
+func TestRotateBySize(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.MaxBytes = 10 })
+
+	w.Write([]byte("12345678\n"))
+	w.Write([]byte("abc\n"))
+	w.Write([]byte("def\n"))
+	if got := fs.read("/logs/" + today()); got != "12345678\n" {
+		t.Errorf("first file = %q", got)
+	}
+	if got := fs.read("/logs/" + today("1")); got != "abc\ndef\n" {
+		t.Errorf("second file = %q", got)
+	}
+	checkNames(t, fs, today(), today("1"))
+}
+
+func TestRotateBySizeLargeWrite(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.MaxBytes = 4 })
+
+	// an empty file takes a write larger than MaxBytes
+	w.Write([]byte("123456789\n"))
+	w.Write([]byte("ab\n"))
+	checkNames(t, fs, today(), today("1"))
+	if got := fs.read("/logs/" + today()); got != "123456789\n" {
+		t.Errorf("first file = %q", got)
+	}
+
+	l := NewLogger(w, "", 0)
+	l.SetOutput(w)
+	l.SetRotateBySize(2)
+	if w.MaxBytes != 2<<20 {
+		t.Errorf("MaxBytes = %d after SetRotateBySize(2)", w.MaxBytes)
+	}
+	l.Named("cmd").SetRotateBySize(3)
+	if w.MaxBytes != 3<<20 || l.MaxSize != 3 {
+		t.Errorf("MaxBytes = %d, MaxSize = %d after SetRotateBySize(3) on a child", w.MaxBytes, l.MaxSize)
+	}
+}
+
+func TestRotateIndexedNames(t *testing.T) {
+	fs := newMemFS()
+	fs.write("/logs/"+today(), "before restart\n", time.Now())
+	fs.write("/logs/"+today("1")+".gz", "", time.Now())
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.NoAppend = true })
+
+	// the name of a compressed file stays taken
+	if got := w.Name(); got != "/logs/"+today("2") {
+		t.Errorf("NoAppend opened %s, want %s", got, today("2"))
+	}
+	w.Rotate()
+	if got := w.Name(); got != "/logs/"+today("3") {
+		t.Errorf("Rotate opened %s, want %s", got, today("3"))
+	}
+}
+
+func TestRotateAppendsWithinPeriod(t *testing.T) {
+	fs := newMemFS()
+	fs.write("/logs/"+today(), "before restart\n", time.Now())
+	w := memWriter(t, fs, nil)
+
+	w.Write([]byte("after restart\n"))
+	if got := fs.read("/logs/" + today()); got != "before restart\nafter restart\n" {
+		t.Errorf("file = %q, want the restart appended", got)
+	}
+	// a forced rotation in the same period never appends
+	w.Rotate()
+	w.Write([]byte("rotated\n"))
+	if got := fs.read("/logs/" + today("1")); got != "rotated\n" {
+		t.Errorf("%s = %q, want %q", today("1"), got, "rotated\n")
+	}
+	checkNames(t, fs, today(), today("1"))
+}
+
+func TestRotateRetention(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	for _, tt := range []struct {
+		name string
+		opt  func(w *RotatingWriter)
+		want []string
+	}{
+		{"none", nil, []string{"app.20240101.log", "app.20240102.log", "app.20240103.log", "app.20240104.log"}},
+		{"MaxBackups", func(w *RotatingWriter) { w.MaxBackups = 2 }, []string{"app.20240103.log", "app.20240104.log"}},
+		{"MaxAge", func(w *RotatingWriter) { w.MaxAge = 36 * time.Hour }, []string{"app.20240104.log"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newMemFS()
+			for i, name := range []string{"app.20240101.log", "app.20240102.log", "app.20240103.log", "app.20240104.log"} {
+				fs.write("/logs/"+name, "old\n", now.Add(time.Duration(i-4)*day))
+			}
+			fs.write("/logs/app.access.20240101.log", "other\n", now.Add(-10*day))
+			memWriter(t, fs, tt.opt)
+
+			checkNames(t, fs, append(tt.want, "app.access.20240101.log", today())...)
+		})
+	}
+}
+
+func TestRotateQuota(t *testing.T) {
+	fs := newMemFS()
+	fs.write("/logs/app.20240101.log", "old\n", time.Now().Add(-time.Hour))
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.MaxTotalBytes = 10 })
+
+	// the backup is deleted to make room
+	if _, err := w.Write([]byte("12345678\n")); err != nil {
+		t.Fatal(err)
+	}
+	checkNames(t, fs, today())
+	if _, err := w.Write([]byte("abc\n")); err != ErrQuotaExceeded {
+		t.Errorf("write over quota: err = %v, want ErrQuotaExceeded", err)
+	}
+}
+
+func TestRotateReopen(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, nil)
+	name := "/logs/" + today()
+
+	w.Write([]byte("a\n"))
+	// logrotate renames the file, then the writer is told to reopen
+	fs.Rename(name, name+".1")
+	w.Write([]byte("b\n"))
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("c\n"))
+	if got := fs.read(name + ".1"); got != "a\nb\n" {
+		t.Errorf("renamed file = %q, want %q", got, "a\nb\n")
+	}
+	if got := fs.read(name); got != "c\n" {
+		t.Errorf("reopened file = %q, want %q", got, "c\n")
+	}
+}
+
+func TestRotateCheckFile(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, func(w *RotatingWriter) { w.MaxBytes = 6 })
+	name := "/logs/" + today()
+
+	w.Write([]byte("a\n"))
+	fs.Remove(name)
+	w.maintain()
+	w.Write([]byte("b\n"))
+	if got := fs.read(name); got != "b\n" {
+		t.Errorf("file recreated after rm = %q, want %q", got, "b\n")
+	}
+
+	// copytruncate: the size is read again, so MaxBytes counts from 0
+	w.Write([]byte("c\n"))
+	fs.write(name, "", time.Now())
+	w.maintain()
+	w.Write([]byte("12345\n"))
+	if got := w.Name(); got != name {
+		t.Errorf("rotated to %s after truncation, want %s kept", got, name)
+	}
+}
+
+func TestRotateSymlink(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, func(w *RotatingWriter) {
+		w.Symlink = "/logs/app.log"
+		w.MaxBytes = 4
+	})
+
+	w.Write([]byte("a\n"))
+	w.Write([]byte("bcd\n"))
+	if got := fs.read("/logs/app.log"); got != "bcd\n" {
+		t.Errorf("symlink leads to %q, want %q", got, "bcd\n")
+	}
+	fi, err := fs.Lstat("/logs/app.log")
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("app.log is not a symlink: %v", err)
+	}
+	// the link is no backup
+	checkNames(t, fs, "app.log", today(), today("1"))
+}
+
+func TestRotateSymlinkTarget(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		link string
+		want string
+	}{
+		// relative next to the files, so the directory can be moved
+		{filepath.Join(dir, "app.log"), today()},
+		{filepath.Join(dir, "current", "app.log"), filepath.Join(dir, today())},
+	} {
+		os.MkdirAll(filepath.Dir(tt.link), 0755)
+		w := &RotatingWriter{FileName: filepath.Join(dir, "app"), TimeFormat: FORMAT_TIME_DAY, SuffixName: ".log", Symlink: tt.link}
+		if err := w.Open(); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("a\n"))
+		w.Close()
+
+		if got, err := os.Readlink(tt.link); err != nil || got != tt.want {
+			t.Errorf("%s -> %q, %v, want %q", tt.link, got, err, tt.want)
+		}
+	}
+}
+
+func TestRotateHooks(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, func(w *RotatingWriter) {
+		w.MaxBytes = 8
+		w.Compress = COMPRESS_GZIP
+	})
+	l := NewLogger(w, "", 0)
+	l.SetOutput(w)
+
+	type rotation struct{ old, new string }
+	done := make(chan rotation, 2)
+	l.Named("upload").RegisterRotateHook(func(oldPath, newPath string) {
+		panic("first hook")
+	})
+	l.RegisterRotateHook(func(oldPath, newPath string) {
+		// compression waits for the hooks
+		if got := fs.read(oldPath); got != "[info] 12345 \n" {
+			t.Errorf("sealed file = %q", got)
+		}
+		done <- rotation{oldPath, newPath}
+	})
+
+	captureStderr(t, func() {
+		l.Info("12345")
+		l.Info("678")
+		select {
+		case r := <-done:
+			if r.old != "/logs/"+today() || r.new != "/logs/"+today("1") {
+				t.Errorf("hook got %s -> %s", r.old, r.new)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("rotate hook did not run")
+		}
+	})
+}
+
+func TestRotateUTCSuffix(t *testing.T) {
+	w := &RotatingWriter{TimeFormat: FORMAT_TIME_HOUR}
+	now := time.Date(2024, 5, 1, 1, 30, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	if got := w.nowSuffix(now); got != "2024050101" {
+		t.Errorf("local suffix = %s", got)
+	}
+	w.SetUTC(true)
+	if got := w.nowSuffix(now); got != "2024043022" {
+		t.Errorf("UTC suffix = %s, want the UTC hour", got)
+	}
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sync/atomic"
+)
+
+type sampler struct {
+	rates [5]float64
+	key   string
+}
+
+// SetSampling keeps only a share of the entries of each level: rates maps
+// levels to the kept fraction, e.g. {LOG_INFO: 0.1, LOG_DEBUG: 0.01}.
+// Levels missing from rates are kept in full; nil turns sampling off.
+// Dropped entries are counted in Stats.
+//
+// With key set, entries carrying that field are sampled by a hash of its
+// value instead of at random, so all entries of a kept request_id are
+// kept. The decision is the same at every level: a key kept at 1% is also
+// kept at 10%.
+func (l *Logger) SetSampling(rates map[LogType]float64, key string) {
+	var s *sampler
+	if rates != nil {
+		s = &sampler{key: key}
+		for i := range s.rates {
+			s.rates[i] = 1
+		}
+		for t, rate := range rates {
+			if i := bits.TrailingZeros(uint(t)); i < len(s.rates) {
+				s.rates[i] = math.Max(0, math.Min(1, rate))
+			}
+		}
+	}
+
+	r := l.root()
+	r.lock.Lock()
+	r.sampler = s
+	r.lock.Unlock()
+}
+
+// samplingRates maps the Sampling config to levels.
+func samplingRates(m map[string]float64) (map[LogType]float64, error) {
+	rates := make(map[LogType]float64, len(m))
+	for k, v := range m {
+		t := StringToLogType(k)
+		if t == 0 {
+			return nil, errors.New("unknown level in Sampling: " + k)
+		}
+		rates[t] = v
+	}
+	return rates, nil
+}
+
+func (s *sampler) keep(e *Entry) bool {
+	i := bits.TrailingZeros(uint(e.Level))
+	if i >= len(s.rates) || s.rates[i] >= 1 {
+		return true
+	}
+	rate := s.rates[i]
+
+	if s.key != "" {
+		for _, f := range e.Fields {
+			if f.Key == s.key {
+				h := fnv.New64a()
+				fmt.Fprint(h, f.Value)
+				return float64(h.Sum64()>>11)/(1<<53) < rate
+			}
+		}
+	}
+	return rand.Float64() < rate
+}
+
+func (l *Logger) sampled(e *Entry) bool {
+	l.lock.Lock()
+	s := l.sampler
+	l.lock.Unlock()
+
+	if s == nil || s.keep(e) {
+		return false
+	}
+	atomic.AddInt64(&l.counters.sampled, 1)
+	return true
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"io"
+	"strconv"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	l := NewLogger(io.Discard, "", 0)
+	s := &entriesSink{}
+	l.AddSink(s)
+	l.Named("child").SetSampling(map[LogType]float64{LOG_DEBUG: 0, LOG_INFO: 0.5}, "")
+	for i := 0; i < 1000; i++ {
+		l.Debug("debug")
+		l.Info("info")
+		l.Warning("warning")
+	}
+
+	counts := map[LogType]int{}
+	for _, e := range s.entries {
+		counts[e.Level]++
+	}
+	if counts[LOG_DEBUG] != 0 || counts[LOG_WARNING] != 1000 {
+		t.Errorf("kept %d debug and %d warning entries, want 0 and 1000", counts[LOG_DEBUG], counts[LOG_WARNING])
+	}
+	if n := counts[LOG_INFO]; n < 400 || n > 600 {
+		t.Errorf("kept %d of 1000 info entries at 0.5", n)
+	}
+	if got, want := l.Stats().Sampled, int64(2000-counts[LOG_INFO]); got != want {
+		t.Errorf("Sampled = %d, want %d", got, want)
+	}
+}
+
+func TestSamplingByKey(t *testing.T) {
+	low := &sampler{key: "request_id", rates: [5]float64{1, 1, 1, 0.1, 0.01}}
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		id := F("request_id", strconv.Itoa(i))
+		info := low.keep(&Entry{Level: LOG_INFO, Fields: []Field{id}})
+		if info != low.keep(&Entry{Level: LOG_INFO, Fields: []Field{id}}) {
+			t.Fatalf("request %d: decision changed", i)
+		}
+		// kept at 1% means kept at 10%
+		if low.keep(&Entry{Level: LOG_DEBUG, Fields: []Field{id}}) && !info {
+			t.Errorf("request %d kept at debug but not at info", i)
+		}
+		if info {
+			kept++
+		}
+	}
+	if kept < 50 || kept > 150 {
+		t.Errorf("kept %d of 1000 requests at 0.1", kept)
+	}
+}
@@ -0,0 +1,164 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// maintenance runs the periodic work of every logger and rotating writer
+// (rotation checks, cleanup, reports) from one shared goroutine, and keeps
+// track of what ShutdownAll has to close.
+var maintenance = &scheduler{}
+
+type scheduler struct {
+	lock    sync.Mutex
+	tasks   map[*task]struct{}
+	closers []io.Closer
+	running bool
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type task struct {
+	every time.Duration
+	next  time.Time
+	fn    func()
+}
+
+// schedule runs fn every interval until the returned cancel func is called.
+func (s *scheduler) schedule(every time.Duration, fn func()) (cancel func()) {
+	t := &task{every: every, next: time.Now().Add(every), fn: fn}
+
+	s.lock.Lock()
+	if s.tasks == nil {
+		s.tasks = make(map[*task]struct{})
+	}
+	s.tasks[t] = struct{}{}
+	if !s.running {
+		s.running = true
+		s.wake = make(chan struct{}, 1)
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.run(s.wake, s.stop, s.done)
+	}
+	wake := s.wake
+	s.lock.Unlock()
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+
+	return func() {
+		s.lock.Lock()
+		delete(s.tasks, t)
+		s.lock.Unlock()
+	}
+}
+
+func (s *scheduler) run(wake, stop, done chan struct{}) {
+	defer close(done)
+
+	for {
+		now := time.Now()
+		next := now.Add(time.Hour)
+		var due []*task
+
+		s.lock.Lock()
+		for t := range s.tasks {
+			if !t.next.After(now) {
+				due = append(due, t)
+				t.next = now.Add(t.every)
+			}
+			if t.next.Before(next) {
+				next = t.next
+			}
+		}
+		s.lock.Unlock()
+
+		for _, t := range due {
+			t.fn()
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-wake:
+			timer.Stop()
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (s *scheduler) register(c io.Closer) {
+	s.lock.Lock()
+	s.closers = append(s.closers, c)
+	s.lock.Unlock()
+}
+
+func (s *scheduler) unregister(c io.Closer) {
+	s.lock.Lock()
+	for i, r := range s.closers {
+		if r == c {
+			s.closers = append(s.closers[:i], s.closers[i+1:]...)
+			break
+		}
+	}
+	s.lock.Unlock()
+}
+
+func (s *scheduler) shutdown() error {
+	s.lock.Lock()
+	running, stop, done := s.running, s.stop, s.done
+	s.running = false
+	s.tasks = nil
+	closers := s.closers
+	s.closers = nil
+	s.lock.Unlock()
+
+	if running {
+		close(stop)
+		<-done
+	}
+
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		err := closers[i].Close()
+		if err != nil {
+			errs = append(errs, &CloseError{closerName(closers[i]), err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ShutdownAll stops the maintenance goroutine shared by all loggers, then
+// closes every logger and rotating writer still open, most recently opened
+// first. It returns the close errors joined, or ctx.Err() if ctx ends first.
+func ShutdownAll(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- maintenance.shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeOnDone closes c once ctx ends. c must cancel ctx when it is closed
+// otherwise, so the goroutine waiting for it does not outlive c.
+func closeOnDone(ctx context.Context, c io.Closer) {
+	go func() {
+		<-ctx.Done()
+		c.Close()
+	}()
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsUntilCanceled(t *testing.T) {
+	s := &scheduler{}
+	defer s.shutdown()
+
+	var fast, slow int32
+	cancel := s.schedule(5*time.Millisecond, func() { atomic.AddInt32(&fast, 1) })
+	s.schedule(time.Hour, func() { atomic.AddInt32(&slow, 1) })
+	within(t, "three runs", func() {
+		for atomic.LoadInt32(&fast) < 3 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	cancel()
+	n := atomic.LoadInt32(&fast)
+	time.Sleep(20 * time.Millisecond)
+	// a run in progress when cancel was called may still finish
+	if got := atomic.LoadInt32(&fast); got > n+1 {
+		t.Errorf("%d runs after cancel", got-n)
+	}
+	if got := atomic.LoadInt32(&slow); got != 0 {
+		t.Errorf("hourly task ran %d times", got)
+	}
+}
+
+// orderCloser records the order it was closed in.
+type orderCloser struct {
+	closed *[]int
+	id     int
+	err    error
+}
+
+func (c orderCloser) Close() error {
+	*c.closed = append(*c.closed, c.id)
+	return c.err
+}
+
+func TestSchedulerShutdown(t *testing.T) {
+	s := &scheduler{}
+	var ran int32
+	s.schedule(time.Millisecond, func() { atomic.AddInt32(&ran, 1) })
+
+	var closed []int
+	errClose := errors.New("flush failed")
+	a := orderCloser{&closed, 1, nil}
+	b := orderCloser{&closed, 2, errClose}
+	c := orderCloser{&closed, 3, nil}
+	s.register(a)
+	s.register(b)
+	s.register(c)
+	s.unregister(c)
+
+	err := s.shutdown()
+	if !errors.Is(err, errClose) {
+		t.Errorf("err = %v, want %v", err, errClose)
+	}
+	if len(closed) != 2 || closed[0] != 2 || closed[1] != 1 {
+		t.Errorf("closed %v, want [2 1]", closed)
+	}
+	n := atomic.LoadInt32(&ran)
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&ran) != n {
+		t.Error("tasks still run after shutdown")
+	}
+}
@@ -0,0 +1,312 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sink receives entries as values instead of encoded bytes.
+type Sink interface {
+	WriteEntry(e *Entry) error
+}
+
+// ConcurrentSink is implemented by sinks that declare whether their
+// WriteEntry may be called from several goroutines at once. Sinks not
+// implementing it are treated as unsafe: entries reach them one at a time.
+// When an entry goes to several safe sinks they are written in parallel.
+type ConcurrentSink interface {
+	Sink
+	ConcurrentSafe() bool
+}
+
+// WriteEntry makes a Logger usable as a Sink: e is written with its own
+// time and caller if it passes the level filter.
+func (l *Logger) WriteEntry(e *Entry) error {
+	if !l.enabled(e.Level) {
+		return nil
+	}
+	// e may be shared with other sinks
+	e = e.Clone()
+	if e.File == "" {
+		// the caller of WriteEntry is not where the entry came from
+		e.File = "???"
+	}
+
+	l.output(2, e)
+	return nil
+}
+
+// Emit writes a copy of e through l's pipeline if it passes the level
+// filter, keeping its time, name, fields and caller; l adds its own fields
+// and sinks. An entry without time gets the current one, and one without
+// caller the code calling Emit. Hooks use
+// it to pass entries, or modified Clones of them, on to other loggers:
+//
+//	l.AddHook("analytics", func(e *log.Entry) {
+//		c := e.Clone()
+//		anonymize(c)
+//		analytics.Emit(c)
+//	})
+func (l *Logger) Emit(e *Entry) {
+	if !l.enabled(e.Level) {
+		return
+	}
+	e = e.Clone()
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	l.output(2, e)
+}
+
+func (l *Logger) ConcurrentSafe() bool {
+	return true
+}
+
+// SinkOption configures how entries reach one sink.
+type SinkOption func(*sinkConfig)
+
+type sinkConfig struct {
+	sink   Sink
+	allow  map[string]bool
+	deny   map[string]bool
+	format FormatFunc
+
+	// set by RouteLevel and RouteAlert
+	routed bool
+	levels LogLevel
+	alerts map[string]bool
+
+	// serializes writes to sinks that are not safe for concurrent use
+	safe   bool
+	serial sync.Mutex
+
+	// set by Async
+	queued                bool
+	queue, workers, batch int
+	async                 *AsyncSink
+}
+
+// FormatFunc renders one entry, trailing newline included.
+type FormatFunc func(level LogType, ts time.Time, msg string, fields []Field) []byte
+
+// Format hands the sink the bytes fn renders instead of the entry, for
+// layouts the package cannot produce. It only applies to sinks that are
+// io.Writers, such as WriterSink and NetworkSink; others get the entry.
+func Format(fn FormatFunc) SinkOption {
+	return func(c *sinkConfig) {
+		c.format = fn
+	}
+}
+
+// AllowFields only lets fields with the given keys through to the sink.
+func AllowFields(keys ...string) SinkOption {
+	return func(c *sinkConfig) {
+		c.allow = keySet(c.allow, keys)
+	}
+}
+
+// DenyFields strips fields with the given keys before entries reach the
+// sink, e.g. personal data for a third-party service.
+func DenyFields(keys ...string) SinkOption {
+	return func(c *sinkConfig) {
+		c.deny = keySet(c.deny, keys)
+	}
+}
+
+// Async queues the entries of the sink and writes them from workers
+// goroutines in batches of up to batch entries, see AsyncSink, so each sink
+// gets the queue depth and batching that suits it. Sinks implementing
+// BatchSink get whole batches. Zeros get the defaults of NewAsyncSink.
+// Flush and Close drain the queue.
+func Async(queue, workers, batch int) SinkOption {
+	return func(c *sinkConfig) {
+		c.queued = true
+		c.queue, c.workers, c.batch = queue, workers, batch
+	}
+}
+
+func keySet(m map[string]bool, keys []string) map[string]bool {
+	if m == nil {
+		m = make(map[string]bool, len(keys))
+	}
+	for _, k := range keys {
+		m[k] = true
+	}
+	return m
+}
+
+// AddSink makes every entry written by l also go to s, after the main
+// output.
+func (l *Logger) AddSink(s Sink, opts ...SinkOption) {
+	c := &sinkConfig{sink: s}
+	if cs, ok := s.(ConcurrentSink); ok {
+		c.safe = cs.ConcurrentSafe()
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if c.queued {
+		i := len(l.sinks)
+		c.async = NewAsyncSink(sinkWorker{c}, c.queue, c.workers, c.batch)
+		c.async.OnError = func(err error) {
+			l.sinkError(i, c, err)
+		}
+	}
+	l.sinks = append(l.sinks, c)
+}
+
+// filter returns e, or a copy of it with only the fields the sink accepts.
+func (c *sinkConfig) filter(e *Entry) *Entry {
+	if c.allow == nil && c.deny == nil {
+		return e
+	}
+
+	f := *e
+	f.Fields = make([]Field, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		if (c.allow == nil || c.allow[field.Key]) && !c.deny[field.Key] {
+			f.Fields = append(f.Fields, field)
+		}
+	}
+	return &f
+}
+
+func (c *sinkConfig) write(e *Entry) error {
+	if c.format != nil {
+		if w, ok := c.sink.(io.Writer); ok {
+			_, err := w.Write(c.format(e.Level, e.Time, e.Message, e.Fields))
+			return err
+		}
+	}
+	return c.sink.WriteEntry(e)
+}
+
+func (c *sinkConfig) writeEntry(e *Entry) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	if !c.routes(e) {
+		return nil
+	}
+	if c.async != nil {
+		return c.async.WriteEntry(c.filter(e))
+	}
+	if !c.safe {
+		c.serial.Lock()
+		defer c.serial.Unlock()
+	}
+	return c.write(c.filter(e))
+}
+
+func (l *Logger) writeSinks(e *Entry) {
+	l.lock.Lock()
+	sinks := l.sinks
+	l.lock.Unlock()
+
+	parallel := 0
+	for _, s := range sinks {
+		if s.parallel() {
+			parallel++
+		}
+	}
+	if parallel > 1 {
+		l.writeParallel(sinks, e)
+		return
+	}
+
+	// every sink gets the entry whatever happens in the others
+	for i, s := range sinks {
+		l.sinkError(i, s, s.writeEntry(e))
+	}
+}
+
+// writeParallel writes e to the safe sinks at the same time.
+func (l *Logger) writeParallel(sinks []*sinkConfig, e *Entry) {
+	var wg sync.WaitGroup
+	for i, s := range sinks {
+		if s.parallel() {
+			wg.Add(1)
+			go func(i int, s *sinkConfig) {
+				defer wg.Done()
+				l.sinkError(i, s, s.writeEntry(e))
+			}(i, s)
+			continue
+		}
+		l.sinkError(i, s, s.writeEntry(e))
+	}
+	wg.Wait()
+}
+
+// parallel reports whether writing to c is worth a goroutine; queueing is
+// not.
+func (c *sinkConfig) parallel() bool {
+	return c.safe && c.async == nil
+}
+
+// sinkWorker is what the AsyncSink of a sink config writes to.
+type sinkWorker struct {
+	c *sinkConfig
+}
+
+func (w sinkWorker) WriteEntry(e *Entry) error {
+	if !w.c.safe {
+		w.c.serial.Lock()
+		defer w.c.serial.Unlock()
+	}
+	return w.c.write(e)
+}
+
+func (w sinkWorker) WriteEntries(entries []*Entry) error {
+	b, ok := w.c.sink.(BatchSink)
+	if !ok || w.c.format != nil {
+		var errs []error
+		for _, e := range entries {
+			if err := w.WriteEntry(e); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	if !w.c.safe {
+		w.c.serial.Lock()
+		defer w.c.serial.Unlock()
+	}
+	return b.WriteEntries(entries)
+}
+
+func (l *Logger) sinkError(i int, s *sinkConfig, err error) {
+	if err != nil {
+		atomic.AddInt64(&l.counters.sinkErrors, 1)
+		l.handleError(&WriteError{sinkName(i, s.sink), err})
+	}
+}
+
+func (l *Logger) closeSinks() []error {
+	l.lock.Lock()
+	sinks := l.sinks
+	l.sinks = nil
+	l.lock.Unlock()
+
+	var errs []error
+	for i, s := range sinks {
+		if s.async != nil {
+			s.async.stop()
+		}
+		if c, ok := s.sink.(io.Closer); ok {
+			err := c.Close()
+			if err != nil {
+				errs = append(errs, &CloseError{sinkName(i, s.sink), err})
+			}
+		}
+	}
+	return errs
+}
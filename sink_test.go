@@ -0,0 +1,35 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func fieldKeys(e *Entry) string {
+	var keys []string
+	for _, f := range e.Fields {
+		keys = append(keys, f.Key)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestSinkFieldLists(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	allow := &entriesSink{}
+	deny := &entriesSink{}
+	l.AddSink(allow, AllowFields("a", "b"), DenyFields("b"))
+	l.AddSink(deny, DenyFields("secret"))
+	l.LogFields(LOG_INFO, "x", F("a", 1), F("b", 2), F("secret", "pw"), F("c", 3))
+
+	if got := fieldKeys(allow.entries[0]); got != "a" {
+		t.Errorf("allow list sink got fields %s, want a", got)
+	}
+	if got := fieldKeys(deny.entries[0]); got != "a,b,c" {
+		t.Errorf("deny list sink got fields %s, want a,b,c", got)
+	}
+	if !strings.Contains(b.String(), "secret=pw") {
+		t.Errorf("output = %q, want all fields", b.String())
+	}
+}
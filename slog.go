@@ -0,0 +1,113 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogHandler is a slog.Handler writing records through a Logger, with its
+// levels, outputs, rotation and sinks. Attributes become fields, those of
+// groups with keys such as "group.key"; the fields NewContext attached to
+// the context of a record come first.
+type SlogHandler struct {
+	l      *Logger
+	fields []Field
+	// prefix of the keys of the open groups, e.g. "a.b."
+	group string
+}
+
+// NewSlogHandler returns a handler writing through l.
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{l: l}
+}
+
+// Slog returns a slog.Logger writing through l.
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(l))
+}
+
+// SlogLevel maps a slog level to the level of its entries: below
+// slog.LevelInfo is debug, below slog.LevelWarn info, below slog.LevelError
+// warning and the rest error.
+func SlogLevel(level slog.Level) LogType {
+	switch {
+	case level < slog.LevelInfo:
+		return LOG_DEBUG
+	case level < slog.LevelWarn:
+		return LOG_INFO
+	case level < slog.LevelError:
+		return LOG_WARNING
+	}
+	return LOG_ERROR
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.l.enabled(SlogLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	t := SlogLevel(r.Level)
+	if !h.l.enabled(t) {
+		return nil
+	}
+
+	e := &Entry{Level: t, Time: r.Time, Message: r.Message}
+	if e.Time.IsZero() {
+		// unlike what slog asks for, entries always have a time
+		e.Time = time.Now()
+	}
+	e.Fields = append(e.Fields, ContextFields(ctx)...)
+	e.Fields = append(e.Fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		e.Fields = appendAttr(e.Fields, h.group, a)
+		return true
+	})
+	var ok bool
+	e.File, e.Line, ok = pcCaller(r.PC)
+	e.pc = r.PC
+	if !ok {
+		// the stack of Handle says nothing about the record
+		e.File, e.Line = "???", 0
+	}
+
+	h.l.output(2, e)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.fields = h.fields[:len(h.fields):len(h.fields)]
+	for _, a := range attrs {
+		c.fields = appendAttr(c.fields, h.group, a)
+	}
+	return &c
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.group += name + "."
+	return &c
+}
+
+// appendAttr appends a as fields, following the rules of slog.Handler:
+// empty attributes are left out and groups without key are inlined.
+func appendAttr(fields []Field, group string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return append(fields, Field{group + a.Key, a.Value.Any()})
+	}
+	if a.Key != "" {
+		group += a.Key + "."
+	}
+	for _, g := range a.Value.Group() {
+		fields = appendAttr(fields, group, g)
+	}
+	return fields
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	l, b := jsonLogger()
+	l.SetLevel(LOG_LEVEL_INFO)
+	s := l.Slog().With("service", "shop").WithGroup("req")
+	ctx := NewContext(context.Background(), nil, F("request_id", 7))
+
+	file, line := here()
+	s.InfoContext(ctx, "done", "status", 200, slog.Group("user", "id", 3), slog.Group("", "inline", true), slog.Attr{})
+	s.Debug("hidden")
+	l.Slog().Warn("slow", "took", time.Second)
+
+	got := decodeLines(t, b)
+	if len(got) != 2 {
+		t.Fatalf("entries = %v", got)
+	}
+	want := map[string]interface{}{
+		"level":       "info",
+		"caller":      file + ":" + strconv.Itoa(line+1),
+		"request_id":  float64(7),
+		"service":     "shop",
+		"req.status":  float64(200),
+		"req.user.id": float64(3),
+		"req.inline":  true,
+	}
+	for k, v := range want {
+		if got[0][k] != v {
+			t.Errorf("%s = %v, want %v", k, got[0][k], v)
+		}
+	}
+	if len(got[0]) != len(want)+2 {
+		t.Errorf("fields %v, want only %v with time and message", got[0], want)
+	}
+	if got[1]["level"] != "warning" || got[1]["took"] == nil {
+		t.Errorf("second entry %v", got[1])
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	for level, want := range map[slog.Level]LogType{
+		slog.LevelDebug - 4: LOG_DEBUG,
+		slog.LevelDebug:     LOG_DEBUG,
+		slog.LevelInfo:      LOG_INFO,
+		slog.LevelInfo + 2:  LOG_INFO,
+		slog.LevelWarn:      LOG_WARNING,
+		slog.LevelError:     LOG_ERROR,
+		slog.LevelError + 4: LOG_ERROR,
+	} {
+		if got := SlogLevel(level); got != want {
+			t.Errorf("SlogLevel(%v) = %v, want %v", level, got, want)
+		}
+	}
+	h := NewSlogHandler(NewLogger(io.Discard, "", 0))
+	h.l.SetLevel(LOG_LEVEL_WARN)
+	if h.Enabled(context.Background(), slog.LevelInfo) || !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Enabled does not follow the level")
+	}
+}
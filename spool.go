@@ -0,0 +1,340 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// spool files are truncated once everything in them is acknowledged and
+// they grew past this size
+const SPOOL_COMPACT_SIZE = 4 << 20
+
+// the high-water mark is persisted at most this often, and on Close; after a
+// crash the entries acknowledged since are sent again
+const SPOOL_MARK_INTERVAL = time.Second
+
+// SpoolSink adds crash-safe delivery bookkeeping in front of a sink such
+// as NetworkSink. Every entry gets a sequence number (Entry.Seq) and is
+// appended to a spool file before delivery; an entry counts as acknowledged
+// once the target's WriteEntry returns nil, and the highest acknowledged
+// sequence number is persisted next to the spool as a high-water mark, at
+// most every SPOOL_MARK_INTERVAL.
+// Entries that could not be delivered are retried, in order, before the
+// next one. After a crash only the entries above the mark are sent again,
+// with their original sequence numbers, so collectors that ingest
+// idempotently by seq see each entry exactly once.
+type SpoolSink struct {
+	path   string
+	target Sink
+
+	spool   *os.File
+	size    int64
+	seq     uint64
+	hwm     uint64
+	backlog []*Entry
+	closed  bool
+
+	// bytes of the backlog accounted with the memory limit. Once spilled
+	// the backlog is not kept in memory and is delivered from the spool.
+	backlogBytes int64
+	spilled      bool
+	// offset in the spool of the first entry a spilled backlog has not
+	// delivered yet
+	readOff int64
+
+	// the high-water mark last persisted and when
+	savedHWM  uint64
+	markSaved time.Time
+
+	lock sync.Mutex
+}
+
+// NewSpoolSink opens (or creates) the spool at path, with the high-water
+// mark in path+".hwm", and starts delivering what is left in it to target.
+func NewSpoolSink(path string, target Sink) (*SpoolSink, error) {
+	s := &SpoolSink{path: path, target: target}
+
+	b, err := os.ReadFile(path + ".hwm")
+	if err == nil {
+		s.hwm, err = strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	s.savedHWM = s.hwm
+
+	err = s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.spool, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := s.spool.Stat(); err == nil {
+		s.size = fi.Size()
+	}
+
+	memory.register(s)
+
+	s.lock.Lock()
+	s.deliver()
+	s.lock.Unlock()
+
+	return s, nil
+}
+
+func (s *SpoolSink) ConcurrentSafe() bool {
+	return true
+}
+
+func (s *SpoolSink) WriteEntry(e *Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return os.ErrClosed
+	}
+
+	c := e.Clone()
+	s.seq++
+	c.Seq = s.seq
+
+	buf := getBuffer()
+	buf.b = encodeJSON(buf.b, c)
+	n, err := s.spool.Write(buf.b)
+	s.size += int64(n)
+	buf.Free()
+	if err != nil {
+		return err
+	}
+
+	s.keep(c)
+	return s.deliver()
+}
+
+// keep adds e to the in-memory backlog, or spills the backlog if the memory
+// limit does not allow it.
+func (s *SpoolSink) keep(e *Entry) {
+	if s.spilled {
+		return
+	}
+	size := int64(entrySize(e))
+	if !memory.reserve(size) {
+		s.spill()
+		return
+	}
+	s.backlog = append(s.backlog, e)
+	s.backlogBytes += size
+}
+
+func (s *SpoolSink) spill() {
+	memory.release(s.backlogBytes)
+	s.backlog = nil
+	s.backlogBytes = 0
+	s.spilled = true
+	// the backlog was not tracked by offset: look for it from the start
+	s.readOff = 0
+}
+
+func (s *SpoolSink) shedMemory(need int64) int64 {
+	if !s.lock.TryLock() {
+		return 0
+	}
+	defer s.lock.Unlock()
+
+	freed := s.backlogBytes
+	if freed > 0 {
+		s.spill()
+	}
+	return freed
+}
+
+// Pending returns the number of spooled entries not acknowledged yet.
+func (s *SpoolSink) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return int(s.seq - s.hwm)
+}
+
+// Close persists the high-water mark and closes the spool and the target
+// if it is an io.Closer. Unacknowledged entries stay in the spool.
+func (s *SpoolSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	memory.unregister(s)
+	memory.release(s.backlogBytes)
+	s.backlog = nil
+	s.backlogBytes = 0
+
+	var errs []error
+	if s.hwm != s.savedHWM {
+		if err := s.saveMark(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := s.spool.Close(); err != nil {
+		errs = append(errs, &CloseError{"spool " + s.path, err})
+	}
+	if c, ok := s.target.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			errs = append(errs, &CloseError{fmt.Sprintf("target (%T)", s.target), err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver sends the backlog in order, stopping at the first failure.
+func (s *SpoolSink) deliver() error {
+	hwm := s.hwm
+	var err error
+	if s.spilled {
+		err = s.deliverSpilled()
+	} else {
+		sent, freed := 0, int64(0)
+		for _, e := range s.backlog {
+			err = s.target.WriteEntry(e)
+			if err != nil {
+				break
+			}
+			s.hwm = e.Seq
+			freed += int64(entrySize(e))
+			sent++
+		}
+
+		n := copy(s.backlog, s.backlog[sent:])
+		for i := n; i < len(s.backlog); i++ {
+			s.backlog[i] = nil
+		}
+		s.backlog = s.backlog[:n]
+		s.backlogBytes -= freed
+		memory.release(freed)
+	}
+	if s.hwm == hwm {
+		return err
+	}
+
+	// the mark must be persisted before the spool is truncated, or the
+	// sequence numbers would start again below it after a crash
+	compact := s.hwm == s.seq && s.size > SPOOL_COMPACT_SIZE
+	if compact || time.Since(s.markSaved) >= SPOOL_MARK_INTERVAL {
+		perr := s.saveMark()
+		if err == nil {
+			err = perr
+		}
+		if perr != nil {
+			compact = false
+		}
+	}
+	if compact && s.spool.Truncate(0) == nil {
+		s.size = 0
+		s.readOff = 0
+	}
+	return err
+}
+
+func (s *SpoolSink) saveMark() error {
+	tmp := s.path + ".hwm.tmp"
+	err := os.WriteFile(tmp, []byte(strconv.FormatUint(s.hwm, 10)+"\n"), 0666)
+	if err == nil {
+		err = os.Rename(tmp, s.path+".hwm")
+	}
+	if err != nil {
+		return err
+	}
+	s.savedHWM = s.hwm
+	s.markSaved = time.Now()
+	return nil
+}
+
+// deliverSpilled sends the unacknowledged entries straight from the spool,
+// reading on from where the last call stopped, and takes the backlog back
+// into memory once they are all delivered.
+func (s *SpoolSink) deliverSpilled() error {
+	var werr error
+	err := s.scan(s.readOff, func(e *Entry, end int64) bool {
+		if e.Seq > s.hwm {
+			werr = s.target.WriteEntry(e)
+			if werr != nil {
+				return false
+			}
+			s.hwm = e.Seq
+		}
+		s.readOff = end
+		return true
+	})
+	if werr != nil {
+		return werr
+	}
+	if err == nil && s.hwm == s.seq {
+		s.spilled = false
+		s.readOff = 0
+	}
+	return err
+}
+
+// load reads the spool, keeping entries above the high-water mark.
+func (s *SpoolSink) load() error {
+	err := s.scan(0, func(e *Entry, end int64) bool {
+		if e.Seq > s.seq {
+			s.seq = e.Seq
+		}
+		if e.Seq > s.hwm {
+			s.keep(e)
+		}
+		return true
+	})
+	if s.hwm > s.seq {
+		s.seq = s.hwm
+	}
+	return err
+}
+
+// scan calls fn with the entries of the spool from offset off in order, and
+// the offset following each, until fn returns false.
+func (s *SpoolSink) scan(off int64, fn func(e *Entry, end int64) bool) error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if off > 0 {
+		_, err = f.Seek(off, io.SeekStart)
+		if err != nil {
+			return err
+		}
+	}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for sc.Scan() {
+		// lines end with a newline but a torn last one
+		off += int64(len(sc.Bytes())) + 1
+		e, err := parseJSON(sc.Bytes())
+		if err != nil {
+			// a torn last line from a crash
+			continue
+		}
+		if !fn(e, off) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
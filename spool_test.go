@@ -0,0 +1,77 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// limitSink accepts limit entries, or any number if limit is negative, and
+// records their sequence numbers.
+type limitSink struct {
+	limit int
+	seqs  []uint64
+}
+
+func (s *limitSink) WriteEntry(e *Entry) error {
+	if s.limit >= 0 && len(s.seqs) >= s.limit {
+		return errors.New("down")
+	}
+	s.seqs = append(s.seqs, e.Seq)
+	return nil
+}
+
+func readMark(t *testing.T, path string) string {
+	b, err := os.ReadFile(path + ".hwm")
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func TestSpoolSpilledReadsOn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool")
+	target := &limitSink{}
+	s, err := NewSpoolSink(path, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Entry{Level: LOG_INFO, Time: time.Now(), Message: "m"}
+	s.WriteEntry(e)
+	s.lock.Lock()
+	s.spill()
+	s.lock.Unlock()
+	s.WriteEntry(e)
+	first := s.size / 2
+
+	target.limit = 1
+	s.WriteEntry(e)
+	if s.readOff != first {
+		t.Errorf("read offset %d after the first entry, want %d", s.readOff, first)
+	}
+
+	target.limit = -1
+	if err := s.WriteEntry(e); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(target.seqs); got != "[1 2 3 4]" {
+		t.Errorf("delivered %s, want [1 2 3 4]", got)
+	}
+	if s.spilled {
+		t.Error("backlog still spilled once delivered")
+	}
+
+	// the mark was saved at the first delivery, then waits for the interval
+	if got := readMark(t, path); got != "1" {
+		t.Errorf("mark %q before Close, want 1", got)
+	}
+	s.Close()
+	if got := readMark(t, path); got != "4" {
+		t.Errorf("mark %q after Close, want 4", got)
+	}
+}
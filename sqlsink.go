@@ -0,0 +1,202 @@
+package log
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store is a Sink that keeps entries in a queryable backend.
+type Store interface {
+	Sink
+	Query(q StoreQuery) ([]Entry, error)
+}
+
+// StoreQuery selects entries from a Store. Zero values do not restrict:
+// From and To bound the entry time (To excluded), Level keeps entries at
+// that level or more severe, Logger matches the logger name, Limit caps
+// the number of entries, newest first.
+type StoreQuery struct {
+	From   time.Time
+	To     time.Time
+	Level  LogType
+	Logger string
+	Limit  int
+}
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLSink is a Store writing entries to a database/sql table with the
+// columns ts (unix nanoseconds), level, logger, caller, message and fields
+// (a JSON object), indexed by time and level. The statements are written
+// for SQLite (see package logsqlite) and also run on MySQL-like databases
+// using ? placeholders.
+type SQLSink struct {
+	db    *sql.DB
+	table string
+
+	insert *sql.Stmt
+	closed bool
+	lock   sync.Mutex
+}
+
+// NewSQLSink creates table and its indexes in db if missing.
+func NewSQLSink(db *sql.DB, table string) (*SQLSink, error) {
+	if !sqlIdentifier.MatchString(table) {
+		return nil, errors.New("log: bad table name " + table)
+	}
+
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+			id INTEGER PRIMARY KEY,
+			ts INTEGER NOT NULL,
+			level INTEGER NOT NULL,
+			logger TEXT NOT NULL,
+			caller TEXT NOT NULL,
+			message TEXT NOT NULL,
+			fields TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_ts ON ` + table + ` (ts)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_level_ts ON ` + table + ` (level, ts)`,
+	} {
+		_, err := db.Exec(stmt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	insert, err := db.Prepare(`INSERT INTO ` + table + ` (ts, level, logger, caller, message, fields) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLSink{db: db, table: table, insert: insert}, nil
+}
+
+func (s *SQLSink) ConcurrentSafe() bool {
+	return true
+}
+
+func (s *SQLSink) WriteEntry(e *Entry) error {
+	caller := ""
+	if e.File != "" && e.File != "???" {
+		caller = e.File + ":" + strconv.Itoa(e.Line)
+	}
+
+	buf := getBuffer()
+	buf.b = appendJSONFields(buf.b, e.Fields)
+	fields := string(buf.b)
+	buf.Free()
+
+	_, err := s.insert.Exec(e.Time.UnixNano(), int(e.Level), e.Name, caller, e.Message, fields)
+	return err
+}
+
+func (s *SQLSink) Query(q StoreQuery) ([]Entry, error) {
+	var where []string
+	var args []interface{}
+	if !q.From.IsZero() {
+		where = append(where, "ts >= ?")
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "ts < ?")
+		args = append(args, q.To.UnixNano())
+	}
+	if q.Level != 0 {
+		// more severe levels have lower values
+		where = append(where, "level <= ?")
+		args = append(args, int(q.Level))
+	}
+	if q.Logger != "" {
+		where = append(where, "logger = ?")
+		args = append(args, q.Logger)
+	}
+
+	query := `SELECT ts, level, logger, caller, message, fields FROM ` + s.table
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY ts DESC, id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(q.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var ts int64
+		var level int
+		var caller, fields string
+		var e Entry
+		err = rows.Scan(&ts, &level, &e.Name, &caller, &e.Message, &fields)
+		if err != nil {
+			return nil, err
+		}
+		e.Time = time.Unix(0, ts)
+		e.Level = LogType(level)
+		if i := strings.LastIndexByte(caller, ':'); i > 0 {
+			e.File = caller[:i]
+			e.Line, _ = strconv.Atoi(caller[i+1:])
+		}
+		e.Fields, err = parseJSONFields(fields)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Close closes the prepared statement; the database is left open.
+func (s *SQLSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.insert.Close()
+}
+
+func appendJSONFields(buf []byte, fields []Field) []byte {
+	buf = append(buf, '{')
+	for i, f := range fields {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, f.Key)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, f.Value)
+	}
+	return append(buf, '}')
+}
+
+func parseJSONFields(s string) ([]Field, error) {
+	var m map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader([]byte(s)))
+	d.UseNumber()
+	err := d.Decode(&m)
+	if err != nil || len(m) == 0 {
+		return nil, err
+	}
+
+	fields := make([]Field, 0, len(m))
+	for k, v := range m {
+		fields = append(fields, Field{k, v})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields, nil
+}
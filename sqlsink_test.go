@@ -0,0 +1,40 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewSQLSinkTableName(t *testing.T) {
+	for _, table := range []string{"", "logs; DROP TABLE users", "1logs", "app-logs"} {
+		if _, err := NewSQLSink(nil, table); err == nil {
+			t.Errorf("table %q accepted", table)
+		}
+	}
+}
+
+func TestSQLFieldsRoundTrip(t *testing.T) {
+	fields := []Field{{"user", "ann"}, {"attempt", 3}, {"ok", true}, {"ratio", 0.5}}
+	s := string(appendJSONFields(nil, fields))
+	if s != `{"user":"ann","attempt":3,"ok":true,"ratio":0.5}` {
+		t.Errorf("encoded = %s", s)
+	}
+
+	got, err := parseJSONFields(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sorted by key, numbers kept exact
+	want := []Field{{"attempt", json.Number("3")}, {"ok", true}, {"ratio", json.Number("0.5")}, {"user", "ann"}}
+	if len(got) != len(want) {
+		t.Fatalf("fields = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if got, err := parseJSONFields("{}"); err != nil || got != nil {
+		t.Errorf("no fields = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,36 @@
+package log
+
+import (
+	"runtime/debug"
+)
+
+// ErrorWithStack logs msg at error level with err and the current goroutine
+// stack attached as the error and stack fields.
+func (l *Logger) ErrorWithStack(err error, msg string) {
+	l.logFields(LOG_ERROR, msg, []Field{
+		{"error", err},
+		{"stack", string(debug.Stack())},
+	})
+}
+
+// SetStackLevel makes every entry at t or more severe carry a stack field.
+// 0 disables it.
+func (l *Logger) SetStackLevel(t LogType) {
+	r := l.root()
+	r.lock.Lock()
+	r.stackLevel = t
+	r.StackLevel = LogTypeToString(t)
+	r.lock.Unlock()
+}
+
+func (l *Logger) needStack(e *Entry) bool {
+	if l.stackLevel == 0 || e.Level > l.stackLevel {
+		return false
+	}
+	for _, f := range e.Fields {
+		if f.Key == "stack" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestErrorWithStack(t *testing.T) {
+	l, b := jsonLogger()
+	l.ErrorWithStack(errTest, "failed")
+
+	got := decodeLines(t, b)
+	if len(got) != 1 {
+		t.Fatalf("%d entries, want 1", len(got))
+	}
+	stack, _ := got[0]["stack"].(string)
+	if got[0]["level"] != "error" || got[0]["error"] != string(errTest) || !strings.Contains(stack, "TestErrorWithStack") {
+		t.Errorf("entry = %v, want the error and a stack of the caller", got[0])
+	}
+}
+
+func TestStackLevel(t *testing.T) {
+	l, b := jsonLogger()
+	l.SetStackLevel(LOG_ERROR)
+	l.Error("error")
+	l.Warning("warning")
+	l.ErrorWithStack(errTest, "own stack")
+	l.SetStackLevel(0)
+	l.Error("off")
+
+	got := decodeLines(t, b)
+	if len(got) != 4 {
+		t.Fatalf("%d entries, want 4", len(got))
+	}
+	for i, want := range []bool{true, false, true, false} {
+		if has := got[i]["stack"] != nil; has != want {
+			t.Errorf("%s: stack %v, want %v", got[i]["message"], has, want)
+		}
+	}
+	if n := strings.Count(b.String(), `"stack"`); n != 2 {
+		t.Errorf("%d stack fields, want 2: an entry with a stack gets no second one", n)
+	}
+	if l.StackLevel != LogTypeToString(0) {
+		t.Errorf("StackLevel = %q after turning it off", l.StackLevel)
+	}
+}
@@ -0,0 +1,63 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// LogStartup writes an info entry summarizing the effective configuration
+// (level, outputs, rotation and retention), the host identity and the build
+// info, so the running setup can be checked from the log alone.
+func (l *Logger) LogStartup() {
+	l.lazyInit()
+
+	r := l.root()
+	c := r.configSummary()
+	fields := []Field{
+		{"level", c["level"]},
+		{"output", c["output"]},
+	}
+	if sinks := c["sinks"].([]string); len(sinks) > 0 {
+		fields = append(fields, Field{"sinks", sinks})
+	}
+	r.lock.Lock()
+	file := r.rw != nil
+	buildInfo := r.BuildInfo
+	r.lock.Unlock()
+	if file {
+		fields = append(fields, Field{"rotate", c["TimeFormat"]})
+		for _, key := range []string{"RotateEvery", "MaxSize", "MaxTotalSize", "MaxBackups", "MaxAge", "Compress"} {
+			fields = append(fields, Field{key, c[key]})
+		}
+	}
+	if c["mirror"] != "none" {
+		fields = append(fields, Field{"mirror", c["mirror"]})
+	}
+
+	host, _ := os.Hostname()
+	fields = append(fields,
+		Field{"host", host},
+		Field{"pid", os.Getpid()},
+		Field{"go", runtime.Version()},
+	)
+	if !buildInfo {
+		fields = append(fields, buildInfoFields()...)
+	}
+
+	l.logFields(LOG_INFO, "startup", fields)
+}
+
+func (l *Logger) outputName() string {
+	if l.rw != nil {
+		return l.rw.Name()
+	}
+	switch w := l._log.Writer(); w {
+	case os.Stdout:
+		return "stdout"
+	case os.Stderr:
+		return "stderr"
+	default:
+		return fmt.Sprintf("%T", w)
+	}
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// lastSink keeps the last entry it got.
+type lastSink struct {
+	e *Entry
+}
+
+func (s *lastSink) WriteEntry(e *Entry) error {
+	s.e = e.Clone()
+	return nil
+}
+
+func TestLogStartup(t *testing.T) {
+	l := NewLogger(io.Discard, "", 0)
+	l.MaxSize = 10
+	l.MaxBackups = 3
+	l.MaxAge = "168h"
+	if err := l.SetOutputByName(filepath.Join(t.TempDir(), "app")); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := &lastSink{}
+	l.AddSink(s)
+
+	l.LogStartup()
+	if s.e == nil {
+		t.Fatal("no startup entry")
+	}
+	got := make(map[string]string)
+	for _, f := range s.e.Fields {
+		got[f.Key] = fmt.Sprint(f.Value)
+	}
+	for key, want := range map[string]string{
+		"level":        LogLevelToString(l.Level()),
+		"sinks":        "[*log.lastSink]",
+		"rotate":       FORMAT_TIME_DAY,
+		"MaxSize":      "10",
+		"MaxBackups":   "3",
+		"MaxAge":       "168h",
+		"Compress":     "",
+		"RotateEvery":  "",
+		"MaxTotalSize": "0",
+	} {
+		v, ok := got[key]
+		if !ok || v != want {
+			t.Errorf("%s = %q (present %v), want %q", key, v, ok, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package log
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// Stats are counters of a root logger and all its children since creation.
+type Stats struct {
+	// Entries counts written entries by level name
+	Entries     map[string]int64
+	WriteErrors int64
+	SinkErrors  int64
+	// Sampled counts entries dropped by sampling and SetEveryN, Suppressed
+	// those held back by cooldowns
+	Sampled    int64
+	Suppressed int64
+	// RateLimited counts entries dropped by SetRateLimit
+	RateLimited int64
+
+	// Memory is the approximate memory held by the package across all
+	// loggers, MemoryDropped the entries dropped to stay under
+	// SetMemoryLimit
+	Memory        int64
+	MemoryDropped int64
+	Buffers       BufferStats
+}
+
+type counters struct {
+	entries     [5]int64
+	writeErrors int64
+	sinkErrors  int64
+	sampled     int64
+	suppressed  int64
+	rateLimited int64
+}
+
+func (c *counters) entry(t LogType) {
+	if i := bits.TrailingZeros(uint(t)); i < len(c.entries) {
+		atomic.AddInt64(&c.entries[i], 1)
+	}
+}
+
+func (l *Logger) Stats() Stats {
+	c := &l.root().counters
+
+	s := Stats{
+		Entries:     make(map[string]int64, len(c.entries)),
+		WriteErrors: atomic.LoadInt64(&c.writeErrors),
+		SinkErrors:  atomic.LoadInt64(&c.sinkErrors),
+		Sampled:     atomic.LoadInt64(&c.sampled),
+		Suppressed:  atomic.LoadInt64(&c.suppressed),
+		RateLimited: atomic.LoadInt64(&c.rateLimited),
+
+		Memory:        atomic.LoadInt64(&memory.used),
+		MemoryDropped: atomic.LoadInt64(&memory.dropped),
+		Buffers:       BufferPoolStats(),
+	}
+	for i := range c.entries {
+		s.Entries[LogTypeToString(LogType(1<<uint(i)))] = atomic.LoadInt64(&c.entries[i])
+	}
+	return s
+}
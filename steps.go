@@ -0,0 +1,45 @@
+package log
+
+import (
+	"strings"
+	"time"
+)
+
+// Steps times the phases of a multi-phase operation. Each call to Step logs
+// the phase that just finished with its own and the cumulative duration;
+// Done logs a summary of all phases. A Steps is not safe for concurrent use.
+type Steps struct {
+	l     *Logger
+	name  string
+	start time.Time
+	last  time.Time
+	done  []string
+}
+
+func (l *Logger) Steps(name string) *Steps {
+	now := time.Now()
+	return &Steps{l: l, name: name, start: now, last: now}
+}
+
+// Step records the end of the phase called step.
+func (s *Steps) Step(step string) {
+	now := time.Now()
+	d := now.Sub(s.last)
+	s.last = now
+	s.done = append(s.done, step+"="+d.String())
+
+	s.l.logFields(LOG_INFO, s.name+" step done", []Field{
+		{"step", step},
+		{"duration", d},
+		{"elapsed", now.Sub(s.start)},
+	})
+}
+
+// Done logs the total duration and the duration of each recorded step.
+func (s *Steps) Done() {
+	s.l.logFields(LOG_INFO, s.name+" done", []Field{
+		{"steps", len(s.done)},
+		{"total", time.Since(s.start)},
+		{"durations", strings.Join(s.done, ",")},
+	})
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSteps(t *testing.T) {
+	l, b := jsonLogger()
+	file, start := here()
+	s := l.Steps("deploy")
+	time.Sleep(5 * time.Millisecond)
+	s.Step("build")
+	s.Step("push")
+	s.Done()
+
+	got := decodeLines(t, b)
+	if len(got) != 3 {
+		t.Fatalf("%d entries, want 3: %s", len(got), b.String())
+	}
+	for i, step := range []string{"build", "push"} {
+		if got[i]["message"] != "deploy step done" || got[i]["step"] != step {
+			t.Errorf("entry %d = %v %v, want step %s", i, got[i]["message"], got[i]["step"], step)
+		}
+		if want := file + ":" + strconv.Itoa(start+3+i); got[i]["caller"] != want {
+			t.Errorf("%s: caller %v, want %s", step, got[i]["caller"], want)
+		}
+	}
+	if d, err := time.ParseDuration(got[0]["duration"].(string)); err != nil || d < 5*time.Millisecond {
+		t.Errorf("build took %v (%v), want at least 5ms", got[0]["duration"], err)
+	}
+
+	done := got[2]
+	durations, _ := done["durations"].(string)
+	if done["message"] != "deploy done" || done["steps"] != float64(2) ||
+		!strings.HasPrefix(durations, "build=") || !strings.Contains(durations, ",push=") {
+		t.Errorf("summary = %v", done)
+	}
+}
@@ -0,0 +1,105 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is what a TemplateFormatter template is executed with.
+type TemplateData struct {
+	// Level is the default name of the level, e.g. "info"
+	Level string
+	Time  time.Time
+	Msg   string
+	// Caller is file:line, or "" when not known
+	Caller string
+	// Name is the name of the child logger that wrote the entry
+	Name   string
+	Fields []Field
+}
+
+// Field returns the value of the field key, or "" if the entry has none.
+func (d *TemplateData) Field(key string) interface{} {
+	for _, f := range d.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// TemplateFormatter is a Formatter rendering entries with a text/template,
+// for matching a legacy line format exactly, e.g.
+//
+//	{{.Time.Format "2006-01-02 15:04:05.000"}} {{upper .Level | printf "%-5s"}} [{{.Caller}}] {{.Msg}}{{fields .Fields}}
+//
+// Besides the standard functions, templates can use upper and lower, fields
+// (the fields as " key=value" pairs, like the text format) and json (a
+// value, or the fields as one object, as JSON).
+type TemplateFormatter struct {
+	t *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"fields": func(fields []Field) string {
+		return string(appendFields(nil, fields))
+	},
+	"json": func(v interface{}) string {
+		if fields, ok := v.([]Field); ok {
+			return string(appendJSONFields(nil, fields))
+		}
+		return string(appendJSONValue(nil, v))
+	},
+}
+
+// NewTemplateFormatter parses text as the template of every line.
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	if text == "" {
+		return nil, errors.New("empty Template")
+	}
+	t, err := template.New("line").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFormatter{t}, nil
+}
+
+// Format renders a line without fields of its own: they are part of msg.
+func (f *TemplateFormatter) Format(level LogType, t time.Time, caller string, msg string) []byte {
+	b, err := f.execute(nil, &TemplateData{Level: LogTypeToString(level), Time: t, Msg: msg, Caller: caller})
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (f *TemplateFormatter) formatEntry(buf []byte, e *Entry, caller string) ([]byte, error) {
+	return f.execute(buf, &TemplateData{
+		Level:  LogTypeToString(e.Level),
+		Time:   e.Time,
+		Msg:    e.Message,
+		Caller: caller,
+		Name:   e.Name,
+		Fields: e.Fields,
+	})
+}
+
+func (f *TemplateFormatter) execute(buf []byte, d *TemplateData) ([]byte, error) {
+	w := &byteWriter{buf}
+	err := f.t.Execute(w, d)
+	return w.b, err
+}
+
+// byteWriter appends what is written to it to b.
+type byteWriter struct {
+	b []byte
+}
+
+func (w *byteWriter) Write(p []byte) (int, error) {
+	w.b = append(w.b, p...)
+	return len(p), nil
+}
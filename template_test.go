@@ -0,0 +1,56 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	f, err := NewTemplateFormatter(`{{upper .Level | printf "%-5s"}} {{.Name}} [{{.Caller}}] {{.Msg}}{{fields .Fields}} user={{.Field "user"}} {{json .Fields}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	l := NewLogger(&b, "", Lshortfile)
+	l.SetFormatter(f)
+	_, line := here()
+	l.Named("api").LogFields(LOG_WARNING, "slow", F("ms", 30), F("user", "ann"))
+
+	want := fmt.Sprintf(`WARNING api [template_test.go:%d] slow ms=30 user=ann user=ann {"ms":30,"user":"ann"}`+"\n", line+1)
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	l.Info("no fields")
+	if got := b.String(); !strings.HasPrefix(got, "INFO   [") || !strings.HasSuffix(got, "] no fields  user= {}\n") {
+		t.Errorf("output = %q", got)
+	}
+
+	got := string(f.Format(LOG_ERROR, time.Time{}, "main.go:1", "failed"))
+	if got != "ERROR  [main.go:1] failed user= {}" {
+		t.Errorf("Format = %q", got)
+	}
+}
+
+func TestTemplateConfig(t *testing.T) {
+	l := &Logger{}
+	if err := l.Init(`{"Template": "{{.Msg"}`); err == nil {
+		t.Error("Init accepted a broken template")
+	}
+	if _, err := NewTemplateFormatter(""); err == nil {
+		t.Error("NewTemplateFormatter accepted an empty template")
+	}
+
+	var b bytes.Buffer
+	l = NewLogger(&b, "", 0)
+	f, _ := NewTemplateFormatter(`{{index .Fields 3}}`)
+	l.SetFormatter(f)
+	stderr := captureStderr(t, func() { l.Info("kept") })
+	if b.String() != "[info] kept \n" || !strings.Contains(stderr, "template failed") {
+		t.Errorf("failing template: output %q, stderr %q", b.String(), stderr)
+	}
+}
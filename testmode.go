@@ -0,0 +1,61 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"sync/atomic"
+)
+
+// TestingT is the part of testing.TB TestMode uses.
+type TestingT interface {
+	Log(args ...interface{})
+	Error(args ...interface{})
+	FailNow()
+	Cleanup(func())
+}
+
+// TestMode makes the default logger write through t.Log for the rest of
+// the test, so its output shows with -v or when the test fails, and
+// returns it. Fatal entries fail the test instead of exiting the process;
+// like t.Fatal, they must come from the goroutine running the test to stop
+// it. The previous default logger is restored when the test ends, and
+// entries written after that are dropped. Loggers obtained with GetLogger
+// before the call keep writing to the previous one.
+func TestMode(t TestingT) *Logger {
+	tw := &testWriter{t: t}
+	l := NewLogger(tw, "", Lshortfile)
+	l.onFatal = func() {
+		t.Error("log: fatal entry")
+		t.FailNow()
+	}
+
+	prev := Default()
+	SetDefault(l)
+	t.Cleanup(func() {
+		SetDefault(prev)
+		atomic.StoreInt32(&tw.done, 1)
+	})
+	return l
+}
+
+func (l *Logger) exit() {
+	if f := l.root().onFatal; f != nil {
+		f()
+		return
+	}
+	os.Exit(-1)
+}
+
+// testWriter writes every entry as a t.Log call.
+type testWriter struct {
+	t    TestingT
+	done int32 // accessed atomically
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	// t.Log panics once the test has ended
+	if atomic.LoadInt32(&w.done) == 0 {
+		w.t.Log(string(bytes.TrimSuffix(p, []byte("\n"))))
+	}
+	return len(p), nil
+}
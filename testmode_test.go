@@ -0,0 +1,47 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+// fakeT records what TestMode does with a test.
+type fakeT struct {
+	logs, errors []string
+	failed       bool
+	cleanups     []func()
+}
+
+func (t *fakeT) Log(args ...interface{})   { t.logs = append(t.logs, args[0].(string)) }
+func (t *fakeT) Error(args ...interface{}) { t.errors = append(t.errors, args[0].(string)) }
+func (t *fakeT) FailNow()                  { t.failed = true }
+func (t *fakeT) Cleanup(fn func())         { t.cleanups = append(t.cleanups, fn) }
+
+func TestTestMode(t *testing.T) {
+	prev := Default()
+	ft := &fakeT{}
+	l := TestMode(ft)
+	if Default() != l {
+		t.Fatal("TestMode does not replace the default logger")
+	}
+
+	l.Info("ready")
+	l.Fatal("broken")
+	if len(ft.logs) != 2 || !strings.HasSuffix(ft.logs[0], "[info] ready ") || !strings.Contains(ft.logs[0], "testmode_test.go:") {
+		t.Errorf("logs = %q", ft.logs)
+	}
+	if !ft.failed || len(ft.errors) != 1 {
+		t.Errorf("fatal entry: failed %v, errors %q", ft.failed, ft.errors)
+	}
+
+	for _, fn := range ft.cleanups {
+		fn()
+	}
+	if Default() != prev {
+		t.Error("the previous default logger is not restored")
+	}
+	l.Info("after the test")
+	if len(ft.logs) != 2 {
+		t.Errorf("logged after the test ended: %q", ft.logs)
+	}
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	formatProbe1 = time.Date(2001, 2, 3, 4, 5, 6, 7e6, time.UTC)
+	formatProbe2 = time.Date(2012, 11, 22, 16, 17, 18, 19e6, time.UTC)
+)
+
+// hasTimeElements reports whether layout actually formats a time rather than
+// being a constant string.
+func hasTimeElements(layout string) bool {
+	return formatProbe1.Format(layout) != formatProbe2.Format(layout)
+}
+
+func validateRotateFormat(format string) error {
+	if !hasTimeElements(format) {
+		return fmt.Errorf("rotation time format %q has no time elements", format)
+	}
+	if strings.ContainsAny(formatProbe2.Format(format), `/\`) {
+		return fmt.Errorf("rotation time format %q would put path separators in file names", format)
+	}
+	return nil
+}
+
+func validateEntryTimeFormat(format string) error {
+	if format != "" && !hasTimeElements(format) {
+		return fmt.Errorf("entry time format %q has no time elements", format)
+	}
+	return nil
+}
+
+// parseRotateEvery reads a RotateEvery duration such as "6h".
+func parseRotateEvery(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid RotateEvery %q, want a positive duration like \"6h\"", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntryTimeFormat(t *testing.T) {
+	fs := newMemFS()
+	w := memWriter(t, fs, nil)
+	l := NewLogger(w, "", LstdFlags)
+	if err := l.SetEntryTimeFormat("constant"); err == nil {
+		t.Error("format without time elements accepted")
+	}
+	if err := l.SetEntryTimeFormat("2006-01-02T15:04"); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	l.Info("entry")
+
+	// the file keeps its name from TimeFormat
+	checkNames(t, fs, today())
+	got := fs.read(w.Name())
+	stamp, _, _ := strings.Cut(got, " ")
+	if at, err := time.ParseInLocation("2006-01-02T15:04", stamp, time.Local); err != nil || before.Sub(at) > time.Minute {
+		t.Errorf("entry %q does not start with the entry time format", got)
+	}
+
+	var b bytes.Buffer
+	l = NewLogger(&b, "", 0)
+	l.SetEntryTimeFormat("2006")
+	l.SetEntryTimeFormat("")
+	l.Info("flags")
+	if b.String() != "[info] flags \n" {
+		t.Errorf("output = %q, want the flags to decide again", b.String())
+	}
+}
+
+func TestValidateRotateFormat(t *testing.T) {
+	for format, ok := range map[string]bool{
+		FORMAT_TIME_DAY: true,
+		"2006-01-02T15": true,
+		"daily":         false,
+		"2006/01/02":    false,
+	} {
+		if err := validateRotateFormat(format); (err == nil) != ok {
+			t.Errorf("%q: err = %v, want ok %v", format, err, ok)
+		}
+	}
+}
+
+func TestRotateEvery(t *testing.T) {
+	for s, want := range map[string]time.Duration{"": 0, "6h": 6 * time.Hour, "90m": 90 * time.Minute, "0s": -1, "-1h": -1, "6": -1} {
+		d, err := parseRotateEvery(s)
+		if want < 0 && err == nil || want >= 0 && (err != nil || d != want) {
+			t.Errorf("parseRotateEvery(%q) = %v, %v", s, d, err)
+		}
+	}
+
+	w := &RotatingWriter{TimeFormat: FORMAT_TIME_HOUR, Every: 6 * time.Hour, UTC: true}
+	now := time.Date(2024, 5, 1, 13, 20, 0, 0, time.UTC)
+	if got := w.nowSuffix(now); got != "2024050112" {
+		t.Errorf("suffix at 13:20 = %s, want the period starting at 12:00", got)
+	}
+
+	fs := newMemFS()
+	rw := memWriter(t, fs, nil)
+	l := NewLogger(rw, "", 0)
+	l.SetOutput(rw)
+	l.Named("cmd").SetRotateEvery(6 * time.Hour)
+	if l.RotateEvery != "6h0m0s" || rw.Every != 6*time.Hour {
+		t.Errorf("RotateEvery = %q, Every = %v after SetRotateEvery on a child", l.RotateEvery, rw.Every)
+	}
+	l.SetRotateEvery(0)
+	if l.RotateEvery != "" || rw.Every != 0 {
+		t.Errorf("RotateEvery = %q, Every = %v after SetRotateEvery(0)", l.RotateEvery, rw.Every)
+	}
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// how long UnixSink waits after a failed dial before dialing again
+const DEFAULT_UNIX_RETRY_INTERVAL = time.Second
+
+// UnixSink writes entries to a local socket, e.g. one exposed by a sidecar
+// log collector. With "unix" (SOCK_STREAM) entries are newline-terminated
+// lines, with "unixgram" (SOCK_DGRAM) each entry is one datagram. The
+// socket is dialed again when a write fails, so a collector restart only
+// loses the entries written while it was down.
+type UnixSink struct {
+	Network string // unix or unixgram
+	Path    string
+	// JSON writes entries as JSON objects instead of text lines
+	JSON          bool
+	DialTimeout   time.Duration
+	RetryInterval time.Duration
+
+	conn    net.Conn
+	failed  time.Time
+	dialErr error
+	closed  bool
+	lock    sync.Mutex
+}
+
+func NewUnixSink(network, path string) (*UnixSink, error) {
+	if network != "unix" && network != "unixgram" {
+		return nil, errors.New("log: unsupported socket network " + network)
+	}
+	return &UnixSink{
+		Network:       network,
+		Path:          path,
+		DialTimeout:   DEFAULT_DIAL_TIMEOUT,
+		RetryInterval: DEFAULT_UNIX_RETRY_INTERVAL,
+	}, nil
+}
+
+func (s *UnixSink) ConcurrentSafe() bool {
+	return true
+}
+
+func (s *UnixSink) WriteEntry(e *Entry) error {
+	buf := getBuffer()
+	if s.JSON {
+		buf.b = encodeJSON(buf.b, e)
+	} else {
+		enc := textEncoder{timeFormat: time.RFC3339Nano, flags: Lshortfile}
+		buf.b = enc.encode(buf.b, e)
+	}
+	_, err := s.Write(buf.b)
+	buf.Free()
+
+	return err
+}
+
+// Write sends p as is, dialing again once if the connection broke.
+func (s *UnixSink) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+
+	var err error
+	for try := 0; try < 2; try++ {
+		if s.conn == nil {
+			err = s.connect()
+			if err != nil {
+				return 0, err
+			}
+		}
+		var n int
+		n, err = s.conn.Write(p)
+		if err == nil {
+			return n, nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return 0, err
+}
+
+// connect dials the socket, unless the last dial failed less than
+// RetryInterval ago.
+func (s *UnixSink) connect() error {
+	if !s.failed.IsZero() && time.Since(s.failed) < s.RetryInterval {
+		return s.dialErr
+	}
+	timeout := s.DialTimeout
+	if timeout <= 0 {
+		timeout = DEFAULT_DIAL_TIMEOUT
+	}
+	conn, err := net.DialTimeout(s.Network, s.Path, timeout)
+	if err != nil {
+		s.failed, s.dialErr = time.Now(), err
+		return err
+	}
+	s.conn = conn
+	s.failed, s.dialErr = time.Time{}, nil
+	return nil
+}
+
+func (s *UnixSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
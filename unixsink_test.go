@@ -0,0 +1,107 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// socketPath returns a path short enough for a socket address.
+func socketPath(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "s")
+}
+
+func TestUnixSinkDatagram(t *testing.T) {
+	path := socketPath(t)
+	c, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer c.Close()
+
+	s, err := NewUnixSink("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.JSON = true
+	l := NewLogger(&strings.Builder{}, "", 0)
+	l.AddSink(s)
+	l.Info("one")
+	l.Info("two")
+	l.Close()
+
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	for _, want := range []string{"one", "two"} {
+		n, err := c.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); !strings.HasPrefix(got, "{") || !strings.Contains(got, `"message":"`+want) {
+			t.Errorf("datagram = %q, want the %s entry", got, want)
+		}
+	}
+	if _, err := s.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after Close: %v", err)
+	}
+}
+
+func TestUnixSinkReconnects(t *testing.T) {
+	if _, err := NewUnixSink("tcp", "x"); err == nil {
+		t.Error("NewUnixSink accepted tcp")
+	}
+	path := socketPath(t)
+	s, _ := NewUnixSink("unix", path)
+	defer s.Close()
+
+	// nothing listens yet; the failed dial is not repeated at once
+	e := &Entry{Level: LOG_INFO, Message: "lost"}
+	if err := s.WriteEntry(e); err == nil {
+		t.Fatal("write without a collector succeeded")
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	if err := s.WriteEntry(e); err == nil {
+		t.Error("dialed again within RetryInterval")
+	}
+	s.lock.Lock()
+	s.failed = time.Now().Add(-time.Hour)
+	s.lock.Unlock()
+
+	for i, msg := range []string{"first", "second"} {
+		if err := s.WriteEntry(&Entry{Level: LOG_INFO, Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil || !strings.Contains(line, "[info] "+msg) {
+			t.Errorf("line = %q, %v", line, err)
+		}
+		conn.Close()
+		if i == 0 {
+			// the collector restarts
+			ln.Close()
+			ln, err = net.Listen("unix", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ln.Close()
+}
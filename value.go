@@ -0,0 +1,94 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// how nil interfaces and nil pointers appear in text output; JSON uses null
+const NIL_VALUE = "<nil>"
+
+// isNil reports whether v is nil or a nil pointer. Methods of nil pointers
+// are never called, so a typed nil error renders like an untyped one.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// errorString returns err.Error(), reporting a panicking Error method the
+// way fmt does instead of crashing the caller.
+func errorString(err error) (s string) {
+	defer func() {
+		if p := recover(); p != nil {
+			s = fmt.Sprintf("<PANIC=Error method: %v>", p)
+		}
+	}()
+	return err.Error()
+}
+
+// rendered stands in for an operand already turned into text. It is not a
+// string, so JoinSprint spaces it like the value it replaces.
+type rendered struct {
+	s string
+}
+
+func (r rendered) String() string {
+	return r.s
+}
+
+// operands renders nil pointers and errors among the operands of a
+// Print-style call the same way fields are rendered.
+func operands(v []interface{}) []interface{} {
+	var out []interface{}
+	for i, a := range v {
+		var s string
+		switch {
+		case a == nil:
+			continue
+		case isNil(a):
+			s = NIL_VALUE
+		default:
+			err, ok := a.(error)
+			if !ok {
+				continue
+			}
+			s = errorString(err)
+		}
+		if out == nil {
+			out = append([]interface{}(nil), v...)
+		}
+		out[i] = rendered{s}
+	}
+	if out == nil {
+		return v
+	}
+	return out
+}
+
+// appendOperand formats v like fmt.Sprint(v), without going through fmt
+// for the most common types.
+func appendOperand(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return append(buf, v...)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	}
+	return fmt.Append(buf, v)
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type panicError struct{}
+
+func (*panicError) Error() string {
+	panic("boom")
+}
+
+func TestNilOperands(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	var p *int
+	var nilErr *panicError
+	l.Info("p", p, "err", nilErr, errors.New("failed"))
+	l.Info("bad", &panicError{})
+
+	want := "[info] p <nil> err <nil> failed \n[info] bad <PANIC=Error method: boom> \n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestNilFieldsJSON(t *testing.T) {
+	l, b := jsonLogger()
+	var p *int
+	var nilErr *panicError
+	l.LogFields(LOG_INFO, "done", F("p", p), F("err", nilErr))
+
+	got := decodeLines(t, b)
+	if len(got) != 1 {
+		t.Fatalf("entries = %v", got)
+	}
+	for _, k := range []string{"p", "err"} {
+		if v, ok := got[0][k]; !ok || v != nil {
+			t.Errorf("%s = %v, want null", k, v)
+		}
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	l, b := jsonLogger()
+	l.Named("cmd").SetExpandErrors(true)
+	l.Error("open config", errors.New("not found"), "retrying", errors.New("denied"))
+	l.SetExpandErrors(false)
+	l.Error("open config", errors.New("not found"))
+
+	got := decodeLines(t, b)
+	if len(got) != 2 {
+		t.Fatalf("entries = %v", got)
+	}
+	e := got[0]
+	if msg, _ := e["message"].(string); strings.Contains(msg, "not found") || !strings.Contains(msg, "retrying") {
+		t.Errorf("message = %q", msg)
+	}
+	if e["error"] != "not found" || e["error2"] != "denied" || e["stack"] == nil {
+		t.Errorf("fields = %v", e)
+	}
+	if msg, _ := got[1]["message"].(string); !strings.Contains(msg, "not found") || got[1]["error"] != nil {
+		t.Errorf("without ExpandErrors: %v", got[1])
+	}
+}
+
+func TestSprintlnLayout(t *testing.T) {
+	type point struct{ X, Y int }
+	v := []interface{}{"id", 7, int64(-3), uint32(9), true, 1.5, point{1, 2}, []byte("b")}
+	want := fmt.Sprintln(append(v, "")...)
+	if got := sprintln(v); got != want[:len(want)-1] {
+		t.Errorf("sprintln = %q, want %q", got, want[:len(want)-1])
+	}
+	if got := JoinWith("|")(v); got != "id|7|-3|9|true|1.5|{1 2}|[98]" {
+		t.Errorf("JoinWith = %q", got)
+	}
+	if got := sprintf("%d items\n", []interface{}{3}); got != "3 items" {
+		t.Errorf("sprintf = %q", got)
+	}
+	if n := testing.AllocsPerRun(100, func() { sprintln([]interface{}{"id", 7}) }); n > 1 {
+		t.Errorf("sprintln allocates %v times, want only the message", n)
+	}
+}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"strings"
+)
+
+// FlagProvider evaluates feature flags, e.g. through an adapter for the
+// flag service in use. scope holds the fields of the entry, so a flag can
+// be on for some users or tenants only.
+type FlagProvider interface {
+	Enabled(flag string, scope []Field) bool
+}
+
+// FlagProviderFunc adapts a function to FlagProvider.
+type FlagProviderFunc func(flag string, scope []Field) bool
+
+func (f FlagProviderFunc) Enabled(flag string, scope []Field) bool {
+	return f(flag, scope)
+}
+
+type verbosityFlags struct {
+	p     FlagProvider
+	areas map[string]string
+	level LogLevel
+}
+
+// SetVerbosityFlags lets feature flags raise the verbosity of named areas.
+// areas maps logger names, as given by Named, to flags: entries of a logger
+// in an area ("db" covers "db" and "db.pool", "" covers all) that its level
+// filters out but level allows, e.g. LOG_LEVEL_DEBUG, are written if p
+// reports the flag of the most specific area on for their fields. p is
+// asked for every such entry, so flag changes apply right away; it must be
+// fast and must not log. A nil p turns verbosity flags off.
+func (l *Logger) SetVerbosityFlags(p FlagProvider, areas map[string]string, level LogLevel) {
+	var v *verbosityFlags
+	if p != nil {
+		v = &verbosityFlags{p: p, areas: make(map[string]string, len(areas)), level: level}
+		for area, flag := range areas {
+			v.areas[area] = flag
+		}
+	}
+	l.root().verbosity.Store(v)
+}
+
+func (l *Logger) verbosityFlags() *verbosityFlags {
+	v, _ := l.root().verbosity.Load().(*verbosityFlags)
+	return v
+}
+
+// flag returns the flag of the most specific area name is in.
+func (v *verbosityFlags) flag(name string) (string, bool) {
+	for {
+		if flag, ok := v.areas[name]; ok {
+			return flag, true
+		}
+		if name == "" {
+			return "", false
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			i = 0
+		}
+		name = name[:i]
+	}
+}
+
+// covers reports whether entries of level t written under name may pass
+// through a flag.
+func (v *verbosityFlags) covers(name string, t LogType) bool {
+	if v.level|LogLevel(t) != v.level {
+		return false
+	}
+	_, ok := v.flag(name)
+	return ok
+}
+
+// enabled asks the provider about e, written under name. A panicking
+// provider counts as off.
+func (v *verbosityFlags) enabled(name string, e *Entry) (on bool) {
+	if !v.covers(name, e.Level) {
+		return false
+	}
+	flag, _ := v.flag(name)
+	defer func() {
+		if recover() != nil {
+			on = false
+		}
+	}()
+	return v.p.Enabled(flag, e.Fields)
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVerbosityFlags(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(&b, "", 0)
+	l.SetLevel(LOG_LEVEL_INFO)
+	var asked []string
+	l.SetVerbosityFlags(FlagProviderFunc(func(flag string, scope []Field) bool {
+		asked = append(asked, flag)
+		for _, f := range scope {
+			if f.Key == "tenant" {
+				if f.Value == "bad" {
+					panic("flag service down")
+				}
+				return f.Value == "t1"
+			}
+		}
+		return false
+	}), map[string]string{"db": "debug-db", "db.pool": "debug-pool"}, LOG_LEVEL_DEBUG)
+
+	db, pool, api := l.Named("db"), l.Named("db").Named("pool"), l.Named("api")
+	if !db.IsLevelEnabled(LOG_DEBUG) || api.IsLevelEnabled(LOG_DEBUG) {
+		t.Error("IsLevelEnabled does not account for the areas")
+	}
+	db.LogFields(LOG_DEBUG, "query", F("tenant", "t1"))
+	db.LogFields(LOG_DEBUG, "query", F("tenant", "t2"))
+	db.LogFields(LOG_DEBUG, "query", F("tenant", "bad"))
+	pool.LogFields(LOG_DEBUG, "conn", F("tenant", "t1"))
+	api.LogFields(LOG_DEBUG, "request", F("tenant", "t1"))
+	db.Info("always")
+
+	want := "[debug] [db] query tenant=t1\n[debug] [db.pool] conn tenant=t1\n[info] [db] always \n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+	// the most specific area decides; enabled levels never ask
+	if got := strings.Join(asked, " "); got != "debug-db debug-db debug-db debug-pool" {
+		t.Errorf("flags asked: %s", got)
+	}
+
+	l.SetVerbosityFlags(nil, nil, 0)
+	b.Reset()
+	db.LogFields(LOG_DEBUG, "query", F("tenant", "t1"))
+	if b.Len() != 0 || db.IsLevelEnabled(LOG_DEBUG) {
+		t.Errorf("output %q with verbosity flags off", b.String())
+	}
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"errors"
+)
+
+type wrappedError struct {
+	msg    string
+	err    error
+	fields []Field
+}
+
+func (e *wrappedError) Error() string {
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *wrappedError) Unwrap() error {
+	return e.err
+}
+
+// Wrap logs msg at error level with err and fields, and returns err wrapped
+// with msg. The fields travel with the returned error and can be read back
+// with ErrorFields further up the stack. fields are alternating keys and
+// values or Field values. A nil err is returned as is without logging.
+func (l *Logger) Wrap(err error, msg string, fields ...interface{}) error {
+	return l.wrap(err, msg, fields)
+}
+
+// Wrap calls Wrap on the default logger.
+func Wrap(err error, msg string, fields ...interface{}) error {
+	return Default().wrap(err, msg, fields)
+}
+
+// wrap is called by both Wrap, so their callers are two frames up.
+func (l *Logger) wrap(err error, msg string, fields []interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	w := &wrappedError{msg: msg, err: err, fields: toFields(fields)}
+
+	entryFields := append([]Field(nil), w.fields...)
+	entryFields = append(entryFields, Field{"error", err})
+	entryFields = append(entryFields, ErrorFields(err)...)
+	l.LogFieldsSkip(2, LOG_ERROR, msg, entryFields...)
+
+	return w
+}
+
+// ErrorFields returns the fields attached by Wrap anywhere in err's chain,
+// outermost first.
+func ErrorFields(err error) []Field {
+	var fields []Field
+	for err != nil {
+		if w, ok := err.(*wrappedError); ok {
+			fields = append(fields, w.fields...)
+		}
+		err = errors.Unwrap(err)
+	}
+	return fields
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	l, b := jsonLogger()
+	if err := l.Wrap(nil, "nothing"); err != nil || b.Len() != 0 {
+		t.Fatalf("nil error: %v, output %q", err, b.String())
+	}
+
+	inner := l.Wrap(errTest, "read config", "path", "/etc/app")
+	outer := fmt.Errorf("start: %w", l.Wrap(inner, "load", F("attempt", 2)))
+	if !errors.Is(outer, errTest) {
+		t.Error("wrapped error does not unwrap to the original")
+	}
+	if inner.Error() != "read config: "+string(errTest) {
+		t.Errorf("Error() = %q", inner.Error())
+	}
+	fields := ErrorFields(outer)
+	if len(fields) != 2 || fields[0] != F("attempt", 2) || fields[1] != F("path", "/etc/app") {
+		t.Errorf("ErrorFields = %v, want outermost first", fields)
+	}
+
+	got := decodeLines(t, b)
+	if len(got) != 2 {
+		t.Fatalf("%d entries, want 2", len(got))
+	}
+	// the second entry carries the fields of the error it wraps
+	if got[1]["level"] != "error" || got[1]["attempt"] != float64(2) || got[1]["path"] != "/etc/app" {
+		t.Errorf("entry = %v", got[1])
+	}
+}
+
+func TestToFields(t *testing.T) {
+	got := toFields([]interface{}{"a", 1, F("b", 2), 3, "dangling"})
+	want := []Field{{"a", 1}, {"b", 2}, {"!BADKEY", 3}, {"!BADKEY", "dangling"}}
+	if len(got) != len(want) {
+		t.Fatalf("fields = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
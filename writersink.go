@@ -0,0 +1,88 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+const COLOR_RESET = "\x1b[0m"
+
+// Theme maps levels to the ANSI escape sequence their tag is written in.
+// Levels missing from it are written plain.
+type Theme map[LogType]string
+
+var DefaultTheme = Theme{
+	LOG_FATAL:   "\x1b[1;31m",
+	LOG_ERROR:   "\x1b[31m",
+	LOG_WARNING: "\x1b[33m",
+	LOG_INFO:    "\x1b[32m",
+	LOG_DEBUG:   "\x1b[90m",
+}
+
+// WriterSink writes entries in the text layout to any io.Writer, e.g. a
+// second file or a console, with its own flags and colors.
+type WriterSink struct {
+	Writer     io.Writer
+	Prefix     string
+	Flags      int
+	TimeFormat string
+	Theme      Theme
+
+	// JSON writes entries as JSON objects instead, one per line unless
+	// Indent is set. Indented output is meant for people reading a
+	// console; Decoder only reads the compact form.
+	JSON   bool
+	Indent string
+
+	lock sync.Mutex
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{Writer: w, Flags: LstdFlags}
+}
+
+func (s *WriterSink) ConcurrentSafe() bool {
+	return true
+}
+
+func (s *WriterSink) WriteEntry(e *Entry) error {
+	enc := textEncoder{prefix: s.Prefix, flags: s.Flags, timeFormat: s.TimeFormat, theme: s.Theme}
+
+	buf := getBuffer()
+	if s.JSON {
+		buf.b = encodeJSON(buf.b, e)
+		if s.Indent != "" {
+			buf.b = indentJSON(buf.b, s.Indent)
+		}
+	} else {
+		buf.b = enc.encode(buf.b, e)
+	}
+	_, err := s.Write(buf.b)
+	buf.Free()
+
+	return err
+}
+
+func (s *WriterSink) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.Writer.Write(p)
+}
+
+// Close closes the writer if it is an io.Closer, except for the standard
+// streams, which outlive the sink.
+func (s *WriterSink) Close() error {
+	if c, ok := s.Writer.(io.Closer); ok && !isStdStream(c) {
+		return c.Close()
+	}
+	return nil
+}
+
+// isStdStream reports whether v is os.Stdout or os.Stderr, which are
+// neither closed nor synced (syncing a terminal or a pipe fails).
+func isStdStream(v interface{}) bool {
+	f, ok := v.(*os.File)
+	return ok && (f == os.Stdout || f == os.Stderr)
+}
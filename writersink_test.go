@@ -0,0 +1,49 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloseKeepsStdStreams(t *testing.T) {
+	l := NewLogger(os.Stderr, "", 0)
+	l.AddSink(NewWriterSink(os.Stdout))
+	l.AddSink(NewWriterSink(os.Stderr))
+	err := l.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewAsyncWriter(os.Stderr, 0).Close()
+
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		if _, err := f.Stat(); err != nil {
+			t.Errorf("%s closed: %v", f.Name(), err)
+		}
+	}
+}
+
+func TestWriterSinkJSON(t *testing.T) {
+	e := &Entry{Level: LOG_INFO, Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Message: "ready", Fields: []Field{{"port", 80}}}
+	for _, indent := range []string{"", "  "} {
+		var b bytes.Buffer
+		s := NewWriterSink(&b)
+		s.JSON = true
+		s.Indent = indent
+		if err := s.WriteEntry(e); err != nil {
+			t.Fatal(err)
+		}
+
+		var m map[string]interface{}
+		if err := json.Unmarshal(b.Bytes(), &m); err != nil || m["message"] != "ready" || m["port"] != float64(80) {
+			t.Errorf("indent %q: %q (%v)", indent, b.String(), err)
+		}
+		lines := strings.Count(b.String(), "\n")
+		if indent == "" && lines != 1 || indent != "" && (lines < 3 || !strings.Contains(b.String(), "\n  \"level\"")) {
+			t.Errorf("indent %q: %d lines: %q", indent, lines, b.String())
+		}
+	}
+}